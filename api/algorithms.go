@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+type AlgorithmResponse struct {
+	Name               string            `json:"name"`
+	DefaultKeySize     int               `json:"default_key_size,omitempty"`
+	KeySizes           []int             `json:"key_sizes,omitempty"`
+	DefaultCurve       string            `json:"default_curve,omitempty"`
+	Curves             []string          `json:"curves,omitempty"`
+	Hashes             []string          `json:"hashes"`
+	DefaultHash        string            `json:"default_hash,omitempty"`
+	CurveHashes        map[string]string `json:"curve_hashes,omitempty"`
+	SignatureEncodings []string          `json:"signature_encodings"`
+	SignatureTag       string            `json:"signature_tag,omitempty"`
+}
+
+// ListAlgorithms handles GET /api/v0/algorithms to report the algorithms in the crypto
+// registry along with their parameters, so clients can discover capabilities at runtime.
+func (s *Server) ListAlgorithms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	algorithms := signingcrypto.Algorithms()
+	response := make([]AlgorithmResponse, 0, len(algorithms))
+	for _, info := range algorithms {
+		response = append(response, AlgorithmResponse{
+			Name:               info.Name,
+			DefaultKeySize:     info.DefaultKeySize,
+			KeySizes:           info.KeySizes,
+			DefaultCurve:       info.DefaultCurve,
+			Curves:             info.Curves,
+			Hashes:             info.Hashes,
+			DefaultHash:        info.DefaultHash,
+			CurveHashes:        info.CurveHashes,
+			SignatureEncodings: info.SignatureEncodings,
+			SignatureTag:       info.SignatureTag,
+		})
+	}
+
+	s.writeResponse(w, http.StatusOK, response)
+}
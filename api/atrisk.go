@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// DefaultAtRiskThreshold is the fraction of a device's signing quota left at or below which
+// the device is listed as at risk when the request omits "threshold".
+const DefaultAtRiskThreshold = 0.1
+
+// ListAtRiskDevices handles GET /api/v0/admin/devices/at-risk to list the devices close to
+// exhausting their signing quota, so operators can act before signing starts failing. The
+// optional "threshold" query parameter, a fraction between 0 and 1, sets how close.
+func (s *Server) ListAtRiskDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	threshold := DefaultAtRiskThreshold
+	if raw := r.URL.Query().Get("threshold"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(parsed >= 0 && parsed <= 1) {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{
+				"Query parameter 'threshold' must be a number between 0 and 1",
+			})
+			return
+		}
+		threshold = parsed
+	}
+
+	devices, err := s.signDeviceService.AtRiskDevices(threshold)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Failed to get at-risk devices",
+		})
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, devices)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/gorilla/mux"
+)
+
+// AttestSignature handles POST /api/v0/devices/{id}/attest to timestamp an external signature.
+// The device signs a digest of (external signature, timestamp, counter) as the next link in
+// its chain and returns the new signature with the digest and timestamp needed to verify it.
+// Devices requiring nonces need a fresh "nonce", which is bound into the signed data; a missing
+// or malformed one returns 400 and a reused one 409. Returns 403 if the pre-sign hook refuses
+// it and 429 once the device's signing quota is used up.
+func (s *Server) AttestSignature(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.AttestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if req.Signature == "" {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Signature is required",
+		})
+		return
+	}
+
+	opts := req.ToOptions()
+	opts.DeviceID = mux.Vars(r)["id"]
+	resp, err := s.signDeviceService.AttestSignature(opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeviceNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		case errors.Is(err, domain.ErrVerifyOnly):
+			writeVerifyOnlyError(w)
+		case errors.Is(err, domain.ErrDeviceLocked):
+			writeLockedError(w)
+		case errors.Is(err, domain.ErrInvalidNonce):
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		case errors.Is(err, domain.ErrNonceReused):
+			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+		case errors.Is(err, domain.ErrSignDenied):
+			WriteErrorResponse(w, http.StatusForbidden, []string{err.Error()})
+		case errors.Is(err, domain.ErrQuotaExceeded):
+			w.Header().Set(QuotaRemainingHeader, "0")
+			WriteErrorResponse(w, http.StatusTooManyRequests, []string{err.Error()})
+		case errors.Is(err, domain.ErrReadOnly):
+			writeReadOnlyError(w)
+		case errors.Is(err, domain.ErrSigningDisabled):
+			writeDisabledError(w, err)
+		case errors.Is(err, domain.ErrSigningCapacity):
+			WriteErrorResponse(w, http.StatusServiceUnavailable, []string{
+				"Signing capacity exhausted, retry later",
+			})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to attest signature",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, resp)
+}
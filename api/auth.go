@@ -0,0 +1,55 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the client's API key when authentication is enabled.
+const APIKeyHeader = "X-API-Key"
+
+// DefaultAuthBypassPrefixes are the paths reachable without an API key, so load balancers
+// can probe the service.
+var DefaultAuthBypassPrefixes = []string{"/api/v0/health", "/api/v0/live", "/api/v0/ready"}
+
+// apiKeyAuth holds the accepted keys and the path prefixes exempt from authentication.
+type apiKeyAuth struct {
+	keys           [][]byte
+	bypassPrefixes []string
+}
+
+// authenticated reports whether key matches one of the accepted keys. Every key is compared
+// in constant time so the response time does not reveal partial matches.
+func (a *apiKeyAuth) authenticated(key string) bool {
+	match := 0
+	for _, accepted := range a.keys {
+		match |= subtle.ConstantTimeCompare(accepted, []byte(key))
+	}
+	return match == 1
+}
+
+// bypassed reports whether path falls under one of the unauthenticated prefixes. Prefixes
+// match whole path segments: /api/v0/health covers /api/v0/health/db but not /api/v0/healthz.
+func (a *apiKeyAuth) bypassed(path string) bool {
+	for _, prefix := range a.bypassPrefixes {
+		prefix = strings.TrimRight(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware rejects requests without a valid API key with 401, except for bypassed paths.
+func authMiddleware(auth *apiKeyAuth, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.bypassed(r.URL.Path) && !auth.authenticated(r.Header.Get(APIKeyHeader)) {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{
+				"Missing or invalid API key",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
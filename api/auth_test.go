@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/persistence"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	newHandler := func(opts ...ServerOption) http.Handler {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		return NewServer(":8080", service, opts...).Handler()
+	}
+	request := func(handler http.Handler, path, key string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("health is reachable without a key", func(t *testing.T) {
+		handler := newHandler(WithAPIKeys([]string{"secret"}))
+
+		if code := request(handler, "/api/v0/health", ""); code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("devices require a key", func(t *testing.T) {
+		handler := newHandler(WithAPIKeys([]string{"secret"}))
+
+		if code := request(handler, "/api/v0/devices", ""); code != http.StatusUnauthorized {
+			t.Errorf("expected status %d without key, got %d", http.StatusUnauthorized, code)
+		}
+		if code := request(handler, "/api/v0/devices", "wrong"); code != http.StatusUnauthorized {
+			t.Errorf("expected status %d with wrong key, got %d", http.StatusUnauthorized, code)
+		}
+		if code := request(handler, "/api/v0/devices", "secret"); code != http.StatusOK {
+			t.Errorf("expected status %d with key, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("bypass prefixes match whole segments", func(t *testing.T) {
+		handler := newHandler(WithAPIKeys([]string{"secret"}))
+
+		if code := request(handler, "/api/v0/healthz", ""); code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, code)
+		}
+	})
+
+	t.Run("custom bypass list replaces the default", func(t *testing.T) {
+		handler := newHandler(WithAuthBypass([]string{"/api/v0/version"}), WithAPIKeys([]string{"secret"}))
+
+		if code := request(handler, "/api/v0/version", ""); code != http.StatusOK {
+			t.Errorf("expected status %d for bypassed path, got %d", http.StatusOK, code)
+		}
+		if code := request(handler, "/api/v0/health", ""); code != http.StatusUnauthorized {
+			t.Errorf("expected status %d for health, got %d", http.StatusUnauthorized, code)
+		}
+	})
+
+	t.Run("disabled without keys", func(t *testing.T) {
+		handler := newHandler()
+
+		if code := request(handler, "/api/v0/devices", ""); code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, code)
+		}
+	})
+}
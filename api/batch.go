@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+// MaxBatchGetIDs caps the number of device IDs accepted by a single batch get request.
+const MaxBatchGetIDs = 100
+
+// BatchGetDevices handles POST /api/v0/devices/batch-get to fetch several devices at once.
+// Accepts {"ids": [...]} and returns an object mapping each ID to its device info, or to null
+// when the device does not exist or belongs to another tenant.
+func (s *Server) BatchGetDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.BatchGetDevicesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if len(req.IDs) > MaxBatchGetIDs {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			fmt.Sprintf("Batch exceeds maximum of %d IDs", MaxBatchGetIDs),
+		})
+		return
+	}
+
+	devices, err := s.signDeviceService.GetDevices(req.IDs)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Failed to get devices",
+		})
+		return
+	}
+
+	tenant := requestTenant(r)
+	response := make(map[string]*model.DeviceResponse, len(devices))
+	for id, device := range devices {
+		// Devices of other tenants are reported like missing ones.
+		if device == nil || (tenant != "" && device.TenantID != tenant) {
+			response[id] = nil
+			continue
+		}
+		deviceResponse := device.ToResponse()
+		response[id] = &deviceResponse
+	}
+	s.writeResponse(w, http.StatusOK, response)
+}
+
+// MaxBulkDeleteIDs caps the number of device IDs accepted by a single bulk delete request.
+const MaxBulkDeleteIDs = 100
+
+// BulkDeleteDevices handles POST /api/v0/devices/bulk-delete to delete several devices at once.
+// Accepts {"ids": [...]} and returns one result per ID, in request order, with status
+// "deleted" or "not_found". Devices of other tenants are reported as not found.
+func (s *Server) BulkDeleteDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.BulkDeleteDevicesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if len(req.IDs) == 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"ids is required"})
+		return
+	}
+	if len(req.IDs) > MaxBulkDeleteIDs {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			fmt.Sprintf("Batch exceeds maximum of %d IDs", MaxBulkDeleteIDs),
+		})
+		return
+	}
+
+	results, err := s.signDeviceService.DeleteDevices(requestTenant(r), req.IDs)
+	if err != nil {
+		if errors.Is(err, domain.ErrReadOnly) {
+			writeReadOnlyError(w)
+			return
+		}
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Failed to delete devices",
+		})
+		return
+	}
+	s.writeResponse(w, http.StatusOK, results)
+}
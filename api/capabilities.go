@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// DeviceCapabilities handles GET /api/v0/devices/{id}/capabilities to report the operations
+// the device supports, so clients can discover them per device. Returns 404 if the device does
+// not exist.
+func (s *Server) DeviceCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	capabilities, err := s.signDeviceService.DeviceCapabilities(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to get device capabilities",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, capabilities)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+// CompareDevices handles GET /api/v0/devices/compare?a=id1&b=id2 to check whether two devices
+// share key material, e.g. during key migrations. Returns 404 if either device does not exist
+// or belongs to another tenant.
+func (s *Server) CompareDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	idA := r.URL.Query().Get("a")
+	idB := r.URL.Query().Get("b")
+	if idA == "" || idB == "" {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Query parameters a and b are required",
+		})
+		return
+	}
+
+	tenant := requestTenant(r)
+	_, err := s.signDeviceService.GetTenantDevice(tenant, idA)
+	if err == nil {
+		_, err = s.signDeviceService.GetTenantDevice(tenant, idB)
+	}
+	var same bool
+	if err == nil {
+		same, err = s.signDeviceService.SamePublicKey(idA, idB)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to compare devices",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, model.CompareDevicesResponse{SamePublicKey: same})
+}
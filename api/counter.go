@@ -0,0 +1,94 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// GetCounter handles GET /api/v0/devices/{id}/counter to return only the chain head:
+// {"counter": N, "last_signature": "..."}. The head doubles as an ETag, so pollers sending
+// If-None-Match get 304 until the device signs again. Returns 404 if the device does not exist.
+func (s *Server) GetCounter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	head, err := s.signDeviceService.ChainHead(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to get device counter",
+			})
+		}
+		return
+	}
+
+	etag := chainHeadETag(head.Counter, head.LastSignature)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, head)
+}
+
+// chainHeadETag derives the ETag of a chain head from both its counter and last_signature, as
+// a recreated or migrated device can reach the same counter with a different chain.
+func chainHeadETag(counter int64, lastSignature string) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(counter, 10) + "_" + lastSignature))
+	return strconv.Quote(hex.EncodeToString(sum[:8]))
+}
+
+// DefaultChainTipsLimit and MaxChainTipsLimit bound the page size of GET /api/v0/chains.
+const (
+	DefaultChainTipsLimit = 100
+	MaxChainTipsLimit     = 1000
+)
+
+// ListChainTips handles GET /api/v0/chains to return the chain head of every device of the
+// request's tenant, ordered by ID: [{"id": "...", "counter": N, "last_signature": "..."}].
+// Pages hold ?limit=... devices (default 100, at most 1000); the response's "next" cursor is
+// passed as ?after=... to fetch the following page. Returns 400 for an invalid limit.
+func (s *Server) ListChainTips(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	limit := DefaultChainTipsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > MaxChainTipsLimit {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{
+				"Query parameter 'limit' must be an integer between 1 and " + strconv.Itoa(MaxChainTipsLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	page, err := s.signDeviceService.ChainTips(requestTenant(r), r.URL.Query().Get("after"), limit)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Failed to list chain tips",
+		})
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, page)
+}
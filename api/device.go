@@ -2,16 +2,23 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/bayuhutajulu/signing-service/domain"
 	"github.com/bayuhutajulu/signing-service/model"
 	"github.com/gorilla/mux"
 )
 
 // CreateDevice handles POST /api/v0/devices to create a new signature device.
 // Validates the request, creates the device with key pair generation, and returns
-// device info (hiding private keys). Returns 409 if device ID already exists.
+// device info (hiding private keys) with its initial last_signature. Returns 409 if
+// device ID already exists, 400 if it violates the configured ID policy and 503 if the
+// key generation queue is full.
 func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
@@ -28,28 +35,58 @@ func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	device, err := s.signDeviceService.CreateDevice(req.ToOptions())
+	opts := req.ToOptions()
+	tenant, err := createTenant(r, opts.TenantID)
 	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
-		} else {
-			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
-		}
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+	opts.TenantID = tenant
+
+	device, err := s.signDeviceService.CreateDevice(opts)
+	if err != nil {
+		writeCreateDeviceError(w, tenant, err)
 		return
 	}
 
-	response := model.DeviceResponse{
-		ID:               device.ID,
-		Label:            device.Label,
-		Algorithm:        device.Algorithm,
-		SignatureCounter: device.SignatureCounter,
+	response := device.ToCreateResponse()
+	s.writeResponse(w, http.StatusCreated, response)
+}
+
+// writeCreateDeviceError maps a CreateDevice failure to its response: 409 for an existing ID,
+// 400 for invalid IDs, labels or imported keys, 403 on read-only replicas and 503 when creation is
+// disabled or key generation is saturated. Device IDs share one namespace across tenants, so for
+// a tenant-scoped request an existing ID gets the same generic 409 whichever tenant holds it.
+func writeCreateDeviceError(w http.ResponseWriter, tenant string, err error) {
+	if strings.Contains(err.Error(), "already exists") && tenant != "" {
+		WriteErrorResponse(w, http.StatusConflict, []string{"Device ID is not available"})
+	} else if strings.Contains(err.Error(), "already exists") {
+		WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+	} else if errors.Is(err, domain.ErrInvalidDeviceID) || errors.Is(err, domain.ErrInvalidLabel) ||
+		errors.Is(err, domain.ErrInvalidPublicKey) || errors.Is(err, domain.ErrInvalidJWK) ||
+		errors.Is(err, domain.ErrInvalidQuota) || errors.Is(err, domain.ErrInvalidHash) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+	} else if errors.Is(err, domain.ErrReadOnly) {
+		writeReadOnlyError(w)
+	} else if errors.Is(err, domain.ErrDeviceCreationDisabled) {
+		writeDisabledError(w, err)
+	} else if errors.Is(err, domain.ErrKeyGenQueueFull) {
+		WriteErrorResponse(w, http.StatusServiceUnavailable, []string{
+			"Key generation capacity exhausted, retry later",
+		})
+	} else {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
 	}
-	WriteAPIResponse(w, http.StatusCreated, response)
 }
 
+// QuotaRemainingHeader reports the signatures a device with a signing quota has left in its
+// current window.
+const QuotaRemainingHeader = "X-Quota-Remaining"
+
 // SignData handles POST /api/v0/devices/{id}/sign to create a signature with chaining.
 // Extracts device ID from URL path, signs the data using signature chaining format,
-// and returns the signature with signed data string.
+// and returns the signature with signed data string. Devices with a signing quota report
+// the quota left in the X-Quota-Remaining header and get 429 once it is used up.
 func (s *Server) SignData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
@@ -58,6 +95,13 @@ func (s *Server) SignData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Fields are validated before signing, so a bad selection never consumes a counter.
+	fields, err := parseFields(r, model.SignDataResponse{})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+
 	var req model.SignDataRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		WriteErrorResponse(w, http.StatusBadRequest, []string{
@@ -68,15 +112,61 @@ func (s *Server) SignData(w http.ResponseWriter, r *http.Request) {
 
 	opt := req.ToOptions()
 	opt.DeviceID = mux.Vars(r)["id"]
-	resp, err := s.signDeviceService.SignData(opt)
+	resp, err := s.signDeviceService.SignDataContext(r.Context(), opt)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, []string{
-			"Failed to sign data",
-		})
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{
+				"Device not found",
+			})
+		} else if errors.Is(err, domain.ErrSigningCapacity) {
+			WriteErrorResponse(w, http.StatusServiceUnavailable, []string{
+				"Signing capacity exhausted, retry later",
+			})
+		} else if errors.Is(err, domain.ErrUnsupportedFormat) || errors.Is(err, domain.ErrDataTooLarge) ||
+			errors.Is(err, domain.ErrEmptyData) || errors.Is(err, domain.ErrInvalidNonce) ||
+			errors.Is(err, domain.ErrInvalidOperationID) || errors.Is(err, domain.ErrInvalidExpiry) ||
+			errors.Is(err, domain.ErrInvalidAAD) {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		} else if errors.Is(err, domain.ErrVerifyOnly) {
+			writeVerifyOnlyError(w)
+		} else if errors.Is(err, domain.ErrDeviceLocked) {
+			writeLockedError(w)
+		} else if errors.Is(err, domain.ErrDuplicateData) || errors.Is(err, domain.ErrNonceReused) ||
+			errors.Is(err, domain.ErrCounterMismatch) || errors.Is(err, domain.ErrOperationConflict) {
+			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+		} else if errors.Is(err, domain.ErrSignDenied) {
+			WriteErrorResponse(w, http.StatusForbidden, []string{err.Error()})
+		} else if errors.Is(err, domain.ErrQuotaExceeded) {
+			w.Header().Set(QuotaRemainingHeader, "0")
+			WriteErrorResponse(w, http.StatusTooManyRequests, []string{err.Error()})
+		} else if errors.Is(err, domain.ErrReadOnly) {
+			writeReadOnlyError(w)
+		} else if errors.Is(err, domain.ErrSigningDisabled) {
+			writeDisabledError(w, err)
+		} else if errors.Is(err, domain.ErrSignAborted) {
+			WriteErrorResponse(w, http.StatusServiceUnavailable, []string{
+				"Signing aborted: request cancelled or timed out",
+			})
+		} else if errors.Is(err, domain.ErrSignFailed) {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to sign data: signer error",
+			})
+		} else if errors.Is(err, domain.ErrStorageFailure) {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to sign data: could not store device state",
+			})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to sign data",
+			})
+		}
 		return
 	}
 
-	WriteAPIResponse(w, http.StatusOK, resp)
+	if resp.QuotaRemaining != nil {
+		w.Header().Set(QuotaRemainingHeader, strconv.Itoa(*resp.QuotaRemaining))
+	}
+	s.writeSparseResponse(w, http.StatusOK, resp, fields)
 }
 
 // GetDevice handles GET /api/v0/devices/{id} to retrieve a single device by ID.
@@ -96,6 +186,11 @@ func (s *Server) GetDevice(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	fields, err := parseFields(r, model.DeviceResponse{})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
 
 	device, err := s.signDeviceService.GetDevice(deviceID)
 	if err != nil {
@@ -105,17 +200,46 @@ func (s *Server) GetDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := model.DeviceResponse{
-		ID:               device.ID,
-		Label:            device.Label,
-		Algorithm:        device.Algorithm,
-		SignatureCounter: device.SignatureCounter,
+	response := device.ToResponse()
+	s.writeSparseResponse(w, http.StatusOK, response, fields)
+}
+
+// parseDeviceFilter reads the device listing filter from the query. Counter bounds must be
+// non-negative integers with min_counter not above max_counter.
+func parseDeviceFilter(r *http.Request) (model.DeviceFilter, error) {
+	query := r.URL.Query()
+	filter := model.DeviceFilter{Algorithm: query.Get("algorithm")}
+	for _, bound := range []struct {
+		name   string
+		target **int64
+	}{{"min_counter", &filter.MinCounter}, {"max_counter", &filter.MaxCounter}} {
+		raw := query.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			return filter, fmt.Errorf("Query parameter '%s' must be a non-negative integer", bound.name)
+		}
+		*bound.target = &parsed
+	}
+	if filter.MinCounter != nil && filter.MaxCounter != nil && *filter.MinCounter > *filter.MaxCounter {
+		return filter, errors.New("Query parameter 'min_counter' must not exceed 'max_counter'")
 	}
-	WriteAPIResponse(w, http.StatusOK, response)
+	return filter, nil
 }
 
+// DefaultMaxListDevices is the default cap on devices returned by GetAllDevices.
+const DefaultMaxListDevices = 1000
+
+// TruncatedHeader is set to "true" on list responses cut short at the server's cap.
+const TruncatedHeader = "X-Truncated"
+
 // GetAllDevices handles GET /api/v0/devices to list all signature devices.
-// Returns array of device info (without private keys). Returns empty array if no devices exist.
+// Returns array of device info (without private keys), limited to the request's tenant.
+// ?algorithm=, ?min_counter= and ?max_counter= (inclusive) narrow the list and combine.
+// Returns empty array if no devices exist. Lists longer than the configured cap are cut to
+// the first devices by ID and flagged with "truncated": true.
 func (s *Server) GetAllDevices(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
@@ -124,22 +248,44 @@ func (s *Server) GetAllDevices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	devices, err := s.signDeviceService.GetAllDevices()
+	fields, err := parseFields(r, model.DeviceResponse{})
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+	filter, err := parseDeviceFilter(r)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+
+	devices, err := s.signDeviceService.GetTenantDevices(requestTenant(r))
 	if err != nil {
 		WriteErrorResponse(w, http.StatusInternalServerError, []string{
 			"Failed to get all devices",
 		})
 		return
 	}
+	devices = domain.FilterDevices(devices, filter)
+	truncated := s.maxListDevices > 0 && len(devices) > s.maxListDevices
+	if truncated {
+		// Storage order is arbitrary; sort so the same devices are kept on every request.
+		sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+		devices = devices[:s.maxListDevices]
+	}
 
 	responses := make([]model.DeviceResponse, len(devices))
 	for i, device := range devices {
-		responses[i] = model.DeviceResponse{
-			ID:               device.ID,
-			Label:            device.Label,
-			Algorithm:        device.Algorithm,
-			SignatureCounter: device.SignatureCounter,
-		}
+		responses[i] = device.ToResponse()
+	}
+	if !truncated {
+		s.writeSparseResponse(w, http.StatusOK, responses, fields)
+		return
+	}
+	sparse, err := sparseData(responses, fields)
+	if err != nil {
+		WriteInternalError(w)
+		return
 	}
-	WriteAPIResponse(w, http.StatusOK, responses)
+	s.writeTruncatedResponse(w, http.StatusOK, sparse)
 }
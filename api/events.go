@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/websocket"
+)
+
+// eventBufferSize bounds the events queued per streaming client. Events for a client whose
+// buffer is full are dropped rather than slowing down signing.
+const eventBufferSize = 64
+
+// eventWriteTimeout bounds how long a single frame may take to reach a client.
+const eventWriteTimeout = 10 * time.Second
+
+type EventResponse struct {
+	Type      string    `json:"type"`
+	DeviceID  string    `json:"device_id"`
+	Counter   int64     `json:"counter"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func toEventResponse(event domain.SignEvent) EventResponse {
+	return EventResponse{
+		Type:      event.Type,
+		DeviceID:  event.DeviceID,
+		Counter:   event.Counter,
+		Timestamp: event.Timestamp,
+	}
+}
+
+var upgrader = websocket.Upgrader{}
+
+// EventsWebSocket handles GET /api/v0/events, upgrading to a WebSocket that streams device
+// events as JSON frames until the client disconnects.
+func (s *Server) EventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response.
+		return
+	}
+	defer conn.Close()
+
+	events := make(chan domain.SignEvent, eventBufferSize)
+	unsubscribe := s.signDeviceService.Subscribe(events)
+	defer unsubscribe()
+
+	// Clients only listen; reading is needed to notice when they go away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(toEventResponse(event)); err != nil {
+				log.Printf("event stream to %s closed: %v", r.RemoteAddr, err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// EventsStream handles GET /api/v0/events/stream, pushing device events as server-sent
+// events (text/event-stream). Each event is flushed immediately; the subscription ends when
+// the client disconnects. A ": connected" comment is sent once the subscription is active.
+func (s *Server) EventsStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Streaming is not supported",
+		})
+		return
+	}
+
+	events := make(chan domain.SignEvent, eventBufferSize)
+	unsubscribe := s.signDeviceService.Subscribe(events)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case event := <-events:
+			data, err := json.Marshal(toEventResponse(event))
+			if err != nil {
+				log.Printf("failed to encode event: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
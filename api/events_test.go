@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/gorilla/websocket"
+)
+
+func TestEventsWebSocket(t *testing.T) {
+	t.Run("streams sign event to connected client", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-ws-001", Algorithm: "ECC"})
+
+		httpServer := httptest.NewServer(server.Handler())
+		defer httpServer.Close()
+
+		url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/v0/events"
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+
+		// The subscription is registered after the upgrade; retry signing until the event arrives.
+		received := make(chan EventResponse, 1)
+		go func() {
+			var event EventResponse
+			if err := conn.ReadJSON(&event); err == nil {
+				received <- event
+			}
+		}()
+
+		deadline := time.After(2 * time.Second)
+		for {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			select {
+			case event := <-received:
+				if event.Type != domain.EventTypeSign || event.DeviceID != device.ID {
+					t.Errorf("unexpected event %+v", event)
+				}
+				return
+			case <-time.After(20 * time.Millisecond):
+			case <-deadline:
+				t.Fatal("expected sign event frame")
+			}
+		}
+	})
+}
+
+func TestEventsStream(t *testing.T) {
+	t.Run("pushes sign events as SSE frames", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-sse-001", Algorithm: "ECC"})
+
+		httpServer := httptest.NewServer(server.Handler())
+		defer httpServer.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/api/v0/events/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("expected text/event-stream, got %s", ct)
+		}
+
+		reader := bufio.NewReader(resp.Body)
+		if line, _ := reader.ReadString('\n'); line != ": connected\n" {
+			t.Fatalf("expected connected comment, got %q", line)
+		}
+		reader.ReadString('\n')
+
+		for i := 0; i < 2; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		}
+
+		for i := 0; i < 2; i++ {
+			eventLine, _ := reader.ReadString('\n')
+			dataLine, _ := reader.ReadString('\n')
+			reader.ReadString('\n')
+
+			if eventLine != "event: sign\n" {
+				t.Fatalf("expected sign event, got %q", eventLine)
+			}
+			var event EventResponse
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(dataLine), "data: ")), &event); err != nil {
+				t.Fatalf("expected JSON data, got %q", dataLine)
+			}
+			if event.DeviceID != device.ID || event.Counter != int64(i) {
+				t.Errorf("expected counter %d for %s, got %+v", i, device.ID, event)
+			}
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// csvFlushInterval is the number of rows written between flushes of the CSV export.
+const csvFlushInterval = 100
+
+// csvFormulaPrefixes start cells that spreadsheets evaluate as formulas.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText neutralizes a client-supplied value for a CSV cell: a value a spreadsheet would run
+// as a formula is prefixed with a single quote, so it is shown as text.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ExportSignaturesCSV handles GET /api/v0/devices/{id}/signatures.csv to download the device's
+// signature history as CSV (counter, timestamp, data, signature). Rows are streamed to the
+// client as they are encoded. Data that a spreadsheet would run as a formula is prefixed with a
+// single quote. Returns 404 if the device does not exist.
+func (s *Server) ExportSignaturesCSV(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	history, err := s.signDeviceService.SignatureHistory(deviceID)
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to export signatures",
+			})
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", deviceID+"-signatures.csv"))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"counter", "timestamp", "data", "signature"})
+	for i, record := range history {
+		writer.Write([]string{
+			strconv.FormatInt(record.Counter, 10),
+			record.SignedAt.UTC().Format(time.RFC3339Nano),
+			csvText(record.Data),
+			record.Signature,
+		})
+		if (i+1)%csvFlushInterval == 0 {
+			writer.Flush()
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+	}
+	writer.Flush()
+}
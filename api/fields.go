@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// parseFields reads the "fields" query parameter: a comma-separated list of the JSON field
+// names of response to return, e.g. "?fields=signature,signed_data". It returns nil when the
+// parameter is absent, and an error for an empty list or a name response does not have.
+func parseFields(r *http.Request, response interface{}) ([]string, error) {
+	raw, ok := r.URL.Query()["fields"]
+	if !ok {
+		return nil, nil
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(response))
+	fields := []string{}
+	for _, name := range strings.Split(strings.Join(raw, ","), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("fields must name at least one field")
+	}
+	return fields, nil
+}
+
+// jsonFieldNames returns the JSON names of the fields of struct type t.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// writeSparseResponse writes data, an object or a slice of objects, like writeResponse,
+// keeping only the given fields of each object. Nil fields write data unchanged.
+func (s *Server) writeSparseResponse(w http.ResponseWriter, code int, data interface{}, fields []string) {
+	sparse, err := sparseData(data, fields)
+	if err != nil {
+		WriteInternalError(w)
+		return
+	}
+	s.writeResponse(w, code, sparse)
+}
+
+// sparseData returns data, an object or a slice of objects, keeping only the given fields of
+// each object. Nil fields return data unchanged.
+func sparseData(data interface{}, fields []string) (interface{}, error) {
+	if fields == nil {
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers stay json.Number so 64-bit counters survive the round trip.
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	switch value := decoded.(type) {
+	case map[string]interface{}:
+		return selectFields(value, fields), nil
+	case []interface{}:
+		for i, element := range value {
+			if object, ok := element.(map[string]interface{}); ok {
+				value[i] = selectFields(object, fields)
+			}
+		}
+		return value, nil
+	default:
+		return data, nil
+	}
+}
+
+// selectFields returns the subset of object holding fields. Fields omitted from the object
+// (empty omitempty fields) stay omitted.
+func selectFields(object map[string]interface{}, fields []string) map[string]interface{} {
+	selected := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		if value, ok := object[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSparseFields(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "fields-001", Label: "Fields", Algorithm: "ECC"}); err != nil {
+		t.Fatalf("failed to create device: %v", err)
+	}
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder, data interface{}) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		response := struct {
+			Data interface{} `json:"data"`
+		}{Data: data}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+
+	t.Run("sign returns only the signature", func(t *testing.T) {
+		var data map[string]interface{}
+		decode(t, serve(http.MethodPost, "/api/v0/devices/fields-001/sign?fields=signature", `{"data":"payload"}`), &data)
+
+		if len(data) != 1 || data["signature"] == "" || data["signature"] == nil {
+			t.Errorf("expected only signature, got %v", data)
+		}
+	})
+
+	t.Run("device endpoints select fields", func(t *testing.T) {
+		var device map[string]interface{}
+		decode(t, serve(http.MethodGet, "/api/v0/devices/fields-001?fields=id,signature_counter", ""), &device)
+		if len(device) != 2 || device["id"] != "fields-001" || device["signature_counter"] != float64(1) {
+			t.Errorf("expected id and signature_counter only, got %v", device)
+		}
+
+		var devices []map[string]interface{}
+		decode(t, serve(http.MethodGet, "/api/v0/devices?fields=label", ""), &devices)
+		if len(devices) != 1 || len(devices[0]) != 1 || devices[0]["label"] != "Fields" {
+			t.Errorf("expected label only, got %v", devices)
+		}
+	})
+
+	t.Run("unknown field is rejected before signing", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v0/devices/fields-001/sign?fields=signature,bogus", `{"data":"payload"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if head, _ := service.ChainHead("fields-001"); head.Counter != 1 {
+			t.Errorf("expected counter to stay at 1, got %d", head.Counter)
+		}
+		if w := serve(http.MethodGet, "/api/v0/devices/fields-001?fields=", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for empty fields, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("without fields the full response is returned", func(t *testing.T) {
+		var device map[string]interface{}
+		decode(t, serve(http.MethodGet, "/api/v0/devices/fields-001", ""), &device)
+		if _, ok := device["algorithm"]; !ok {
+			t.Errorf("expected full device, got %v", device)
+		}
+	})
+}
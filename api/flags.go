@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// GetFeatureFlags handles GET /api/v0/admin/flags to report the runtime feature flags.
+func (s *Server) GetFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, s.signDeviceService.FeatureFlags())
+}
+
+// UpdateFeatureFlags handles POST /api/v0/admin/flags to toggle features at runtime, e.g.
+// to stop all signing during an incident. Flags omitted from the body keep their value.
+func (s *Server) UpdateFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var update model.FeatureFlagsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if update.IsEmpty() {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"At least one of signing or device_creation must be set",
+		})
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, s.signDeviceService.UpdateFeatureFlags(update))
+}
@@ -21,5 +21,5 @@ func (s *Server) Health(response http.ResponseWriter, request *http.Request) {
 		Version: "v0",
 	}
 
-	WriteAPIResponse(response, http.StatusOK, health)
+	s.writeResponse(response, http.StatusOK, health)
 }
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+// unsignedPathPrefixes and unsignedPathSuffixes match responses that are streamed and therefore
+// never buffered for response signing.
+var (
+	unsignedPathPrefixes = []string{"/api/v0/events"}
+	unsignedPathSuffixes = []string{"/signatures.csv"}
+)
+
+// unsignedPath reports whether responses to path are streamed unsigned.
+func unsignedPath(path string) bool {
+	for _, prefix := range unsignedPathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range unsignedPathSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter holds back the status and body so they can be signed before sending.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// responseSigningMiddleware buffers each response and sends it with an X-Response-Signature
+// header over the request method and target, the status, the Content-Type and the body, so
+// clients holding the server's public key can detect tampering in transit, and a signed response
+// cannot be replayed for another request or status. If signing fails the response is replaced
+// by a 500 rather than sent unsigned.
+func responseSigningMiddleware(signer *signingcrypto.ResponseSigner, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unsignedPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buffered := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(buffered, r)
+		if buffered.status == 0 {
+			buffered.status = http.StatusOK
+		}
+
+		header, err := signer.Header(signingcrypto.SignedResponse{
+			Method:      r.Method,
+			Target:      r.URL.RequestURI(),
+			Status:      buffered.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        buffered.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("failed to sign response to %s %s: %v", r.Method, r.URL.Path, err)
+			WriteInternalError(w)
+			return
+		}
+		w.Header().Set(signingcrypto.ResponseSignatureHeader, header)
+		w.WriteHeader(buffered.status)
+		w.Write(buffered.body.Bytes())
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// CounterIntegrity handles GET /api/v0/devices/{id}/integrity to check the device's history
+// for missing counters. Returns {"ok": bool, "missing": [...]}, or 404 if the device does not exist.
+func (s *Server) CounterIntegrity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	report, err := s.signDeviceService.VerifyCounterIntegrity(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to verify counter integrity",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, report)
+}
+
+// SelfVerify handles POST /api/v0/devices/{id}/self-verify to re-verify every signature in
+// the device's stored history and its chain linkage. Returns the number of records verified
+// and any failures, or 404 if the device does not exist.
+func (s *Server) SelfVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	report, err := s.signDeviceService.SelfVerify(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to verify device history",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, report)
+}
+
+// SelfTest handles GET /api/v0/selftest, a deep health check signing and verifying a fixed
+// payload with throwaway RSA and ECC keys. Reports pass/fail and timing per algorithm, with
+// 503 if any algorithm failed. No device is stored.
+func (s *Server) SelfTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	report := s.signDeviceService.SelfTest()
+	code := http.StatusOK
+	if !report.OK {
+		code = http.StatusServiceUnavailable
+	}
+	s.writeResponse(w, code, report)
+}
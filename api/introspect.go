@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+// IntrospectSignature handles POST /api/v0/introspect to examine a signature of unknown
+// provenance. Accepts {"signed_data", "signature", "kid"} and reports the chain segments of
+// signed_data and which of the tenant's devices, if any, produced the signature; "kid" limits
+// the search to one device and is required once the tenant has more devices than the service
+// scans. Returns 400 for missing inputs, an undecodable signature or a missing required kid,
+// and 404 if the kid device does not exist.
+func (s *Server) IntrospectSignature(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.IntrospectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+
+	opts := req.ToOptions()
+	opts.TenantID = requestTenant(r)
+	report, err := s.signDeviceService.Introspect(opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidIntrospection):
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		case errors.Is(err, domain.ErrDeviceNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to introspect signature",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, report)
+}
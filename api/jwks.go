@@ -0,0 +1,266 @@
+package api
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+const (
+	// JWKSFetchTimeout bounds a remote JWKS fetch, including reading the body.
+	JWKSFetchTimeout = 5 * time.Second
+	// JWKSCacheTTL is how long a fetched JWKS is reused before it is fetched again.
+	JWKSCacheTTL = 5 * time.Minute
+	// JWKSCacheSize caps the number of cached key sets; the least recently used is evicted.
+	JWKSCacheSize = 100
+	// maxJWKSSize caps the size of a remote JWKS document.
+	maxJWKSSize = 1 << 20
+)
+
+// errJWKSHostNotAllowed is returned for a jwks_url outside the configured host allowlist.
+var errJWKSHostNotAllowed = errors.New("jwks_url must be an https URL on an allowed host")
+
+type jwksCacheEntry struct {
+	url     string
+	keys    map[string]interface{}
+	expires time.Time
+}
+
+// jwksCache fetches remote key sets with a timeout and keeps up to size of them for a TTL.
+// Only https URLs on an allowed host are fetched, and redirects are not followed, so callers
+// cannot point the service at arbitrary hosts. With no allowed hosts remote sets are refused.
+type jwksCache struct {
+	client  *http.Client
+	ttl     time.Duration
+	size    int
+	hosts   map[string]bool // Lowercase host[:port] values jwks_url may name
+	now     func() time.Time
+	mu      sync.Mutex
+	order   *list.List // Front is the most recently used entry
+	entries map[string]*list.Element
+}
+
+func newJWKSCache(timeout, ttl time.Duration, size int) *jwksCache {
+	return &jwksCache{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		ttl:     ttl,
+		size:    size,
+		hosts:   map[string]bool{},
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// allowed reports whether jwksURL may be fetched.
+func (c *jwksCache) allowed(jwksURL *url.URL) bool {
+	return jwksURL.Scheme == "https" && jwksURL.User == nil && c.hosts[strings.ToLower(jwksURL.Host)]
+}
+
+// keys returns the key set published at jwksURL, fetching it unless a fresh copy is cached.
+func (c *jwksCache) keys(jwksURL string) (map[string]interface{}, error) {
+	c.mu.Lock()
+	if element, ok := c.entries[jwksURL]; ok {
+		entry := element.Value.(*jwksCacheEntry)
+		if c.now().Before(entry.expires) {
+			c.order.MoveToFront(element)
+			c.mu.Unlock()
+			return entry.keys, nil
+		}
+		c.order.Remove(element)
+		delete(c.entries, jwksURL)
+	}
+	c.mu.Unlock()
+
+	resp, err := c.client.Get(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxJWKSSize {
+		return nil, fmt.Errorf("JWKS exceeds %d bytes", maxJWKSSize)
+	}
+	keys, err := signingcrypto.ParseJWKS(body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(jwksURL, keys)
+	return keys, nil
+}
+
+// put caches keys for jwksURL, evicting the least recently used set when full.
+func (c *jwksCache) put(jwksURL string, keys map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &jwksCacheEntry{url: jwksURL, keys: keys, expires: c.now().Add(c.ttl)}
+	if element, ok := c.entries[jwksURL]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[jwksURL] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*jwksCacheEntry).url)
+	}
+}
+
+// VerifyJWKS handles POST /api/v0/verify/jwks to verify a signature over data with the key
+// identified by kid in a JSON Web Key Set, supplied inline as "jwks" or fetched from "jwks_url",
+// which must be an https URL on a host allowed with WithJWKSHosts. Returns {"valid": bool};
+// 404 if the set has no such key and 502 if the set cannot be fetched.
+func (s *Server) VerifyJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.VerifyJWKSRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if req.KID == "" {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"kid is required"})
+		return
+	}
+	inline := len(req.JWKS) > 0 && string(req.JWKS) != "null"
+	if (req.JWKSURL == "") == !inline {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Exactly one of jwks and jwks_url is required",
+		})
+		return
+	}
+
+	var keys map[string]interface{}
+	if req.JWKSURL != "" {
+		parsed, err := url.Parse(req.JWKSURL)
+		if err != nil || !s.jwks.allowed(parsed) {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{errJWKSHostNotAllowed.Error()})
+			return
+		}
+		keys, err = s.jwks.keys(req.JWKSURL)
+		if err != nil {
+			// The cause may describe hosts behind the service, so only the log gets it.
+			log.Printf("Failed to fetch JWKS from %s: %v", req.JWKSURL, err)
+			WriteErrorResponse(w, http.StatusBadGateway, []string{"Failed to fetch JWKS"})
+			return
+		}
+	} else {
+		var err error
+		keys, err = signingcrypto.ParseJWKS(req.JWKS)
+		if err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+			return
+		}
+	}
+
+	key, ok := keys[req.KID]
+	if !ok {
+		WriteErrorResponse(w, http.StatusNotFound, []string{"Key not found in JWKS"})
+		return
+	}
+	algorithm, hash, signature, err := signingcrypto.DecodeTaggedSignatureHash(req.Signature)
+	if err != nil {
+		s.writeResponse(w, http.StatusOK, model.VerifyResult{Valid: false, Error: err.Error()})
+		return
+	}
+	if keyAlgorithm := signingcrypto.KeyAlgorithm(key); algorithm != "" && algorithm != keyAlgorithm {
+		s.writeResponse(w, http.StatusOK, model.VerifyResult{
+			Valid: false,
+			Error: fmt.Sprintf("signature is tagged %s, key %q is %s", algorithm, req.KID, keyAlgorithm),
+		})
+		return
+	}
+	// Untagged signatures are taken to be over SHA-256 digests; tags name their hash.
+	digest, err := signingcrypto.ParseHash(hash)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+	verifier, err := signingcrypto.NewHashVerifier(key, digest)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+	if err := verifier.Verify([]byte(req.Data), signature); err != nil {
+		message := signingcrypto.ErrInvalidSignature.Error()
+		if !errors.Is(err, signingcrypto.ErrInvalidSignature) {
+			message = err.Error()
+		}
+		s.writeResponse(w, http.StatusOK, model.VerifyResult{Valid: false, Error: message})
+		return
+	}
+	s.writeResponse(w, http.StatusOK, model.VerifyResult{Valid: true})
+}
+
+// ImportJWK handles POST /api/v0/devices/import-jwk to create a device from a JSON Web Key
+// given as "jwk". A private RSA or EC key becomes a signing device, a public one a
+// verify-only device. Returns 400 for a missing or invalid key and otherwise the same
+// statuses as device creation.
+func (s *Server) ImportJWK(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.ImportJWKRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if len(req.JWK) == 0 || string(req.JWK) == "null" {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"jwk is required"})
+		return
+	}
+
+	opts := req.ToOptions()
+	tenant, err := createTenant(r, opts.TenantID)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+	opts.TenantID = tenant
+
+	device, err := s.signDeviceService.CreateDevice(opts)
+	if err != nil {
+		writeCreateDeviceError(w, tenant, err)
+		return
+	}
+
+	s.writeResponse(w, http.StatusCreated, device.ToCreateResponse())
+}
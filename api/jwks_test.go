@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+// testJWKS returns a JWKS holding the public keys of signer ("good") and another key pair ("other").
+func testJWKS(t *testing.T) (json.RawMessage, signingcrypto.Signer) {
+	t.Helper()
+	good, _ := (&signingcrypto.ECCGenerator{}).Generate()
+	other, _ := (&signingcrypto.ECCGenerator{}).Generate()
+
+	size := (good.Public.Curve.Params().BitSize + 7) / 8
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwk := func(kid string, keyPair *signingcrypto.ECCKeyPair) map[string]string {
+		return map[string]string{
+			"kty": "EC",
+			"kid": kid,
+			"crv": "P-384",
+			"x":   encode(keyPair.Public.X.FillBytes(make([]byte, size))),
+			"y":   encode(keyPair.Public.Y.FillBytes(make([]byte, size))),
+		}
+	}
+	set, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{jwk("good", good), jwk("other", other)},
+	})
+	return set, signingcrypto.NewECDSASigner(good.Private)
+}
+
+func postVerifyJWKS(handler http.Handler, req model.VerifyJWKSRequest) (int, model.VerifyResult) {
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v0/verify/jwks", bytes.NewReader(body)))
+
+	var response struct {
+		Data model.VerifyResult `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	return w.Code, response.Data
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	server, _ := setupTestServer()
+	handler := server.Handler()
+	jwks, signer := testJWKS(t)
+	signature, _ := signer.Sign([]byte("federated payload"))
+	encoded := base64.StdEncoding.EncodeToString(signature)
+
+	t.Run("inline JWKS with the matching key", func(t *testing.T) {
+		code, result := postVerifyJWKS(handler, model.VerifyJWKSRequest{JWKS: jwks, KID: "good", Data: "federated payload", Signature: encoded})
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if !result.Valid {
+			t.Errorf("expected valid signature, got %+v", result)
+		}
+	})
+
+	t.Run("inline JWKS with the wrong key", func(t *testing.T) {
+		code, result := postVerifyJWKS(handler, model.VerifyJWKSRequest{JWKS: jwks, KID: "other", Data: "federated payload", Signature: encoded})
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if result.Valid {
+			t.Error("expected invalid signature for the wrong key")
+		}
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		code, _ := postVerifyJWKS(handler, model.VerifyJWKSRequest{JWKS: jwks, KID: "missing", Data: "federated payload", Signature: encoded})
+
+		if code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, code)
+		}
+	})
+
+	t.Run("requires exactly one key source", func(t *testing.T) {
+		code, _ := postVerifyJWKS(handler, model.VerifyJWKSRequest{KID: "good", Data: "federated payload", Signature: encoded})
+
+		if code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	// allowRemote lets server fetch from remote, trusting its test certificate.
+	allowRemote := func(remote *httptest.Server) {
+		WithJWKSHosts([]string{strings.TrimPrefix(remote.URL, "https://")})(server)
+		server.jwks.client.Transport = remote.Client().Transport
+	}
+
+	t.Run("remote JWKS is fetched once and cached", func(t *testing.T) {
+		var fetches atomic.Int32
+		remote := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			w.Write(jwks)
+		}))
+		defer remote.Close()
+		allowRemote(remote)
+
+		for i := 0; i < 2; i++ {
+			code, result := postVerifyJWKS(handler, model.VerifyJWKSRequest{JWKSURL: remote.URL, KID: "good", Data: "federated payload", Signature: encoded})
+			if code != http.StatusOK || !result.Valid {
+				t.Fatalf("expected valid signature, got status %d and %+v", code, result)
+			}
+		}
+		if fetches.Load() != 1 {
+			t.Errorf("expected 1 fetch, got %d", fetches.Load())
+		}
+	})
+
+	t.Run("unreachable JWKS", func(t *testing.T) {
+		remote := httptest.NewTLSServer(http.NotFoundHandler())
+		allowRemote(remote)
+		remote.Close()
+
+		body, _ := json.Marshal(model.VerifyJWKSRequest{JWKSURL: remote.URL, KID: "good", Data: "federated payload", Signature: encoded})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v0/verify/jwks", bytes.NewReader(body)))
+
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("expected status %d, got %d", http.StatusBadGateway, w.Code)
+		}
+		var response ErrorResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		if len(response.Errors) != 1 || response.Errors[0] != "Failed to fetch JWKS" {
+			t.Errorf("expected a generic error, got %v", response.Errors)
+		}
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		var fetches atomic.Int32
+		target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			w.Write(jwks)
+		}))
+		defer target.Close()
+		remote := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target.URL, http.StatusFound)
+		}))
+		defer remote.Close()
+		allowRemote(remote)
+
+		code, _ := postVerifyJWKS(handler, model.VerifyJWKSRequest{JWKSURL: remote.URL + "/jwks", KID: "good", Data: "federated payload", Signature: encoded})
+		if code != http.StatusBadGateway || fetches.Load() != 0 {
+			t.Errorf("expected status %d without following the redirect, got %d and %d fetches", http.StatusBadGateway, code, fetches.Load())
+		}
+	})
+
+	t.Run("rejects URLs outside the allowlist", func(t *testing.T) {
+		WithJWKSHosts([]string{"issuer.example"})(server)
+		for _, jwksURL := range []string{
+			"https://internal.example/jwks.json",
+			"http://issuer.example/jwks.json",
+			"https://user@issuer.example/jwks.json",
+			"file:///etc/passwd",
+		} {
+			code, _ := postVerifyJWKS(handler, model.VerifyJWKSRequest{JWKSURL: jwksURL, KID: "good", Data: "federated payload", Signature: encoded})
+			if code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", jwksURL, http.StatusBadRequest, code)
+			}
+		}
+	})
+}
+
+func TestJWKSCacheEviction(t *testing.T) {
+	cache := newJWKSCache(JWKSFetchTimeout, JWKSCacheTTL, 2)
+	for _, jwksURL := range []string{"https://a.example", "https://b.example", "https://c.example"} {
+		cache.put(jwksURL, map[string]interface{}{})
+	}
+	if len(cache.entries) != 2 {
+		t.Fatalf("expected 2 cached sets, got %d", len(cache.entries))
+	}
+	if _, ok := cache.entries["https://a.example"]; ok {
+		t.Error("expected the least recently used set to be evicted")
+	}
+	parsed, _ := url.Parse("https://A.example/jwks.json")
+	WithJWKSHosts([]string{"a.example"})(&Server{jwks: cache})
+	if !cache.allowed(parsed) {
+		t.Error("expected hosts to match case-insensitively")
+	}
+}
+
+func TestImportJWK(t *testing.T) {
+	keyPair, _ := (&signingcrypto.ECCGenerator{}).Generate()
+	size := (keyPair.Public.Curve.Params().BitSize + 7) / 8
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwk := map[string]string{
+		"kty": "EC",
+		"crv": "P-384",
+		"x":   encode(keyPair.Public.X.FillBytes(make([]byte, size))),
+		"y":   encode(keyPair.Public.Y.FillBytes(make([]byte, size))),
+		"d":   encode(keyPair.Private.D.FillBytes(make([]byte, size))),
+	}
+	post := func(handler http.Handler, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	importBody := func(id string, jwk map[string]string) string {
+		body, _ := json.Marshal(map[string]interface{}{"id": id, "label": "Imported", "jwk": jwk})
+		return string(body)
+	}
+
+	t.Run("imports EC private key and signs with it", func(t *testing.T) {
+		server, service := setupTestServer()
+		handler := server.Handler()
+
+		w := post(handler, "/api/v0/devices/import-jwk", importBody("jwk-001", jwk))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var created struct {
+			Data model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&created)
+		if created.Data.Algorithm != "ECC" || created.Data.VerifyOnly {
+			t.Errorf("expected signing ECC device, got %+v", created.Data)
+		}
+
+		w = post(handler, "/api/v0/devices/jwk-001/sign", `{"data":"payload"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var signed struct {
+			Data model.SignDataResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&signed)
+
+		// The signature verifies with the imported key itself, not a generated one.
+		signature, _ := base64.StdEncoding.DecodeString(signed.Data.Signature)
+		verifier := signingcrypto.NewECDSAVerifier(keyPair.Public)
+		if err := verifier.Verify([]byte(signed.Data.SignedData), signature); err != nil {
+			t.Errorf("expected signature to verify with the imported key, got %v", err)
+		}
+		if device, _ := service.GetDevice("jwk-001"); device.Label != "Imported" {
+			t.Errorf("expected label %q, got %q", "Imported", device.Label)
+		}
+	})
+
+	t.Run("public-only key creates a verify-only device", func(t *testing.T) {
+		server, _ := setupTestServer()
+		handler := server.Handler()
+
+		public := map[string]string{"kty": jwk["kty"], "crv": jwk["crv"], "x": jwk["x"], "y": jwk["y"]}
+		w := post(handler, "/api/v0/devices/import-jwk", importBody("jwk-002", public))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		if w := post(handler, "/api/v0/devices/jwk-002/sign", `{"data":"payload"}`); w.Code != http.StatusConflict {
+			t.Errorf("expected sign status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("rejects missing and invalid keys", func(t *testing.T) {
+		server, _ := setupTestServer()
+		handler := server.Handler()
+
+		bodies := []string{
+			`{"id":"jwk-003"}`,
+			importBody("jwk-003", map[string]string{"kty": "EC", "crv": "P-384", "d": jwk["d"]}),
+			importBody("jwk-003", map[string]string{"kty": "oct", "k": "c2VjcmV0"}),
+		}
+		for _, body := range bodies {
+			if w := post(handler, "/api/v0/devices/import-jwk", body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
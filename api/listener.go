@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net"
+	"sync"
+)
+
+// limitListener caps the number of simultaneously open connections accepted from a listener.
+// Once the cap is reached, Accept blocks until an accepted connection is closed, so excess
+// connections wait in the kernel's backlog instead of consuming goroutines and memory.
+type limitListener struct {
+	net.Listener
+	slots     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLimitListener(listener net.Listener, limit int) *limitListener {
+	return &limitListener{
+		Listener: listener,
+		slots:    make(chan struct{}, limit),
+		done:     make(chan struct{}),
+	}
+}
+
+// Accept waits for a free slot, then for the next connection.
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.slots }}, nil
+}
+
+// Close closes the listener and unblocks any Accept waiting for a slot.
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitConn frees its listener slot when closed.
+type limitConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
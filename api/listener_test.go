@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/persistence"
+)
+
+func TestLimitListener(t *testing.T) {
+	t.Run("caps accepted connections until one closes", func(t *testing.T) {
+		inner, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		listener := newLimitListener(inner, 2)
+		defer listener.Close()
+
+		accepted := make(chan net.Conn, 3)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				accepted <- conn
+			}
+		}()
+
+		for i := 0; i < 3; i++ {
+			conn, err := net.Dial("tcp", inner.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer conn.Close()
+		}
+
+		first := <-accepted
+		<-accepted
+		select {
+		case <-accepted:
+			t.Fatal("expected the third connection to wait for a free slot")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		first.Close()
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		case <-time.After(time.Second):
+			t.Fatal("expected the third connection to be accepted after one closed")
+		}
+	})
+
+	t.Run("close unblocks a waiting accept", func(t *testing.T) {
+		inner, _ := net.Listen("tcp", "127.0.0.1:0")
+		listener := newLimitListener(inner, 1)
+		listener.slots <- struct{}{}
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := listener.Accept()
+			result <- err
+		}()
+		listener.Close()
+
+		select {
+		case err := <-result:
+			if err == nil {
+				t.Error("expected an error after close, got nil")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected accept to return after close")
+		}
+	})
+
+	t.Run("server enforces the cap", func(t *testing.T) {
+		inner, _ := net.Listen("tcp", "127.0.0.1:0")
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		server := NewServer(":8080", service, WithMaxConnections(1))
+		go server.Serve(inner)
+		defer inner.Close()
+
+		// Hold the only slot with an idle connection.
+		idle, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+
+		client := &http.Client{Timeout: 200 * time.Millisecond}
+		if _, err := client.Get("http://" + inner.Addr().String() + "/api/v0/health"); err == nil {
+			t.Error("expected request beyond the cap to time out")
+		}
+
+		idle.Close()
+		client.Timeout = 2 * time.Second
+		resp, err := client.Get("http://" + inner.Addr().String() + "/api/v0/health")
+		if err != nil {
+			t.Fatalf("expected request to succeed once the slot is free, got %v", err)
+		}
+		resp.Body.Close()
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// LockDevice handles POST /api/v0/devices/{id}/lock to freeze signing on a device.
+func (s *Server) LockDevice(w http.ResponseWriter, r *http.Request) {
+	s.setDeviceLocked(w, r, true)
+}
+
+// UnlockDevice handles POST /api/v0/devices/{id}/unlock to resume signing on a device.
+func (s *Server) UnlockDevice(w http.ResponseWriter, r *http.Request) {
+	s.setDeviceLocked(w, r, false)
+}
+
+func (s *Server) setDeviceLocked(w http.ResponseWriter, r *http.Request, locked bool) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	device, err := s.signDeviceService.SetDeviceLocked(mux.Vars(r)["id"], locked)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeviceNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		case errors.Is(err, domain.ErrReadOnly):
+			writeReadOnlyError(w)
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to update device lock",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, device.ToResponse())
+}
+
+// writeLockedError reports a signing attempt on a locked device.
+func writeLockedError(w http.ResponseWriter) {
+	WriteErrorResponse(w, http.StatusLocked, []string{
+		"Device is locked",
+	})
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/gorilla/mux"
+)
+
+// RegenerateInitialSignature handles POST /api/v0/devices/{id}/initial-signature, a maintenance
+// operation that recomputes the base-case last_signature of a device that has not signed yet.
+// Returns 409 if the device has already signed and 404 if it does not exist.
+func (s *Server) RegenerateInitialSignature(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	device, err := s.signDeviceService.RegenerateInitialSignature(mux.Vars(r)["id"])
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeviceNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		case errors.Is(err, domain.ErrDeviceInUse):
+			WriteErrorResponse(w, http.StatusConflict, []string{
+				"Device has already signed; its chain cannot be reinitialized",
+			})
+		case errors.Is(err, domain.ErrReadOnly):
+			writeReadOnlyError(w)
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to regenerate initial signature",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, device.ToResponse())
+}
+
+// MigrateDevice handles POST /api/v0/devices/{id}/migrate, which hands the device's chain to
+// a new device with freshly generated keys, e.g. to move from RSA to ECC. Returns 201 with the
+// new device; the source becomes verify-only. Returns 404 for an unknown source, 409 if it is
+// verify-only (including already migrated), 423 if it is locked, and otherwise the same
+// statuses as device creation.
+func (s *Server) MigrateDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.MigrateDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+
+	opts := req.ToOptions()
+	opts.SourceID = mux.Vars(r)["id"]
+	device, err := s.signDeviceService.MigrateDeviceKeys(opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeviceNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		case errors.Is(err, domain.ErrVerifyOnly):
+			writeVerifyOnlyError(w)
+		case errors.Is(err, domain.ErrDeviceLocked):
+			writeLockedError(w)
+		default:
+			writeCreateDeviceError(w, requestTenant(r), err)
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusCreated, device.ToResponse())
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultMetricsDeviceLimit is the number of devices exported with their own series unless
+// configured otherwise.
+const DefaultMetricsDeviceLimit = 100
+
+// Metrics handles GET /api/v0/metrics to expose service metrics in the Prometheus text
+// format: aggregate signature and error counters, plus per-device series for the most active
+// devices. The per-device series are capped by WithMetricsDeviceLimit to bound cardinality;
+// signing_metrics_devices_omitted reports how many devices were left out.
+func (s *Server) Metrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	// A negative top ranks every device; the cap is applied below so the total is known.
+	stats, err := s.signDeviceService.SignatureStats(-1)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Failed to collect metrics",
+		})
+		return
+	}
+	devices := stats.TopDevices
+	omitted := 0
+	if len(devices) > s.metricsDeviceLimit {
+		omitted = len(devices) - s.metricsDeviceLimit
+		devices = devices[:s.metricsDeviceLimit]
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	writeMetricHeader(out, "signing_signatures_total", "counter", "Signatures produced since startup.")
+	fmt.Fprintf(out, "signing_signatures_total %d\n", stats.TotalSignatures)
+
+	writeMetricHeader(out, "signing_errors_total", "counter", "Failures since startup by algorithm and category.")
+	algorithms := make([]string, 0, len(stats.Errors))
+	for algorithm := range stats.Errors {
+		algorithms = append(algorithms, algorithm)
+	}
+	sort.Strings(algorithms)
+	for _, algorithm := range algorithms {
+		categories := make([]string, 0, len(stats.Errors[algorithm]))
+		for category := range stats.Errors[algorithm] {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+		for _, category := range categories {
+			fmt.Fprintf(out, "signing_errors_total{algorithm=\"%s\",category=\"%s\"} %d\n",
+				escapeLabelValue(algorithm), escapeLabelValue(category), stats.Errors[algorithm][category])
+		}
+	}
+
+	writeMetricHeader(out, "signing_devices", "gauge", "Devices in storage.")
+	fmt.Fprintf(out, "signing_devices %d\n", len(stats.TopDevices))
+
+	writeMetricHeader(out, "signing_metrics_devices_omitted", "gauge", "Devices left out of the per-device series by the cardinality cap.")
+	fmt.Fprintf(out, "signing_metrics_devices_omitted %d\n", omitted)
+
+	writeMetricHeader(out, "signing_device_sign_total", "counter", "Signatures produced by the device, attestations included.")
+	for _, device := range devices {
+		fmt.Fprintf(out, "signing_device_sign_total{device=\"%s\"} %d\n", escapeLabelValue(device.ID), device.SignatureCounter)
+	}
+
+	writeMetricHeader(out, "signing_device_last_counter", "gauge", "Counter of the device's most recent signature.")
+	for _, device := range devices {
+		if device.SignatureCounter > 0 {
+			fmt.Fprintf(out, "signing_device_last_counter{device=\"%s\"} %d\n", escapeLabelValue(device.ID), device.SignatureCounter-1)
+		}
+	}
+}
+
+func writeMetricHeader(out *bufio.Writer, name, metricType, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// labelValueEscaper escapes label values as required by the Prometheus text format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
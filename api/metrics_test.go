@@ -0,0 +1,91 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	model "github.com/bayuhutajulu/signing-service/model"
+	"github.com/bayuhutajulu/signing-service/persistence"
+)
+
+func TestMetrics(t *testing.T) {
+	newServer := func(opts ...ServerOption) (http.Handler, *domain.SignatureDeviceService) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		return NewServer(":8080", service, opts...).Handler(), service
+	}
+	scrape := func(t *testing.T, handler http.Handler) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/metrics", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("expected text/plain content type, got %q", contentType)
+		}
+		body, _ := io.ReadAll(w.Body)
+		return string(body)
+	}
+
+	t.Run("per-device series for created and signed devices", func(t *testing.T) {
+		handler, service := newServer()
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-metrics-001", Algorithm: "ECC"})
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-metrics-002", Algorithm: "ECC"})
+		for i := 0; i < 3; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: "device-metrics-001", Data: "payload"})
+		}
+
+		body := scrape(t, handler)
+
+		for _, line := range []string{
+			"signing_signatures_total 3",
+			"signing_devices 2",
+			`signing_device_sign_total{device="device-metrics-001"} 3`,
+			`signing_device_last_counter{device="device-metrics-001"} 2`,
+			`signing_device_sign_total{device="device-metrics-002"} 0`,
+			"signing_metrics_devices_omitted 0",
+		} {
+			if !strings.Contains(body, line+"\n") {
+				t.Errorf("expected metrics to contain %q, got:\n%s", line, body)
+			}
+		}
+		if strings.Contains(body, `signing_device_last_counter{device="device-metrics-002"}`) {
+			t.Error("expected no last counter for a device that never signed")
+		}
+	})
+
+	t.Run("cap limits cardinality to the most active devices", func(t *testing.T) {
+		handler, service := newServer(WithMetricsDeviceLimit(2))
+		for _, id := range []string{"device-a", "device-b", "device-c", "device-d"} {
+			service.CreateDevice(model.CreateDeviceOptions{ID: id, Algorithm: "ECC"})
+		}
+		service.SignData(model.SignDataOptions{DeviceID: "device-c", Data: "payload"})
+		service.SignData(model.SignDataOptions{DeviceID: "device-d", Data: "payload"})
+
+		body := scrape(t, handler)
+
+		if n := strings.Count(body, "signing_device_sign_total{"); n != 2 {
+			t.Errorf("expected 2 per-device series, got %d", n)
+		}
+		if !strings.Contains(body, `{device="device-c"}`) || !strings.Contains(body, `{device="device-d"}`) {
+			t.Errorf("expected the signing devices to be exported, got:\n%s", body)
+		}
+		if !strings.Contains(body, "signing_metrics_devices_omitted 2\n") {
+			t.Errorf("expected 2 omitted devices, got:\n%s", body)
+		}
+	})
+
+	t.Run("label values are escaped", func(t *testing.T) {
+		handler, service := newServer()
+		service.CreateDevice(model.CreateDeviceOptions{ID: `dev"ice\1`, Algorithm: "ECC"})
+
+		if body := scrape(t, handler); !strings.Contains(body, `{device="dev\"ice\\1"}`) {
+			t.Errorf("expected escaped label value, got:\n%s", body)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// recoveryMiddleware converts a panic in any downstream handler into a 500 response,
+// logging the stack trace instead of letting the panic terminate the process.
+// http.ErrAbortHandler is re-panicked so net/http can abort the response as intended.
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				log.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				WriteInternalError(w)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// trailingSlashMiddleware strips a trailing slash so /api/v0/devices/ is served by the same
+// handler as /api/v0/devices. The request is rewritten rather than redirected, since clients
+// following a 301 may replay a POST as a GET and lose the body.
+func trailingSlashMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+			r.URL.Path = strings.TrimRight(r.URL.Path, "/")
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// headersMiddleware sets the configured headers before the request reaches any handler, so
+// they are present however the response is written.
+func headersMiddleware(headers map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/bayuhutajulu/signing-service/persistence"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Run("panicking handler returns 500", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		router := server.Router()
+		router.HandleFunc("/api/v0/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+		handler := recoveryMiddleware(router)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/panic", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+
+	t.Run("regular routes are unaffected", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/health", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response Response
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	})
+}
+
+func TestTrailingSlashMiddleware(t *testing.T) {
+	server, service := setupTestServer()
+	service.CreateDevice(model.CreateDeviceOptions{
+		ID:        "device-slash-001",
+		Label:     "Slash Test",
+		Algorithm: "ECC",
+	})
+	handler := server.Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{name: "list without slash", method: http.MethodGet, path: "/api/v0/devices", code: http.StatusOK},
+		{name: "list with slash", method: http.MethodGet, path: "/api/v0/devices/", code: http.StatusOK},
+		{name: "get without slash", method: http.MethodGet, path: "/api/v0/devices/device-slash-001", code: http.StatusOK},
+		{name: "get with slash", method: http.MethodGet, path: "/api/v0/devices/device-slash-001/", code: http.StatusOK},
+		{name: "create with slash keeps body", method: http.MethodPost, path: "/api/v0/devices/", code: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader(`{"id":"device-slash-002","label":"Slash","algorithm":"RSA"}`)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+		})
+	}
+}
+
+func TestHeadersMiddleware(t *testing.T) {
+	t.Run("configured headers appear on device responses", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-headers-001", Algorithm: "ECC"})
+		server := NewServer(":8080", service, WithResponseHeaders(map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/device-headers-001", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("expected X-Content-Type-Options nosniff, got %q", got)
+		}
+		if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+			t.Errorf("expected X-Frame-Options DENY, got %q", got)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected handler Content-Type to be kept, got %q", got)
+		}
+	})
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	newServer := func(opts ...ServerOption) http.Handler {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		router := NewServer(":8080", service, opts...).Router()
+		router.HandleFunc("/api/v0/slow/{id}", func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(time.Second):
+				WriteAPIResponse(w, http.StatusOK, "finished")
+			case <-r.Context().Done():
+			}
+		})
+		return recoveryMiddleware(router)
+	}
+	get := func(handler http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("slow handler gets 503", func(t *testing.T) {
+		handler := newServer(WithHandlerTimeout(20 * time.Millisecond))
+		start := time.Now()
+		w := get(handler, "/api/v0/slow/1")
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected the response at the deadline, took %v", elapsed)
+		}
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Errors) != 1 || response.Errors[0] != "Request timed out" {
+			t.Errorf("expected the JSON timeout error, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("fast handlers are unaffected", func(t *testing.T) {
+		handler := newServer(WithHandlerTimeout(time.Second))
+		w := get(handler, "/api/v0/health")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON 200, got %d %q", w.Code, w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("route timeout overrides the global one", func(t *testing.T) {
+		handler := newServer(
+			WithHandlerTimeout(20*time.Millisecond),
+			WithRouteTimeouts(map[string]time.Duration{"/api/v0/slow/{id}": 2 * time.Second}),
+		)
+		if w := get(handler, "/api/v0/slow/1"); w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		handler = newServer(WithRouteTimeouts(map[string]time.Duration{"/api/v0/slow/{id}": 20 * time.Millisecond}))
+		if w := get(handler, "/api/v0/slow/1"); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+
+	t.Run("panics still reach the recovery middleware", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		router := NewServer(":8080", service, WithHandlerTimeout(time.Second)).Router()
+		router.HandleFunc("/api/v0/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+		if w := get(recoveryMiddleware(router), "/api/v0/panic"); w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+
+	t.Run("panics after the timeout are logged", func(t *testing.T) {
+		logged := &syncBuffer{}
+		log.SetOutput(logged)
+		defer log.SetOutput(os.Stderr)
+
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		router := NewServer(":8080", service, WithHandlerTimeout(10*time.Millisecond)).Router()
+		finished := make(chan struct{})
+		router.HandleFunc("/api/v0/late-panic", func(w http.ResponseWriter, r *http.Request) {
+			defer close(finished)
+			<-r.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			panic("late boom")
+		})
+		if w := get(recoveryMiddleware(router), "/api/v0/late-panic"); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		<-finished
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(logged.String(), "late boom") && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if !strings.Contains(logged.String(), "late boom") {
+			t.Errorf("expected the late panic to be logged, got %q", logged.String())
+		}
+	})
+
+	t.Run("CSV export is exempt", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-csv", Algorithm: "ECC"})
+		server := NewServer(":8080", service, WithHandlerTimeout(time.Nanosecond))
+		if w := get(server.Router(), "/api/v0/devices/device-csv/signatures.csv"); w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+// syncBuffer is a bytes.Buffer safe to use as log output from several goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+// ServerOption configures optional behaviour of a Server.
+type ServerOption func(*Server)
+
+// WithRateLimit caps the request rate across all routes with a token bucket refilled at
+// requestsPerSecond and holding up to burst tokens. A non-positive rate disables the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) ServerOption {
+	return func(s *Server) {
+		if requestsPerSecond <= 0 {
+			s.rateLimiter = nil
+			return
+		}
+		s.rateLimiter = newTokenBucket(requestsPerSecond, burst)
+	}
+}
+
+// WithAPIKeys requires every request to carry one of keys in the X-API-Key header, except
+// for paths under the bypass prefixes (DefaultAuthBypassPrefixes unless WithAuthBypass is
+// given). An empty key list disables authentication.
+func WithAPIKeys(keys []string) ServerOption {
+	return func(s *Server) {
+		if len(keys) == 0 {
+			s.auth = nil
+			return
+		}
+		s.auth = &apiKeyAuth{keys: make([][]byte, len(keys))}
+		for i, key := range keys {
+			s.auth.keys[i] = []byte(key)
+		}
+	}
+}
+
+// WithAuthBypass replaces the path prefixes reachable without an API key. It has no effect
+// unless authentication is enabled with WithAPIKeys; the options may be given in any order.
+func WithAuthBypass(prefixes []string) ServerOption {
+	return func(s *Server) {
+		s.authBypass = prefixes
+	}
+}
+
+// WithMaxConnections caps the number of simultaneously open TCP connections. Once reached,
+// new connections wait to be accepted until an open one closes. A limit of zero or less
+// leaves connections unlimited.
+func WithMaxConnections(limit int) ServerOption {
+	return func(s *Server) {
+		s.maxConnections = limit
+	}
+}
+
+// WithTLS serves HTTPS with certificate instead of plain HTTP.
+func WithTLS(certificate tls.Certificate) ServerOption {
+	return func(s *Server) {
+		s.tlsCertificate = &certificate
+	}
+}
+
+// WithClientCAs enables mutual TLS: connections without a client certificate issued by one of
+// the CAs in pool are rejected during the handshake. It has no effect unless TLS is enabled
+// with WithTLS. The verified client's subject is available to handlers via ClientCertSubject.
+func WithClientCAs(pool *x509.CertPool) ServerOption {
+	return func(s *Server) {
+		s.clientCAs = pool
+	}
+}
+
+// WithMetricsDeviceLimit caps the number of devices exported with their own metric series,
+// keeping the most active ones, to bound the cardinality of /api/v0/metrics. A limit of zero
+// exports aggregate metrics only; negative limits keep the default.
+func WithMetricsDeviceLimit(limit int) ServerOption {
+	return func(s *Server) {
+		if limit >= 0 {
+			s.metricsDeviceLimit = limit
+		}
+	}
+}
+
+// WithUnwrappedResponses writes successful responses as the bare payload, e.g. the
+// SignDataResponse object itself, instead of inside the {"data": ...} envelope. Error
+// responses keep their {"errors": [...]} envelope either way.
+func WithUnwrappedResponses(unwrap bool) ServerOption {
+	return func(s *Server) {
+		s.unwrapResponses = unwrap
+	}
+}
+
+// WithResponseHeaders sets the given headers on every response, including error responses
+// produced by middleware, e.g. X-Content-Type-Options or X-Frame-Options.
+func WithResponseHeaders(headers map[string]string) ServerOption {
+	return func(s *Server) {
+		s.responseHeaders = headers
+	}
+}
+
+// WithResponseSigner signs every response, except streamed events and CSV exports, with signer
+// and sends the result in the X-Response-Signature header. A nil signer leaves responses unsigned.
+func WithResponseSigner(signer *signingcrypto.ResponseSigner) ServerOption {
+	return func(s *Server) {
+		s.responseSigner = signer
+	}
+}
+
+// WithMaxListDevices caps the number of devices returned by GetAllDevices; longer lists are
+// cut to the first limit devices by ID and flagged as truncated. A limit of zero lists every
+// device; negative limits keep the default.
+func WithMaxListDevices(limit int) ServerOption {
+	return func(s *Server) {
+		if limit >= 0 {
+			s.maxListDevices = limit
+		}
+	}
+}
+
+// WithHandlerTimeout bounds how long any handler may take; a request still running at the
+// deadline gets 503 and its context is cancelled. Streamed events are exempt. A timeout of
+// zero or less leaves handlers unbounded.
+func WithHandlerTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.handlerTimeout = timeout
+	}
+}
+
+// WithRouteTimeouts overrides the handler timeout for individual routes, keyed by their path
+// template, e.g. "/api/v0/devices/{id}/sign". A zero timeout leaves that route unbounded.
+func WithRouteTimeouts(timeouts map[string]time.Duration) ServerOption {
+	return func(s *Server) {
+		s.routeTimeouts = timeouts
+	}
+}
+
+// WithJWKSHosts allows VerifyJWKS to fetch remote key sets from the given hosts, matched
+// case-insensitively against the host[:port] of an https jwks_url. Without allowed hosts only
+// inline key sets are accepted.
+func WithJWKSHosts(hosts []string) ServerOption {
+	return func(s *Server) {
+		s.jwks.hosts = make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			s.jwks.hosts[strings.ToLower(host)] = true
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/persistence"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Run("rejects requests past the limit then recovers", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		server := NewServer(":8080", service, WithRateLimit(1, 2))
+
+		now := time.Now()
+		server.rateLimiter.last = now
+		server.rateLimiter.now = func() time.Time { return now }
+		handler := server.Handler()
+
+		get := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/health", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		for i := 1; i <= 2; i++ {
+			if w := get(); w.Code != http.StatusOK {
+				t.Fatalf("request %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+			}
+		}
+
+		w := get()
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("expected Retry-After 1, got %q", got)
+		}
+
+		now = now.Add(time.Second)
+		if w := get(); w.Code != http.StatusOK {
+			t.Errorf("expected status %d after refill, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("limit applies across routes", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		server := NewServer(":8080", service, WithRateLimit(0.001, 1))
+		handler := server.Handler()
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v0/health", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v0/devices", nil))
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		if server.rateLimiter != nil {
+			t.Error("expected no rate limiter by default")
+		}
+	})
+}
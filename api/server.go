@@ -1,10 +1,15 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"log"
+	"net"
 	"net/http"
+	"time"
 
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
 	"github.com/bayuhutajulu/signing-service/domain"
 	"github.com/gorilla/mux"
 )
@@ -12,6 +17,8 @@ import (
 // Response is the generic API response container.
 type Response struct {
 	Data interface{} `json:"data"`
+	// Truncated is set when a list was cut short at the server's cap.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // ErrorResponse is the generic error API response container.
@@ -21,45 +28,201 @@ type ErrorResponse struct {
 
 // Server manages HTTP requests and dispatches them to the appropriate services.
 type Server struct {
-	listenAddress     string
-	signDeviceService domain.ISignatureDeviceService
+	listenAddress      string
+	signDeviceService  domain.ISignatureDeviceService
+	rateLimiter        *tokenBucket
+	auth               *apiKeyAuth
+	authBypass         []string
+	responseHeaders    map[string]string
+	jwks               *jwksCache
+	maxConnections     int // Cap on simultaneously open connections; zero is unlimited
+	tlsCertificate     *tls.Certificate
+	clientCAs          *x509.CertPool                // Client CAs for mutual TLS; nil accepts clients without certificates
+	metricsDeviceLimit int                           // Devices exported with per-device metric series
+	unwrapResponses    bool                          // Write success payloads without the {"data": ...} envelope
+	responseSigner     *signingcrypto.ResponseSigner // Signs response bodies; nil sends them unsigned
+	maxListDevices     int                           // Cap on devices listed per request; zero is unlimited
+	handlerTimeout     time.Duration                 // Maximum handler duration; zero is unbounded
+	routeTimeouts      map[string]time.Duration      // Per-route overrides of handlerTimeout, keyed by path template
 }
 
 // NewServer is a factory to instantiate a new Server.
-func NewServer(listenAddress string, signDeviceService *domain.SignatureDeviceService) *Server {
-	return &Server{
-		listenAddress:     listenAddress,
-		signDeviceService: signDeviceService,
+func NewServer(listenAddress string, signDeviceService *domain.SignatureDeviceService, opts ...ServerOption) *Server {
+	s := &Server{
+		listenAddress:      listenAddress,
+		signDeviceService:  signDeviceService,
+		jwks:               newJWKSCache(JWKSFetchTimeout, JWKSCacheTTL, JWKSCacheSize),
+		metricsDeviceLimit: DefaultMetricsDeviceLimit,
+		maxListDevices:     DefaultMaxListDevices,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.auth != nil {
+		s.auth.bypassPrefixes = DefaultAuthBypassPrefixes
+		if s.authBypass != nil {
+			s.auth.bypassPrefixes = s.authBypass
+		}
+	}
+	return s
 }
 
-// Run registers all HandlerFuncs for the existing HTTP routes and starts the Server.
-func (s *Server) Run() error {
+// Router registers all HandlerFuncs for the existing HTTP routes.
+func (s *Server) Router() *mux.Router {
 	router := mux.NewRouter()
 
 	router.HandleFunc("/api/v0/health", s.Health).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/version", s.VersionInfo).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/selftest", s.SelfTest).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/algorithms", s.ListAlgorithms).Methods(http.MethodGet)
 	router.HandleFunc("/api/v0/devices", s.CreateDevice).Methods(http.MethodPost)
 	router.HandleFunc("/api/v0/devices", s.GetAllDevices).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/batch-get", s.BatchGetDevices).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/bulk-delete", s.BulkDeleteDevices).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/import-jwk", s.ImportJWK).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/compare", s.CompareDevices).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/chains", s.ListChainTips).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/verify/jwks", s.VerifyJWKS).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/introspect", s.IntrospectSignature).Methods(http.MethodPost)
 	router.HandleFunc("/api/v0/devices/{id}", s.GetDevice).Methods(http.MethodGet)
 	router.HandleFunc("/api/v0/devices/{id}/sign", s.SignData).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/verify/batch", s.VerifyBatch).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/verify-at", s.VerifyAt).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/attest", s.AttestSignature).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/integrity", s.CounterIntegrity).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/self-verify", s.SelfVerify).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/counter", s.GetCounter).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/capabilities", s.DeviceCapabilities).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/size", s.DeviceSize).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/strength", s.DeviceKeyStrength).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/signatures", s.ListSignatures).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/signatures.csv", s.ExportSignaturesCSV).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/throughput", s.SignatureThroughput).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{id}/initial-signature", s.RegenerateInitialSignature).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/migrate", s.MigrateDevice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/lock", s.LockDevice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{id}/unlock", s.UnlockDevice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/events", s.EventsWebSocket).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/events/stream", s.EventsStream).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/stats/signatures", s.SignatureStats).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/metrics", s.Metrics).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/admin/flags", s.GetFeatureFlags).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/admin/flags", s.UpdateFeatureFlags).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/admin/devices/at-risk", s.ListAtRiskDevices).Methods(http.MethodGet)
+
+	router.Use(s.tenantMiddleware)
+	router.Use(s.timeoutMiddleware)
+
+	router.NotFoundHandler = http.HandlerFunc(notFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+
+	return router
+}
 
+// notFound answers unmatched paths using the JSON error envelope instead of mux's plain text.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	WriteErrorResponse(w, http.StatusNotFound, []string{
+		http.StatusText(http.StatusNotFound),
+	})
+}
+
+// methodNotAllowed answers known paths requested with an unregistered method.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+		http.StatusText(http.StatusMethodNotAllowed),
+	})
+}
+
+// Handler wraps the router in the server's middleware chain.
+func (s *Server) Handler() http.Handler {
+	var handler http.Handler = s.Router()
+	if s.auth != nil {
+		handler = authMiddleware(s.auth, handler)
+	}
+	handler = trailingSlashMiddleware(handler)
+	if s.rateLimiter != nil {
+		handler = rateLimitMiddleware(s.rateLimiter, handler)
+	}
+	if len(s.responseHeaders) > 0 {
+		handler = headersMiddleware(s.responseHeaders, handler)
+	}
+	if s.clientCAs != nil {
+		handler = clientCertMiddleware(handler)
+	}
+	if s.responseSigner != nil {
+		handler = responseSigningMiddleware(s.responseSigner, handler)
+	}
+	return recoveryMiddleware(handler)
+}
+
+// Run starts the Server with all routes and middleware.
+func (s *Server) Run() error {
 	log.Printf("Server is starting on %s", s.listenAddress)
-	return http.ListenAndServe(s.listenAddress, router)
+	listener, err := net.Listen("tcp", s.listenAddress)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener, capped at the configured maximum, and serves them
+// with the server's handler, over TLS when configured.
+func (s *Server) Serve(listener net.Listener) error {
+	if s.maxConnections > 0 {
+		listener = newLimitListener(listener, s.maxConnections)
+	}
+	if config := s.tlsConfig(); config != nil {
+		listener = tls.NewListener(listener, config)
+	}
+	return http.Serve(listener, s.Handler())
+}
+
+// internalErrorBody is the ErrorResponse written by WriteInternalError. It is marshaled once
+// up front, so writing it cannot fail and never falls back to another error writer.
+var internalErrorBody = mustMarshal(ErrorResponse{
+	Errors: []string{http.StatusText(http.StatusInternalServerError)},
+})
+
+func mustMarshal(v interface{}) []byte {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return bytes
 }
 
-// WriteInternalError writes a default internal error message as an HTTP response.
+// WriteInternalError writes a default internal error message as an HTTP response, in the
+// same {"errors": [...]} envelope as WriteErrorResponse.
 func WriteInternalError(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusInternalServerError)
-	w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+	w.Write(internalErrorBody)
+}
+
+// writeReadOnlyError reports a write attempted against a read-only replica.
+func writeReadOnlyError(w http.ResponseWriter) {
+	WriteErrorResponse(w, http.StatusForbidden, []string{
+		"Service is running in read-only mode",
+	})
+}
+
+// writeDisabledError reports a request to a feature switched off by feature flag.
+func writeDisabledError(w http.ResponseWriter, err error) {
+	WriteErrorResponse(w, http.StatusServiceUnavailable, []string{
+		"Temporarily unavailable: " + err.Error(),
+	})
+}
+
+// writeVerifyOnlyError reports a signing attempt on a device that holds no private key.
+func writeVerifyOnlyError(w http.ResponseWriter) {
+	WriteErrorResponse(w, http.StatusConflict, []string{
+		"Device is verify-only",
+	})
 }
 
 // WriteErrorResponse takes an HTTP status code and a slice of errors
 // and writes those as an HTTP error response in a structured format.
 func WriteErrorResponse(w http.ResponseWriter, code int, errors []string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-
 	errorResponse := ErrorResponse{
 		Errors: errors,
 	}
@@ -70,24 +233,51 @@ func WriteErrorResponse(w http.ResponseWriter, code int, errors []string) {
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	w.Write(bytes)
 }
 
 // WriteAPIResponse takes an HTTP status code and a generic data struct
 // and writes those as an HTTP response in a structured format.
 func WriteAPIResponse(w http.ResponseWriter, code int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-
-	response := Response{
+	writeJSON(w, code, Response{
 		Data: data,
+	})
+}
+
+// writeResponse writes a successful response like WriteAPIResponse, or data alone at the top
+// level when the server is configured with WithUnwrappedResponses.
+func (s *Server) writeResponse(w http.ResponseWriter, code int, data interface{}) {
+	if s.unwrapResponses {
+		writeJSON(w, code, data)
+		return
 	}
+	WriteAPIResponse(w, code, data)
+}
 
-	bytes, err := json.MarshalIndent(response, "", "  ")
+// writeTruncatedResponse writes a list cut short at a cap like writeResponse, flagging it with
+// "truncated": true in the envelope and, since an unwrapped list cannot carry the flag, with
+// the TruncatedHeader in both modes.
+func (s *Server) writeTruncatedResponse(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set(TruncatedHeader, "true")
+	if s.unwrapResponses {
+		writeJSON(w, code, data)
+		return
+	}
+	writeJSON(w, code, Response{Data: data, Truncated: true})
+}
+
+// writeJSON writes v as an indented JSON response body.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	// Marshal before writing the header, so a failure can still be reported as a 500.
+	bytes, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		WriteInternalError(w)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	w.Write(bytes)
 }
@@ -2,22 +2,38 @@ package api
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
 	"github.com/bayuhutajulu/signing-service/domain"
 	"github.com/bayuhutajulu/signing-service/model"
 	"github.com/bayuhutajulu/signing-service/persistence"
 	"github.com/gorilla/mux"
 )
 
-func setupTestServer() (*Server, *domain.SignatureDeviceService) {
+func setupTestServer(opts ...domain.ServiceOption) (*Server, *domain.SignatureDeviceService) {
 	storage := persistence.NewInMemoryStorage()
-	service := domain.NewSignatureDeviceService(storage)
+	service := domain.NewSignatureDeviceService(storage, opts...)
 	server := NewServer(":8080", service)
 	return server, service
 }
@@ -56,6 +72,33 @@ func TestCreateDevice(t *testing.T) {
 		}
 	})
 
+	t.Run("response carries the initial last_signature", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		body, _ := json.Marshal(model.CreateDeviceRequest{ID: "device-initial-001", Algorithm: "ECC"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		server.CreateDevice(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var response struct {
+			Data model.CreateDeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		expected := base64.StdEncoding.EncodeToString([]byte("device-initial-001"))
+		if response.Data.LastSignature != expected {
+			t.Errorf("expected last_signature %q, got %q", expected, response.Data.LastSignature)
+		}
+		if response.Data.ID != "device-initial-001" || response.Data.SignatureCounter != 0 {
+			t.Errorf("expected device-initial-001 at counter 0, got %s at %d", response.Data.ID, response.Data.SignatureCounter)
+		}
+	})
+
 	t.Run("successful device creation with ECC", func(t *testing.T) {
 		server, _ := setupTestServer()
 
@@ -177,8 +220,8 @@ func TestSignData(t *testing.T) {
 
 		server.SignData(w, req)
 
-		if w.Code != http.StatusInternalServerError {
-			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
 		}
 	})
 
@@ -378,6 +421,75 @@ func TestGetAllDevices(t *testing.T) {
 	})
 }
 
+func TestGetAllDevicesFilters(t *testing.T) {
+	server, service := setupTestServer()
+	// Counters: rsa-0 = 0, ecc-2 = 2, rsa-5 = 5.
+	for _, device := range []struct {
+		id        string
+		algorithm string
+		signs     int
+	}{{"rsa-0", "RSA", 0}, {"ecc-2", "ECC", 2}, {"rsa-5", "RSA", 5}} {
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: device.id, Algorithm: device.algorithm}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		for i := 0; i < device.signs; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.id, Data: "data"})
+		}
+	}
+
+	list := func(t *testing.T, query string) []string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices"+query, nil)
+		w := httptest.NewRecorder()
+		server.GetAllDevices(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		ids := []string{}
+		for _, device := range response.Data {
+			ids = append(ids, device.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"min only", "?min_counter=2", []string{"ecc-2", "rsa-5"}},
+		{"max only", "?max_counter=2", []string{"ecc-2", "rsa-0"}},
+		{"combined range", "?min_counter=1&max_counter=4", []string{"ecc-2"}},
+		{"range with algorithm", "?algorithm=RSA&min_counter=1", []string{"rsa-5"}},
+		{"algorithm only", "?algorithm=RSA", []string{"rsa-0", "rsa-5"}},
+		{"empty range", "?min_counter=6", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := list(t, tt.query)
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	for _, query := range []string{"?min_counter=-1", "?max_counter=abc", "?min_counter=3&max_counter=2"} {
+		t.Run("rejects "+query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/devices"+query, nil)
+			w := httptest.NewRecorder()
+			server.GetAllDevices(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
 func TestConcurrentAPIRequests(t *testing.T) {
 	t.Run("concurrent device creation", func(t *testing.T) {
 		server, service := setupTestServer()
@@ -465,7 +577,7 @@ func TestConcurrentAPIRequests(t *testing.T) {
 		}
 
 		updatedDevice, _ := service.GetDevice(device.ID)
-		if updatedDevice.SignatureCounter != concurrency {
+		if updatedDevice.SignatureCounter != int64(concurrency) {
 			t.Errorf("expected counter %d, got %d", concurrency, updatedDevice.SignatureCounter)
 		}
 	})
@@ -517,3 +629,2795 @@ func TestResponseFormats(t *testing.T) {
 		}
 	})
 }
+
+func TestSignatureStats(t *testing.T) {
+	t.Run("returns total and ranking", func(t *testing.T) {
+		server, service := setupTestServer()
+
+		for _, id := range []string{"device-stats-001", "device-stats-002"} {
+			service.CreateDevice(model.CreateDeviceOptions{
+				ID:        id,
+				Label:     "Stats Test",
+				Algorithm: "ECC",
+			})
+		}
+		service.SignData(model.SignDataOptions{DeviceID: "device-stats-001", Data: "a"})
+		service.SignData(model.SignDataOptions{DeviceID: "device-stats-002", Data: "b"})
+		service.SignData(model.SignDataOptions{DeviceID: "device-stats-002", Data: "c"})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/stats/signatures?top=1", nil)
+		w := httptest.NewRecorder()
+
+		server.SignatureStats(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data model.SignatureStatsResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if response.Data.TotalSignatures != 3 {
+			t.Errorf("expected total 3, got %d", response.Data.TotalSignatures)
+		}
+		if len(response.Data.TopDevices) != 1 || response.Data.TopDevices[0].ID != "device-stats-002" {
+			t.Errorf("expected device-stats-002 as top device, got %+v", response.Data.TopDevices)
+		}
+	})
+
+	t.Run("invalid top parameter", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/stats/signatures?top=abc", nil)
+		w := httptest.NewRecorder()
+
+		server.SignatureStats(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestVerifyBatch(t *testing.T) {
+	t.Run("mixed valid and tampered entries", func(t *testing.T) {
+		server, service := setupTestServer()
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-verify-001",
+			Label:     "Verify Test",
+			Algorithm: "ECC",
+		})
+		initial := device.LastSignature
+		first, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+		second, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "second"})
+
+		entries := []model.VerifySignatureRequest{
+			{Data: "first", Signature: first.Signature, Counter: 0, LastSignature: initial},
+			{Data: "tampered", Signature: second.Signature, Counter: 1, LastSignature: first.Signature},
+			{Data: "second", Signature: second.Signature, Counter: 1, LastSignature: first.Signature},
+			{Data: "second", Signature: "not base64!", Counter: 1, LastSignature: first.Signature},
+		}
+		body, _ := json.Marshal(entries)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/verify/batch", bytes.NewBuffer(body))
+		req = mux.SetURLVars(req, map[string]string{"id": device.ID})
+		w := httptest.NewRecorder()
+
+		server.VerifyBatch(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data []model.VerifyResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		expected := []bool{true, false, true, false}
+		if len(response.Data) != len(expected) {
+			t.Fatalf("expected %d results, got %d", len(expected), len(response.Data))
+		}
+		for i, valid := range expected {
+			if response.Data[i].Valid != valid {
+				t.Errorf("entry %d: expected valid=%v, got %v", i, valid, response.Data[i].Valid)
+			}
+			if !valid && response.Data[i].Error == "" {
+				t.Errorf("entry %d: expected error message", i)
+			}
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/non-existent/verify/batch", bytes.NewBuffer([]byte("[]")))
+		req = mux.SetURLVars(req, map[string]string{"id": "non-existent"})
+		w := httptest.NewRecorder()
+
+		server.VerifyBatch(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-001/verify/batch", bytes.NewBuffer([]byte("{}")))
+		req = mux.SetURLVars(req, map[string]string{"id": "device-001"})
+		w := httptest.NewRecorder()
+
+		server.VerifyBatch(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestVersionInfo(t *testing.T) {
+	t.Run("returns build info with go version", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/version", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data VersionResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if response.Data.GoVersion != runtime.Version() {
+			t.Errorf("expected go_version %s, got %s", runtime.Version(), response.Data.GoVersion)
+		}
+		if response.Data.Version == "" || response.Data.Commit == "" || response.Data.BuildTime == "" {
+			t.Errorf("expected build fields to fall back to defaults, got %+v", response.Data)
+		}
+	})
+}
+
+type gatedSigner struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *gatedSigner) Sign(dataToBeSigned []byte) ([]byte, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return []byte("signature"), nil
+}
+
+func TestSignDataConcurrencyLimit(t *testing.T) {
+	t.Run("excess requests get 503 when saturated", func(t *testing.T) {
+		server, service := setupTestServer(domain.WithMaxConcurrentSigns(1, 10*time.Millisecond))
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-limit-001",
+			Label:     "Limit Test",
+			Algorithm: "ECC",
+		})
+		signer := &gatedSigner{started: make(chan struct{}), release: make(chan struct{})}
+		device.Signer = signer
+
+		sign := func() int {
+			body, _ := json.Marshal(model.SignDataRequest{Data: "data"})
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBuffer(body))
+			req = mux.SetURLVars(req, map[string]string{"id": device.ID})
+			w := httptest.NewRecorder()
+			server.SignData(w, req)
+			return w.Code
+		}
+
+		firstCode := make(chan int, 1)
+		go func() { firstCode <- sign() }()
+		<-signer.started
+
+		if code := sign(); code != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d while saturated, got %d", http.StatusServiceUnavailable, code)
+		}
+
+		close(signer.release)
+		if code := <-firstCode; code != http.StatusOK {
+			t.Errorf("expected in-flight request to succeed, got %d", code)
+		}
+
+		go func() { <-signer.started }()
+		if code := sign(); code != http.StatusOK {
+			t.Errorf("expected status %d once a slot is free, got %d", http.StatusOK, code)
+		}
+	})
+}
+
+func TestAttestSignature(t *testing.T) {
+	t.Run("successful attestation", func(t *testing.T) {
+		server, service := setupTestServer()
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-attest-001",
+			Label:     "Attest Test",
+			Algorithm: "ECC",
+		})
+
+		body, _ := json.Marshal(model.AttestRequest{Signature: "ZXh0ZXJuYWw="})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/attest", bytes.NewBuffer(body))
+		req = mux.SetURLVars(req, map[string]string{"id": device.ID})
+		w := httptest.NewRecorder()
+
+		server.AttestSignature(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data model.AttestResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if response.Data.Signature == "" || response.Data.Digest == "" {
+			t.Errorf("expected signature and digest, got %+v", response.Data)
+		}
+
+		updatedDevice, _ := service.GetDevice(device.ID)
+		if updatedDevice.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", updatedDevice.SignatureCounter)
+		}
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-001/attest", bytes.NewBuffer([]byte("{}")))
+		req = mux.SetURLVars(req, map[string]string{"id": "device-001"})
+		w := httptest.NewRecorder()
+
+		server.AttestSignature(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		body, _ := json.Marshal(model.AttestRequest{Signature: "ZXh0ZXJuYWw="})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/non-existent/attest", bytes.NewBuffer(body))
+		req = mux.SetURLVars(req, map[string]string{"id": "non-existent"})
+		w := httptest.NewRecorder()
+
+		server.AttestSignature(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("pre-sign hook refusal", func(t *testing.T) {
+		server, service := setupTestServer(domain.WithPreSignHook(func(deviceID string, data []byte) error {
+			return errors.New("attestation not allowed")
+		}))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-attest-002", Algorithm: "ECC"})
+
+		body, _ := json.Marshal(model.AttestRequest{Signature: "ZXh0ZXJuYWw="})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/attest", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+		if updatedDevice, _ := service.GetDevice(device.ID); updatedDevice.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", updatedDevice.SignatureCounter)
+		}
+	})
+}
+
+func TestSignDataCMS(t *testing.T) {
+	t.Run("returns verifiable detached CMS", func(t *testing.T) {
+		server, service := setupTestServer()
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-cms-001",
+			Label:     "CMS Test",
+			Algorithm: "ECC",
+		})
+
+		body, _ := json.Marshal(model.SignDataRequest{Data: "transaction-data", Format: model.SignatureFormatCMS})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBuffer(body))
+		req = mux.SetURLVars(req, map[string]string{"id": device.ID})
+		w := httptest.NewRecorder()
+
+		server.SignData(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data model.SignDataResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		der, err := base64.StdEncoding.DecodeString(response.Data.CMS)
+		if err != nil {
+			t.Fatalf("expected base64 CMS, got %v", err)
+		}
+		cms, err := signingcrypto.ParseDetachedCMS(der)
+		if err != nil {
+			t.Fatalf("expected parseable CMS, got %v", err)
+		}
+		verifier, _ := signingcrypto.NewHashVerifier(cms.Certificate.PublicKey, cms.Hash)
+		if err := verifier.Verify([]byte(response.Data.SignedData), cms.Signature); err != nil {
+			t.Errorf("expected CMS signature to verify, got %v", err)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		server, service := setupTestServer()
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-cms-002",
+			Label:     "CMS Test",
+			Algorithm: "RSA",
+		})
+
+		body, _ := json.Marshal(model.SignDataRequest{Data: "transaction-data", Format: "xml"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBuffer(body))
+		req = mux.SetURLVars(req, map[string]string{"id": device.ID})
+		w := httptest.NewRecorder()
+
+		server.SignData(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestRouterFallbackHandlers(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/api/v0/unknown", code: http.StatusNotFound},
+		{name: "wrong method on known path", method: http.MethodDelete, path: "/api/v0/health", code: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := setupTestServer()
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.Handler().ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected Content-Type application/json, got %s", ct)
+			}
+
+			var response ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("expected JSON error envelope, got %v", err)
+			}
+			if len(response.Errors) != 1 || response.Errors[0] != http.StatusText(tt.code) {
+				t.Errorf("expected error %q, got %v", http.StatusText(tt.code), response.Errors)
+			}
+		})
+	}
+}
+
+func TestCreateDeviceIDPolicy(t *testing.T) {
+	t.Run("non-conforming ID returns 400", func(t *testing.T) {
+		server, _ := setupTestServer(domain.WithDeviceIDPolicy(regexp.MustCompile(domain.DefaultDeviceIDPattern)))
+
+		body, _ := json.Marshal(model.CreateDeviceRequest{ID: "bad id!", Algorithm: "ECC"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		server.CreateDevice(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestCreateDevicePrintableOnly(t *testing.T) {
+	server, _ := setupTestServer(domain.WithPrintableOnly(true))
+
+	for _, label := range []string{"Till\n3", "Till\u00073"} {
+		body := `{"id":"device-print-001","algorithm":"ECC","label":"` + label + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		server.CreateDevice(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("label %s: expected status %d, got %d", label, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	storage := persistence.NewInMemoryStorage()
+	writer := domain.NewSignatureDeviceService(storage)
+	device, _ := writer.CreateDevice(model.CreateDeviceOptions{
+		ID:        "device-replica-001",
+		Label:     "Replica Test",
+		Algorithm: "ECC",
+	})
+	signed, _ := writer.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+	replica := NewServer(":8080", domain.NewSignatureDeviceService(storage, domain.WithReadOnly(true)))
+	handler := replica.Handler()
+
+	verifyBody, _ := json.Marshal([]model.VerifySignatureRequest{{
+		Data:          "payload",
+		Signature:     signed.Signature,
+		Counter:       0,
+		LastSignature: base64.StdEncoding.EncodeToString([]byte(device.ID)),
+	}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   []byte
+		code   int
+	}{
+		{name: "create refused", method: http.MethodPost, path: "/api/v0/devices", body: []byte(`{"id":"device-replica-002","algorithm":"ECC"}`), code: http.StatusForbidden},
+		{name: "sign refused", method: http.MethodPost, path: "/api/v0/devices/" + device.ID + "/sign", body: []byte(`{"data":"more"}`), code: http.StatusForbidden},
+		{name: "attest refused", method: http.MethodPost, path: "/api/v0/devices/" + device.ID + "/attest", body: []byte(`{"signature":"ZXh0ZXJuYWw="}`), code: http.StatusForbidden},
+		{name: "list served", method: http.MethodGet, path: "/api/v0/devices", code: http.StatusOK},
+		{name: "get served", method: http.MethodGet, path: "/api/v0/devices/" + device.ID, code: http.StatusOK},
+		{name: "verify served", method: http.MethodPost, path: "/api/v0/devices/" + device.ID + "/verify/batch", body: verifyBody, code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBuffer(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+		})
+	}
+
+	t.Run("device state unchanged", func(t *testing.T) {
+		stored, _ := storage.GetDevice(device.ID)
+		if stored.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", stored.SignatureCounter)
+		}
+	})
+}
+
+func TestBatchGetDevices(t *testing.T) {
+	t.Run("maps existing and missing IDs", func(t *testing.T) {
+		server, service := setupTestServer()
+		for _, id := range []string{"device-batch-001", "device-batch-002"} {
+			service.CreateDevice(model.CreateDeviceOptions{ID: id, Label: "Batch", Algorithm: "ECC"})
+		}
+
+		body, _ := json.Marshal(model.BatchGetDevicesRequest{IDs: []string{"device-batch-001", "missing", "device-batch-002"}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/batch-get", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data map[string]*model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if len(response.Data) != 3 {
+			t.Fatalf("expected 3 entries, got %d", len(response.Data))
+		}
+		for _, id := range []string{"device-batch-001", "device-batch-002"} {
+			if response.Data[id] == nil || response.Data[id].ID != id {
+				t.Errorf("expected device %s, got %+v", id, response.Data[id])
+			}
+		}
+		if missing, ok := response.Data["missing"]; !ok || missing != nil {
+			t.Errorf("expected null for missing device, got %+v", missing)
+		}
+	})
+
+	t.Run("rejects too many IDs", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		ids := make([]string, MaxBatchGetIDs+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("device-%d", i)
+		}
+		body, _ := json.Marshal(model.BatchGetDevicesRequest{IDs: ids})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/batch-get", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		server.BatchGetDevices(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestBulkDeleteDevices(t *testing.T) {
+	t.Run("deletes existing and reports missing IDs", func(t *testing.T) {
+		server, service := setupTestServer()
+		for _, id := range []string{"device-delete-001", "device-delete-002", "device-keep"} {
+			service.CreateDevice(model.CreateDeviceOptions{ID: id, Algorithm: "ECC"})
+		}
+
+		body, _ := json.Marshal(model.BulkDeleteDevicesRequest{IDs: []string{"device-delete-001", "missing", "device-delete-002"}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/bulk-delete", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []model.DeleteResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		expected := []model.DeleteResult{
+			{ID: "device-delete-001", Status: model.DeleteStatusDeleted},
+			{ID: "missing", Status: model.DeleteStatusNotFound},
+			{ID: "device-delete-002", Status: model.DeleteStatusDeleted},
+		}
+		if !reflect.DeepEqual(response.Data, expected) {
+			t.Errorf("expected %+v, got %+v", expected, response.Data)
+		}
+
+		devices, _ := service.GetAllDevices()
+		if len(devices) != 1 || devices[0].ID != "device-keep" {
+			t.Errorf("expected only device-keep to remain, got %d devices", len(devices))
+		}
+	})
+
+	t.Run("reports devices of other tenants as not found", func(t *testing.T) {
+		server, service := setupTestServer()
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-tenant-b", Algorithm: "ECC", TenantID: "tenant-b"})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/bulk-delete", strings.NewReader(`{"ids":["device-tenant-b"]}`))
+		req.Header.Set(TenantHeader, "tenant-a")
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		var response struct {
+			Data []model.DeleteResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if len(response.Data) != 1 || response.Data[0].Status != model.DeleteStatusNotFound {
+			t.Errorf("expected not_found, got %+v", response.Data)
+		}
+		if _, err := service.GetDevice("device-tenant-b"); err != nil {
+			t.Errorf("expected device of another tenant to remain, got %v", err)
+		}
+	})
+
+	t.Run("rejects empty and oversized batches", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		ids := make([]string, MaxBulkDeleteIDs+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("device-%d", i)
+		}
+		for _, req := range []model.BulkDeleteDevicesRequest{{}, {IDs: ids}} {
+			body, _ := json.Marshal(req)
+			w := httptest.NewRecorder()
+			server.BulkDeleteDevices(w, httptest.NewRequest(http.MethodPost, "/api/v0/devices/bulk-delete", bytes.NewBuffer(body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d for %d IDs, got %d", http.StatusBadRequest, len(req.IDs), w.Code)
+			}
+		}
+	})
+}
+
+func TestListAlgorithms(t *testing.T) {
+	listAlgorithms := func(t *testing.T) map[string]AlgorithmResponse {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/algorithms", nil)
+		w := httptest.NewRecorder()
+
+		server.ListAlgorithms(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data []AlgorithmResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		byName := make(map[string]AlgorithmResponse, len(response.Data))
+		for _, algorithm := range response.Data {
+			byName[algorithm.Name] = algorithm
+		}
+		return byName
+	}
+
+	t.Run("lists RSA and ECC with parameters", func(t *testing.T) {
+		algorithms := listAlgorithms(t)
+
+		rsaInfo, ok := algorithms["RSA"]
+		if !ok {
+			t.Fatal("expected RSA to be listed")
+		}
+		if rsaInfo.DefaultKeySize != signingcrypto.DefaultRSAKeySize || len(rsaInfo.KeySizes) == 0 {
+			t.Errorf("expected RSA key size details, got %+v", rsaInfo)
+		}
+
+		eccInfo, ok := algorithms["ECC"]
+		if !ok {
+			t.Fatal("expected ECC to be listed")
+		}
+		if eccInfo.DefaultCurve != signingcrypto.DefaultECCCurve || len(eccInfo.Curves) == 0 {
+			t.Errorf("expected ECC curve details, got %+v", eccInfo)
+		}
+		if len(eccInfo.Hashes) == 0 || len(eccInfo.SignatureEncodings) == 0 {
+			t.Errorf("expected hash and encoding details, got %+v", eccInfo)
+		}
+	})
+
+	t.Run("newly registered algorithm is listed", func(t *testing.T) {
+		if _, ok := signingcrypto.LookupAlgorithm("API-TEST"); !ok {
+			signingcrypto.MustRegisterAlgorithm(signingcrypto.AlgorithmInfo{
+				Name:   "API-TEST",
+				Hashes: []string{"SHA-512"},
+			})
+		}
+
+		if _, ok := listAlgorithms(t)["API-TEST"]; !ok {
+			t.Error("expected registered algorithm to be listed")
+		}
+	})
+}
+
+func TestCounterIntegrity(t *testing.T) {
+	t.Run("complete history", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-integrity-001", Algorithm: "ECC"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/integrity", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data model.IntegrityReport `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if !response.Data.OK || response.Data.Missing == nil || len(response.Data.Missing) != 0 {
+			t.Errorf("expected ok with empty missing list, got %+v", response.Data)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/missing/integrity", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestSignDataMaxLength(t *testing.T) {
+	t.Run("over limit returns 400", func(t *testing.T) {
+		server, service := setupTestServer(domain.WithMaxSignDataLength(4))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-maxlen-001", Algorithm: "ECC"})
+
+		body, _ := json.Marshal(model.SignDataRequest{Data: "too long"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBuffer(body))
+		req = mux.SetURLVars(req, map[string]string{"id": device.ID})
+		w := httptest.NewRecorder()
+
+		server.SignData(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestSignDataEmptyData(t *testing.T) {
+	sign := func(server *Server, id string) int {
+		body, _ := json.Marshal(model.SignDataRequest{Data: ""})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+id+"/sign", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("allowed by default", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-empty-001", Algorithm: "ECC"})
+
+		if code := sign(server, device.ID); code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("rejected with 400 when configured", func(t *testing.T) {
+		server, service := setupTestServer(domain.WithRejectEmptyData(true))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-empty-002", Algorithm: "ECC"})
+
+		if code := sign(server, device.ID); code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestVerifyOnlyDevice(t *testing.T) {
+	keyPair, _ := (&signingcrypto.ECCGenerator{}).Generate()
+	der, _ := x509.MarshalPKIXPublicKey(keyPair.Public)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	server, service := setupTestServer()
+	handler := server.Handler()
+
+	t.Run("creates verify-only device", func(t *testing.T) {
+		body, _ := json.Marshal(model.CreateDeviceRequest{ID: "device-import-001", Label: "Imported", ImportPublicKeyPEM: publicKeyPEM})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var response struct {
+			Data model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if !response.Data.VerifyOnly || response.Data.Algorithm != "ECC" {
+			t.Errorf("expected verify-only ECC device, got %+v", response.Data)
+		}
+	})
+
+	t.Run("signing is refused", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-import-001/sign", bytes.NewBufferString(`{"data":"payload"}`))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("verifies signature made elsewhere", func(t *testing.T) {
+		lastSignature := base64.StdEncoding.EncodeToString([]byte("device-import-001"))
+		signedData := domain.FormatSignedData(0, "payload", lastSignature, domain.DefaultSeparator)
+		signature, _ := signingcrypto.NewECDSASigner(keyPair.Private).Sign([]byte(signedData))
+
+		body, _ := json.Marshal([]model.VerifySignatureRequest{{
+			Data:          "payload",
+			Signature:     base64.StdEncoding.EncodeToString(signature),
+			Counter:       0,
+			LastSignature: lastSignature,
+		}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-import-001/verify/batch", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		var response struct {
+			Data []model.VerifyResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if len(response.Data) != 1 || !response.Data[0].Valid {
+			t.Errorf("expected valid result, got %+v", response.Data)
+		}
+
+		device, _ := service.GetDevice("device-import-001")
+		if device.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("invalid PEM returns 400", func(t *testing.T) {
+		body, _ := json.Marshal(model.CreateDeviceRequest{ID: "device-import-002", ImportPublicKeyPEM: "garbage"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("mismatched algorithm returns 400", func(t *testing.T) {
+		body, _ := json.Marshal(model.CreateDeviceRequest{ID: "device-import-003", Algorithm: "RSA", ImportPublicKeyPEM: publicKeyPEM})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestExportSignaturesCSV(t *testing.T) {
+	t.Run("streams history as CSV", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-csv-001", Algorithm: "ECC"})
+		for i := 0; i < 3; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: fmt.Sprintf("payload, \"%d\"", i)})
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/signatures.csv", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+			t.Errorf("expected text/csv content type, got %s", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment") {
+			t.Errorf("expected attachment disposition, got %s", cd)
+		}
+
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("expected valid CSV, got %v", err)
+		}
+		if len(rows) != 4 {
+			t.Fatalf("expected header and 3 rows, got %d rows", len(rows))
+		}
+		if strings.Join(rows[0], ",") != "counter,timestamp,data,signature" {
+			t.Errorf("unexpected header %v", rows[0])
+		}
+		if rows[3][0] != "2" || rows[3][2] != `payload, "2"` {
+			t.Errorf("unexpected last row %v", rows[3])
+		}
+	})
+
+	t.Run("formula-like data is escaped", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-csv-002", Algorithm: "ECC"})
+		values := []string{"=HYPERLINK(\"http://evil\")", "+1", "-1", "@SUM(A1)", "\tcmd", "\rcmd", "plain"}
+		for _, value := range values {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: value})
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/signatures.csv", nil)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("expected valid CSV, got %v", err)
+		}
+		for i, value := range values {
+			expected := "'" + value
+			if value == "plain" {
+				expected = value
+			}
+			if got := rows[i+1][2]; got != expected {
+				t.Errorf("expected data %q, got %q", expected, got)
+			}
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/missing/signatures.csv", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestCompareDevices(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+
+	first, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-compare-001", Algorithm: "ECC"})
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-compare-002", Algorithm: "ECC"})
+	der, _ := x509.MarshalPKIXPublicKey(first.PublicKey)
+	service.CreateDevice(model.CreateDeviceOptions{
+		ID:                 "device-compare-003",
+		ImportPublicKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	})
+
+	compare := func(t *testing.T, a, b string) (int, model.CompareDevicesResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/compare?a="+a+"&b="+b, nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		var response struct {
+			Data model.CompareDevicesResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Data
+	}
+
+	t.Run("distinct devices", func(t *testing.T) {
+		code, response := compare(t, "device-compare-001", "device-compare-002")
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if response.SamePublicKey {
+			t.Error("expected different public keys")
+		}
+	})
+
+	t.Run("imported copy of the same key", func(t *testing.T) {
+		code, response := compare(t, "device-compare-001", "device-compare-003")
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if !response.SamePublicKey {
+			t.Error("expected same public key")
+		}
+	})
+
+	t.Run("missing device", func(t *testing.T) {
+		if code, _ := compare(t, "device-compare-001", "missing"); code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, code)
+		}
+	})
+
+	t.Run("missing parameter", func(t *testing.T) {
+		if code, _ := compare(t, "device-compare-001", ""); code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestSignatureThroughput(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-throughput-001", Algorithm: "ECC"})
+	for i := 0; i < 3; i++ {
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+	}
+
+	t.Run("counts recent signatures", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/throughput?window=60s", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data model.ThroughputResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if response.Data.Signatures != 3 || response.Data.Window != "1m0s" {
+			t.Errorf("expected 3 signatures in 1m0s, got %+v", response.Data)
+		}
+	})
+
+	t.Run("unsupported window", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/throughput?window=7s", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestRegenerateInitialSignature(t *testing.T) {
+	t.Run("used device returns 409", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-reinit-001", Algorithm: "ECC"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/initial-signature", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("fresh device returns 200", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-reinit-002", Algorithm: "ECC"})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/initial-signature", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+func TestDeviceLocking(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-lock-001", Algorithm: "ECC"})
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	signPath := "/api/v0/devices/" + device.ID + "/sign"
+
+	t.Run("lock", func(t *testing.T) {
+		w := post("/api/v0/devices/"+device.ID+"/lock", "")
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if !response.Data.Locked {
+			t.Error("expected device to be reported as locked")
+		}
+	})
+
+	t.Run("signing is blocked while locked", func(t *testing.T) {
+		if w := post(signPath, `{"data":"payload"}`); w.Code != http.StatusLocked {
+			t.Errorf("expected status %d, got %d", http.StatusLocked, w.Code)
+		}
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		if w := post("/api/v0/devices/"+device.ID+"/unlock", ""); w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("signing resumes after unlock", func(t *testing.T) {
+		if w := post(signPath, `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		updated, _ := service.GetDevice(device.ID)
+		if updated.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", updated.SignatureCounter)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		if w := post("/api/v0/devices/missing/lock", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestGetCounter(t *testing.T) {
+	t.Run("reflects the counter after several signs", func(t *testing.T) {
+		server, service := setupTestServer()
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-counter-001", Algorithm: "ECC"})
+		var last *model.SignDataResponse
+		for i := 0; i < 3; i++ {
+			last, _ = service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/counter", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data model.ChainHeadResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if response.Data.Counter != 3 {
+			t.Errorf("expected counter 3, got %d", response.Data.Counter)
+		}
+		if response.Data.LastSignature != last.Signature {
+			t.Errorf("expected last signature %s, got %s", last.Signature, response.Data.LastSignature)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/counter", nil)
+		req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		w = httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("expected status %d, got %d", http.StatusNotModified, w.Code)
+		}
+	})
+
+	t.Run("recreated device at the same counter gets a new ETag", func(t *testing.T) {
+		server, service := setupTestServer()
+		get := func(etag string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/device-counter-etag/counter", nil)
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+			return w
+		}
+
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-counter-etag", Algorithm: "ECC"})
+		service.SignData(model.SignDataOptions{DeviceID: "device-counter-etag", Data: "payload"})
+		etag := get("").Header().Get("ETag")
+
+		service.DeleteDevices("", []string{"device-counter-etag"})
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-counter-etag", Algorithm: "ECC"})
+		service.SignData(model.SignDataOptions{DeviceID: "device-counter-etag", Data: "payload"})
+
+		if w := get(etag); w.Code != http.StatusOK {
+			t.Errorf("expected status %d for the new chain, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/missing/counter", nil)
+		w := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestListChainTips(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+
+	ids := []string{"device-chains-001", "device-chains-002", "device-chains-003"}
+	for i, id := range ids {
+		service.CreateDevice(model.CreateDeviceOptions{ID: id, Algorithm: "ECC"})
+		for j := 0; j < i; j++ {
+			service.SignData(model.SignDataOptions{DeviceID: id, Data: "payload"})
+		}
+	}
+
+	list := func(t *testing.T, query string) (int, model.ChainTipsPage) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/chains"+query, nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		var response struct {
+			Data model.ChainTipsPage `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Data
+	}
+
+	t.Run("tips match each device's current state", func(t *testing.T) {
+		code, page := list(t, "")
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if len(page.Chains) != len(ids) || page.Next != "" {
+			t.Fatalf("expected %d tips on a single page, got %+v", len(ids), page)
+		}
+		for i, tip := range page.Chains {
+			device, _ := service.GetDevice(ids[i])
+			if tip.ID != device.ID || tip.Counter != device.SignatureCounter || tip.LastSignature != device.LastSignature {
+				t.Errorf("expected tip %s/%d/%s, got %+v", device.ID, device.SignatureCounter, device.LastSignature, tip)
+			}
+		}
+	})
+
+	t.Run("pages follow the next cursor", func(t *testing.T) {
+		code, first := list(t, "?limit=2")
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if len(first.Chains) != 2 || first.Next != "device-chains-002" {
+			t.Fatalf("expected 2 tips and next device-chains-002, got %+v", first)
+		}
+
+		_, second := list(t, "?limit=2&after="+first.Next)
+		if len(second.Chains) != 1 || second.Chains[0].ID != "device-chains-003" || second.Next != "" {
+			t.Errorf("expected only device-chains-003 on the last page, got %+v", second)
+		}
+	})
+
+	t.Run("invalid limit returns 400", func(t *testing.T) {
+		for _, query := range []string{"?limit=0", "?limit=abc", "?limit=1001"} {
+			if code, _ := list(t, query); code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, code)
+			}
+		}
+	})
+}
+
+func TestDeviceCapabilities(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+
+	signing, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-caps-001", Algorithm: "RSA"})
+	der, _ := x509.MarshalPKIXPublicKey(signing.PublicKey)
+	service.CreateDevice(model.CreateDeviceOptions{
+		ID:                 "device-caps-002",
+		ImportPublicKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	})
+
+	capabilities := func(t *testing.T, id string) (int, model.DeviceCapabilities) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+id+"/capabilities", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		var response struct {
+			Data model.DeviceCapabilities `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Data
+	}
+
+	t.Run("signing device", func(t *testing.T) {
+		code, caps := capabilities(t, "device-caps-001")
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if !caps.Sign || !caps.Verify || caps.VerifyOnly || caps.Locked {
+			t.Errorf("expected a device that signs and verifies, got %+v", caps)
+		}
+		if len(caps.Hashes) != 1 || caps.Hashes[0] != "SHA-256" {
+			t.Errorf("expected SHA-256 hash, got %v", caps.Hashes)
+		}
+		if len(caps.SignatureEncodings) != 1 || caps.SignatureEncodings[0] != "PKCS#1 v1.5" {
+			t.Errorf("expected PKCS#1 v1.5 encoding, got %v", caps.SignatureEncodings)
+		}
+	})
+
+	t.Run("verify-only device", func(t *testing.T) {
+		code, caps := capabilities(t, "device-caps-002")
+
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if caps.Sign || !caps.Verify || !caps.VerifyOnly {
+			t.Errorf("expected sign:false on a verify-only device, got %+v", caps)
+		}
+		if len(caps.Formats) != 0 {
+			t.Errorf("expected no sign formats, got %v", caps.Formats)
+		}
+	})
+
+	t.Run("locked device", func(t *testing.T) {
+		service.SetDeviceLocked("device-caps-001", true)
+		defer service.SetDeviceLocked("device-caps-001", false)
+
+		if _, caps := capabilities(t, "device-caps-001"); caps.Sign || !caps.Locked {
+			t.Errorf("expected sign:false on a locked device, got %+v", caps)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		if code, _ := capabilities(t, "missing"); code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, code)
+		}
+	})
+}
+
+func TestSignDataRejectDuplicates(t *testing.T) {
+	server, service := setupTestServer()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-dup-001", Algorithm: "ECC", RejectDuplicates: true})
+	handler := server.Handler()
+
+	sign := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBufferString(`{"data":"invoice-1"}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := sign(); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if code := sign(); code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, code)
+	}
+}
+
+func TestSignDataIgnoresAlgorithmOverride(t *testing.T) {
+	server, service := setupTestServer()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-override-001", Algorithm: "ECC", Hash: signingcrypto.HashSHA256})
+
+	body := `{"data":"payload","algorithm":"RSA","key_size":2048}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Data model.SignDataResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	signature, _ := base64.StdEncoding.DecodeString(response.Data.Signature)
+	digest := sha256.Sum256([]byte(response.Data.SignedData))
+	if !ecdsa.VerifyASN1(device.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		t.Error("expected an ECDSA signature from the device's own key")
+	}
+}
+
+func TestListSignaturesByPurpose(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-purpose-001", Algorithm: "ECC"})
+
+	for _, purpose := range []string{"invoice", "receipt", "invoice", ""} {
+		body := fmt.Sprintf(`{"data":"payload","purpose":%q}`, purpose)
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var response struct {
+			Data model.SignDataResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Data.Purpose != purpose {
+			t.Errorf("expected purpose %q in response, got %q", purpose, response.Data.Purpose)
+		}
+		if strings.Contains(response.Data.SignedData, "invoice") || strings.Contains(response.Data.SignedData, "receipt") {
+			t.Errorf("expected purpose not to be signed, got %s", response.Data.SignedData)
+		}
+	}
+
+	list := func(t *testing.T, query string) []model.SignatureRecord {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+device.ID+"/signatures"+query, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data []model.SignatureRecord `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	t.Run("filters by purpose", func(t *testing.T) {
+		records := list(t, "?purpose=invoice")
+
+		if len(records) != 2 {
+			t.Fatalf("expected 2 invoice records, got %d", len(records))
+		}
+		if records[0].Counter != 0 || records[1].Counter != 2 {
+			t.Errorf("expected counters 0 and 2, got %d and %d", records[0].Counter, records[1].Counter)
+		}
+	})
+
+	t.Run("returns all records without a filter", func(t *testing.T) {
+		if records := list(t, ""); len(records) != 4 {
+			t.Errorf("expected 4 records, got %d", len(records))
+		}
+	})
+
+	t.Run("unknown purpose yields an empty list", func(t *testing.T) {
+		if records := list(t, "?purpose=refund"); records == nil || len(records) != 0 {
+			t.Errorf("expected empty list, got %v", records)
+		}
+	})
+}
+
+func TestVerifyBatchSignatureEncodings(t *testing.T) {
+	server, service := setupTestServer()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-encodings-001", Algorithm: "ECC"})
+	initial := device.LastSignature
+	signed, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+	raw, _ := base64.StdEncoding.DecodeString(signed.Signature)
+
+	var entries []model.VerifySignatureRequest
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		entries = append(entries, model.VerifySignatureRequest{
+			Data: "payload", Signature: encoding.EncodeToString(raw), Counter: 0, LastSignature: initial,
+		})
+	}
+	entries = append(entries, model.VerifySignatureRequest{
+		Data: "payload", Signature: "not base64!", Counter: 0, LastSignature: initial,
+	})
+	body, _ := json.Marshal(entries)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/verify/batch", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+
+	var response struct {
+		Data []model.VerifyResult `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if len(response.Data) != len(entries) {
+		t.Fatalf("expected %d results, got %d", len(entries), len(response.Data))
+	}
+	for i, result := range response.Data[:4] {
+		if !result.Valid {
+			t.Errorf("entry %d: expected valid, got %+v", i, result)
+		}
+	}
+	if last := response.Data[4]; last.Valid || !strings.Contains(last.Error, "invalid signature encoding") {
+		t.Errorf("expected a clear encoding error, got %+v", last)
+	}
+}
+
+func TestDeviceSize(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-size-rsa", Algorithm: "RSA", KeySize: 2048})
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-size-ecc", Algorithm: "ECC"})
+
+	size := func(t *testing.T, id string) model.DeviceSizeResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+id+"/size", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data model.DeviceSizeResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	t.Run("grows as history grows", func(t *testing.T) {
+		before := size(t, "device-size-ecc")
+		service.SignData(model.SignDataOptions{DeviceID: "device-size-ecc", Data: "payload"})
+		after := size(t, "device-size-ecc")
+
+		if before.HistoryEntries != 0 || after.HistoryEntries != 1 {
+			t.Errorf("expected history to grow from 0 to 1, got %d and %d", before.HistoryEntries, after.HistoryEntries)
+		}
+		if after.DataBytes != int64(len("payload")) {
+			t.Errorf("expected %d data bytes, got %d", len("payload"), after.DataBytes)
+		}
+		if after.TotalBytes <= before.TotalBytes {
+			t.Errorf("expected total to grow, got %d then %d", before.TotalBytes, after.TotalBytes)
+		}
+	})
+
+	t.Run("RSA keys are larger than ECC keys", func(t *testing.T) {
+		rsa, ecc := size(t, "device-size-rsa"), size(t, "device-size-ecc")
+
+		if rsa.PublicKeyBytes <= ecc.PublicKeyBytes || rsa.PrivateKeyBytes <= ecc.PrivateKeyBytes {
+			t.Errorf("expected RSA keys to be larger, got RSA %+v and ECC %+v", rsa, ecc)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/missing/size", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestFeatureFlags(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-flags-001", Algorithm: "ECC"})
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	signPath := "/api/v0/devices/" + device.ID + "/sign"
+	createBody := func(id string) string { return `{"id":"` + id + `","algorithm":"ECC"}` }
+
+	t.Run("everything is enabled by default", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/admin/flags", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var response struct {
+			Data model.FeatureFlags `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if !response.Data.Signing || !response.Data.DeviceCreation {
+			t.Errorf("expected all flags enabled, got %+v", response.Data)
+		}
+	})
+
+	t.Run("disabling signing returns 503 from sign and attest", func(t *testing.T) {
+		w := post("/api/v0/admin/flags", `{"signing":false}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data model.FeatureFlags `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Data.Signing || !response.Data.DeviceCreation {
+			t.Errorf("expected only signing disabled, got %+v", response.Data)
+		}
+
+		if w := post(signPath, `{"data":"payload"}`); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected sign status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		attest := `{"signature":"c2lnbmF0dXJl"}`
+		if w := post("/api/v0/devices/"+device.ID+"/attest", attest); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected attest status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if w := post("/api/v0/devices", createBody("device-flags-002")); w.Code != http.StatusCreated {
+			t.Errorf("expected device creation to stay enabled, got status %d", w.Code)
+		}
+	})
+
+	t.Run("re-enabling signing", func(t *testing.T) {
+		post("/api/v0/admin/flags", `{"signing":true}`)
+
+		if w := post(signPath, `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("disabling device creation", func(t *testing.T) {
+		post("/api/v0/admin/flags", `{"device_creation":false}`)
+
+		if w := post("/api/v0/devices", createBody("device-flags-003")); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if w := post(signPath, `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Errorf("expected signing to stay enabled, got status %d", w.Code)
+		}
+
+		post("/api/v0/admin/flags", `{"device_creation":true}`)
+		if w := post("/api/v0/devices", createBody("device-flags-003")); w.Code != http.StatusCreated {
+			t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	})
+
+	t.Run("update without flags", func(t *testing.T) {
+		if w := post("/api/v0/admin/flags", `{}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestSignDataNonce(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-api", Algorithm: "ECC", RequireNonce: true})
+	signPath := "/api/v0/devices/" + device.ID + "/sign"
+
+	sign := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, signPath, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("fresh nonce", func(t *testing.T) {
+		w := sign(`{"data":"payload","nonce":"n0nce4api"}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data model.SignDataResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if !strings.HasSuffix(response.Data.SignedData, "_n0nce4api") {
+			t.Errorf("expected nonce in signed_data, got %q", response.Data.SignedData)
+		}
+		if response.Data.Nonce != "n0nce4api" {
+			t.Errorf("expected nonce to be echoed, got %q", response.Data.Nonce)
+		}
+	})
+
+	t.Run("reused nonce", func(t *testing.T) {
+		if w := sign(`{"data":"other","nonce":"n0nce4api"}`); w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("missing nonce", func(t *testing.T) {
+		if w := sign(`{"data":"payload"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestSelfVerify(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-self-api", Algorithm: "ECC"})
+	for i := 0; i < 3; i++ {
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+	}
+
+	selfVerify := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+id+"/self-verify", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) model.SelfVerifyReport {
+		var response struct {
+			Data model.SelfVerifyReport `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	t.Run("healthy device", func(t *testing.T) {
+		w := selfVerify(device.ID)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if report := decode(w); !report.OK || report.Verified != 3 {
+			t.Errorf("expected 3 verified records, got %+v", report)
+		}
+	})
+
+	t.Run("corrupted stored signature", func(t *testing.T) {
+		stored, _ := service.GetDevice(device.ID)
+		stored.History[2].Signature = stored.History[0].Signature
+
+		report := decode(selfVerify(device.ID))
+
+		if report.OK || len(report.Failures) == 0 {
+			t.Errorf("expected failures, got %+v", report)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		if w := selfVerify("missing"); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestSignDataExpectedCounter(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-cas-001", Algorithm: "ECC"})
+	service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+
+	sign := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("matching counter signs", func(t *testing.T) {
+		w := sign(`{"data":"second","expected_counter":1}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected counter 2, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("mismatching counter is rejected without incrementing", func(t *testing.T) {
+		w := sign(`{"data":"third","expected_counter":1}`)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected counter to stay 2, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("zero is a valid expectation", func(t *testing.T) {
+		fresh, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-cas-002", Algorithm: "ECC"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+fresh.ID+"/sign", bytes.NewBufferString(`{"data":"first","expected_counter":0}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+// failingUpdateStorage is an in-memory storage whose updates fail, so signing fails on persist.
+type failingUpdateStorage struct {
+	*persistence.InMemoryStorage
+}
+
+func (s failingUpdateStorage) Update(*model.SignatureDevice) error {
+	return errors.New("disk full")
+}
+
+// failingSigner is a signer that always fails.
+type failingSigner struct{}
+
+func (failingSigner) Sign([]byte) ([]byte, error) {
+	return nil, errors.New("hardware token unavailable")
+}
+
+func TestSignDataErrorMapping(t *testing.T) {
+	sign := func(handler http.Handler, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+id+"/sign", strings.NewReader(`{"data":"payload"}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	errorMessage := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var response ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil || len(response.Errors) == 0 {
+			t.Fatalf("expected error response, got %q", w.Body.String())
+		}
+		return response.Errors[0]
+	}
+
+	t.Run("missing device returns 404", func(t *testing.T) {
+		server, _ := setupTestServer()
+
+		w := sign(server.Handler(), "missing")
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if msg := errorMessage(t, w); msg != "Device not found" {
+			t.Errorf("expected %q, got %q", "Device not found", msg)
+		}
+	})
+
+	t.Run("locked device returns 423", func(t *testing.T) {
+		server, service := setupTestServer()
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "locked", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		if _, err := service.SetDeviceLocked("locked", true); err != nil {
+			t.Fatalf("failed to lock device: %v", err)
+		}
+
+		w := sign(server.Handler(), "locked")
+		if w.Code != http.StatusLocked {
+			t.Fatalf("expected status %d, got %d", http.StatusLocked, w.Code)
+		}
+		if msg := errorMessage(t, w); msg != "Device is locked" {
+			t.Errorf("expected %q, got %q", "Device is locked", msg)
+		}
+	})
+
+	t.Run("rate limited client returns 429", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "limited", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		handler := NewServer(":8080", service, WithRateLimit(0.001, 1)).Handler()
+
+		if w := sign(handler, "limited"); w.Code != http.StatusOK {
+			t.Fatalf("expected first status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w := sign(handler, "limited"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+	})
+
+	t.Run("signer failure returns 500", func(t *testing.T) {
+		storage := persistence.NewInMemoryStorage()
+		service := domain.NewSignatureDeviceService(storage)
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "broken-signer", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		device, _ := storage.GetDevice("broken-signer")
+		device.Signer = failingSigner{}
+
+		w := sign(NewServer(":8080", service).Handler(), "broken-signer")
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		if msg := errorMessage(t, w); msg != "Failed to sign data: signer error" {
+			t.Errorf("expected signer error message, got %q", msg)
+		}
+	})
+
+	t.Run("storage failure returns 500", func(t *testing.T) {
+		storage := failingUpdateStorage{persistence.NewInMemoryStorage()}
+		service := domain.NewSignatureDeviceService(storage)
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "broken-storage", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+
+		w := sign(NewServer(":8080", service).Handler(), "broken-storage")
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		if msg := errorMessage(t, w); msg != "Failed to sign data: could not store device state" {
+			t.Errorf("expected storage error message, got %q", msg)
+		}
+	})
+}
+
+func TestTaggedSignature(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+
+	device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-tagged-001", Algorithm: "RSA", KeySize: 2048})
+	if err != nil {
+		t.Fatalf("failed to create device: %v", err)
+	}
+	initial := device.LastSignature
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", strings.NewReader(`{"data":"payload","format":"tagged"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var signResponse struct {
+		Data model.SignDataResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&signResponse)
+	signed := signResponse.Data
+
+	t.Run("sign returns tagged and raw signature", func(t *testing.T) {
+		if signed.TaggedSignature != "RSA-PKCS1-SHA256:"+signed.Signature {
+			t.Errorf("expected tagged form of %q, got %q", signed.Signature, signed.TaggedSignature)
+		}
+	})
+
+	t.Run("verify accepts the tagged form", func(t *testing.T) {
+		entries := []model.VerifySignatureRequest{
+			{Data: "payload", Signature: signed.TaggedSignature, Counter: 0, LastSignature: initial},
+			{Data: "payload", Signature: signed.Signature, Counter: 0, LastSignature: initial},
+			{Data: "payload", Signature: "ECDSA-SHA256:" + signed.Signature, Counter: 0, LastSignature: initial},
+			{Data: "payload", Signature: "UNKNOWN:" + signed.Signature, Counter: 0, LastSignature: initial},
+		}
+		body, _ := json.Marshal(entries)
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/verify/batch", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Data []model.VerifyResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		expected := []bool{true, true, false, false}
+		if len(response.Data) != len(expected) {
+			t.Fatalf("expected %d results, got %d", len(expected), len(response.Data))
+		}
+		for i, valid := range expected {
+			if response.Data[i].Valid != valid {
+				t.Errorf("entry %d: expected valid=%v, got %v (%s)", i, valid, response.Data[i].Valid, response.Data[i].Error)
+			}
+		}
+	})
+}
+
+func TestMultibaseSignature(t *testing.T) {
+	server, service := setupTestServer()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-multibase-001", Algorithm: "ECC"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/"+device.ID+"/sign", strings.NewReader(`{"data":"payload","format":"multibase"}`))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data model.SignDataResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if !strings.HasPrefix(response.Data.MultibaseSignature, "z") {
+		t.Fatalf("expected base58btc multibase signature, got %q", response.Data.MultibaseSignature)
+	}
+	decoded, err := signingcrypto.DecodeMultibase(response.Data.MultibaseSignature)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(response.Data.Signature)
+	if !bytes.Equal(decoded, raw) {
+		t.Errorf("expected multibase signature to decode to %x, got %x", raw, decoded)
+	}
+	if err := device.Verifier.Verify([]byte(response.Data.SignedData), decoded); err != nil {
+		t.Errorf("expected decoded signature to verify, got %v", err)
+	}
+}
+
+func TestWriteInternalError(t *testing.T) {
+	assertEnvelope := func(t *testing.T, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("expected application/json, got %q", contentType)
+		}
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("expected valid JSON, got %q: %v", w.Body.String(), err)
+		}
+		if len(response.Errors) != 1 || response.Errors[0] != "Internal Server Error" {
+			t.Errorf("expected errors [Internal Server Error], got %v", response.Errors)
+		}
+	}
+
+	t.Run("writes the error envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteInternalError(w)
+		assertEnvelope(t, w)
+	})
+
+	t.Run("unmarshalable response data becomes an internal error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteAPIResponse(w, http.StatusOK, make(chan int))
+		assertEnvelope(t, w)
+	})
+}
+
+func TestMigrateDevice(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "migrate-rsa", Algorithm: "RSA"}); err != nil {
+		t.Fatalf("failed to create device: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if w := post("/api/v0/devices/migrate-rsa/sign", `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+
+	t.Run("creates the successor continuing the counter", func(t *testing.T) {
+		w := post("/api/v0/devices/migrate-rsa/migrate", `{"id":"migrate-ecc","algorithm":"ECC"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Data.Algorithm != "ECC" || response.Data.SignatureCounter != 2 || response.Data.MigratedFrom != "migrate-rsa" {
+			t.Errorf("expected ECC successor at counter 2 migrated from migrate-rsa, got %+v", response.Data)
+		}
+
+		if w := post("/api/v0/devices/migrate-ecc/sign", `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Errorf("expected successor to sign, got status %d", w.Code)
+		}
+	})
+
+	t.Run("source can no longer sign", func(t *testing.T) {
+		if w := post("/api/v0/devices/migrate-rsa/sign", `{"data":"payload"}`); w.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+		if w := post("/api/v0/devices/migrate-rsa/migrate", `{"id":"migrate-ecc-2"}`); w.Code != http.StatusConflict {
+			t.Errorf("expected repeated migration status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		if w := post("/api/v0/devices/missing/migrate", `{"id":"migrate-ecc-3"}`); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestUnwrappedResponses(t *testing.T) {
+	sign := func(t *testing.T, opts ...ServerOption) map[string]json.RawMessage {
+		t.Helper()
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		server := NewServer(":8080", service, opts...)
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-unwrap", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-unwrap/sign", strings.NewReader(`{"data":"payload"}`))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body
+	}
+
+	t.Run("unwrapped mode returns fields at the top level", func(t *testing.T) {
+		body := sign(t, WithUnwrappedResponses(true))
+		if _, ok := body["data"]; ok {
+			t.Error("expected no data envelope")
+		}
+		var signedData string
+		if err := json.Unmarshal(body["signed_data"], &signedData); err != nil || !strings.HasPrefix(signedData, "0_payload_") {
+			t.Errorf("expected top-level signed_data, got %s", body["signed_data"])
+		}
+		if _, ok := body["signature"]; !ok {
+			t.Error("expected top-level signature")
+		}
+	})
+
+	t.Run("default wraps in data", func(t *testing.T) {
+		body := sign(t)
+		var response model.SignDataResponse
+		if err := json.Unmarshal(body["data"], &response); err != nil {
+			t.Fatalf("expected data envelope, got %v", body)
+		}
+		if response.Signature == "" {
+			t.Error("expected signature inside data")
+		}
+		if _, ok := body["signature"]; ok {
+			t.Error("expected no top-level signature")
+		}
+	})
+
+	t.Run("errors keep their envelope", func(t *testing.T) {
+		server := NewServer(":8080", domain.NewSignatureDeviceService(persistence.NewInMemoryStorage()), WithUnwrappedResponses(true))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/missing/sign", strings.NewReader(`{"data":"payload"}`))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		var response ErrorResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		if len(response.Errors) == 0 {
+			t.Error("expected errors envelope")
+		}
+	})
+
+	t.Run("sparse listings are unwrapped too", func(t *testing.T) {
+		service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+		server := NewServer(":8080", service, WithUnwrappedResponses(true))
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-sparse", Algorithm: "ECC"})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices?fields=id", nil)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		var devices []map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&devices); err != nil {
+			t.Fatalf("expected a top-level array: %v", err)
+		}
+		if len(devices) != 1 || devices[0]["id"] != "device-sparse" {
+			t.Errorf("expected [{id: device-sparse}], got %v", devices)
+		}
+	})
+}
+
+func TestSelfTest(t *testing.T) {
+	server, service := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/selftest", nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data model.SelfTestReport `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.Data.OK {
+		t.Errorf("expected self-test to pass, got %+v", response.Data)
+	}
+	algorithms := map[string]bool{}
+	for _, result := range response.Data.Results {
+		if !result.OK {
+			t.Errorf("expected %s to pass, got error %q", result.Algorithm, result.Error)
+		}
+		algorithms[result.Algorithm] = true
+	}
+	if !algorithms["RSA"] || !algorithms["ECC"] {
+		t.Errorf("expected results for RSA and ECC, got %+v", response.Data.Results)
+	}
+
+	devices, _ := service.GetAllDevices()
+	if len(devices) != 0 {
+		t.Errorf("expected no stored devices, got %d", len(devices))
+	}
+}
+
+// manualClock is a domain.Clock that only moves when advanced.
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSignDataQuota(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	server, _ := setupTestServer(domain.WithClock(clock))
+
+	body := `{"id":"device-quota","algorithm":"ECC","sign_quota":2,"quota_window_seconds":3600}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.CreateDevice(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	sign := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-quota/sign", strings.NewReader(`{"data":"payload"}`))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("reports remaining quota", func(t *testing.T) {
+		for _, expected := range []string{"1", "0"} {
+			w := sign()
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if got := w.Header().Get(QuotaRemainingHeader); got != expected {
+				t.Errorf("expected %s %q, got %q", QuotaRemainingHeader, expected, got)
+			}
+		}
+	})
+
+	t.Run("exhausted quota returns 429", func(t *testing.T) {
+		w := sign()
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if got := w.Header().Get(QuotaRemainingHeader); got != "0" {
+			t.Errorf("expected %s \"0\", got %q", QuotaRemainingHeader, got)
+		}
+	})
+
+	t.Run("quota recovers after the window", func(t *testing.T) {
+		clock.Advance(time.Hour)
+		w := sign()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get(QuotaRemainingHeader); got != "1" {
+			t.Errorf("expected %s \"1\", got %q", QuotaRemainingHeader, got)
+		}
+	})
+
+	t.Run("invalid quota returns 400", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices", strings.NewReader(`{"algorithm":"ECC","sign_quota":5}`))
+		w := httptest.NewRecorder()
+		server.CreateDevice(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestListAtRiskDevices(t *testing.T) {
+	server, service := setupTestServer()
+	for _, device := range []struct {
+		id    string
+		quota int
+		signs int
+	}{{"near", 10, 9}, {"far", 10, 2}, {"unlimited", 0, 12}} {
+		opts := model.CreateDeviceOptions{ID: device.id, Algorithm: "ECC", SignQuota: device.quota}
+		if device.quota > 0 {
+			opts.QuotaWindow = time.Hour
+		}
+		if _, err := service.CreateDevice(opts); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		for i := 0; i < device.signs; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.id, Data: "payload"})
+		}
+	}
+
+	list := func(t *testing.T, query string) []model.AtRiskDevice {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/admin/devices/at-risk"+query, nil)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []model.AtRiskDevice `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	t.Run("default threshold lists only devices near their quota", func(t *testing.T) {
+		devices := list(t, "")
+		if len(devices) != 1 || devices[0].ID != "near" {
+			t.Fatalf("expected only near, got %+v", devices)
+		}
+		if devices[0].QuotaUsed != 9 || devices[0].QuotaRemaining != 1 {
+			t.Errorf("expected 9 used and 1 remaining, got %+v", devices[0])
+		}
+	})
+
+	t.Run("wider threshold includes farther devices", func(t *testing.T) {
+		devices := list(t, "?threshold=0.8")
+		if len(devices) != 2 || devices[0].ID != "near" || devices[1].ID != "far" {
+			t.Errorf("expected near then far, got %+v", devices)
+		}
+	})
+
+	t.Run("invalid threshold returns 400", func(t *testing.T) {
+		for _, query := range []string{"?threshold=2", "?threshold=-0.1", "?threshold=abc"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/admin/devices/at-risk"+query, nil)
+			w := httptest.NewRecorder()
+			server.ListAtRiskDevices(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
+
+func TestSignDataOperationID(t *testing.T) {
+	server, service := setupTestServer()
+	if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op", Algorithm: "ECC"}); err != nil {
+		t.Fatalf("failed to create device: %v", err)
+	}
+
+	sign := func(t *testing.T, body string) (*httptest.ResponseRecorder, model.SignDataResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-op/sign", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		var response struct {
+			Data model.SignDataResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response.Data
+	}
+
+	w, first := sign(t, `{"data":"payload","operation_id":"op-1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	w, retry := sign(t, `{"data":"payload","operation_id":"op-1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d on retry, got %d", http.StatusOK, w.Code)
+	}
+	if retry.Signature != first.Signature || retry.SignedData != first.SignedData {
+		t.Errorf("expected the original signature on retry, got %+v and %+v", first, retry)
+	}
+	device, _ := service.GetDevice("device-op")
+	if device.SignatureCounter != 1 {
+		t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+	}
+
+	if w, _ := sign(t, `{"data":"other","operation_id":"op-1"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a conflicting retry, got %d", http.StatusConflict, w.Code)
+	}
+	if w, _ := sign(t, `{"data":"payload","operation_id":"op 1"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid operation ID, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestResponseSigning(t *testing.T) {
+	keyPair, _ := (&signingcrypto.ECCGenerator{}).Generate()
+	signer, err := signingcrypto.NewResponseSigner(keyPair.Private, "server")
+	if err != nil {
+		t.Fatalf("failed to create response signer: %v", err)
+	}
+	service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+	server := NewServer(":0", service, WithResponseSigner(signer))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v0/health", nil),
+		httptest.NewRequest(http.MethodPost, "/api/v0/devices/missing/sign", strings.NewReader(`{"data":"payload"}`)),
+	} {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+
+		header := w.Header().Get(signingcrypto.ResponseSignatureHeader)
+		response := signingcrypto.SignedResponse{
+			Method:      req.Method,
+			Target:      req.URL.RequestURI(),
+			Status:      w.Code,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.Body.Bytes(),
+		}
+		if err := signingcrypto.VerifyResponseSignature(header, response, keyPair.Public); err != nil {
+			t.Errorf("expected a valid signature for %s (status %d), got %v", req.URL.Path, w.Code, err)
+		}
+		if w.Header().Get("Signature") != "" {
+			t.Errorf("expected no RFC 9421 Signature header for %s", req.URL.Path)
+		}
+	}
+
+	t.Run("CSV export is streamed unsigned", func(t *testing.T) {
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-csv", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v0/devices/device-csv/signatures.csv", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if header := w.Header().Get(signingcrypto.ResponseSignatureHeader); header != "" {
+			t.Errorf("expected no response signature, got %q", header)
+		}
+	})
+}
+
+func TestGetAllDevicesTruncation(t *testing.T) {
+	service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+	for i := 0; i < 5; i++ {
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: fmt.Sprintf("device-%d", i), Algorithm: "ECC"}); err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+	}
+
+	list := func(t *testing.T, server *Server, query string) (*httptest.ResponseRecorder, []model.DeviceResponse, bool) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.GetAllDevices(w, httptest.NewRequest(http.MethodGet, "/api/v0/devices"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data      []model.DeviceResponse `json:"data"`
+			Truncated bool                   `json:"truncated"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("expected valid JSON, got %v", err)
+		}
+		return w, response.Data, response.Truncated
+	}
+
+	t.Run("more devices than the cap are truncated and flagged", func(t *testing.T) {
+		server := NewServer(":8080", service, WithMaxListDevices(3))
+		w, devices, truncated := list(t, server, "")
+		if !truncated || w.Header().Get(TruncatedHeader) != "true" {
+			t.Errorf("expected the truncated flag and header, got %v and %q", truncated, w.Header().Get(TruncatedHeader))
+		}
+		var ids []string
+		for _, device := range devices {
+			ids = append(ids, device.ID)
+		}
+		if expected := []string{"device-0", "device-1", "device-2"}; !reflect.DeepEqual(ids, expected) {
+			t.Errorf("expected %v, got %v", expected, ids)
+		}
+	})
+
+	t.Run("lists within the cap are not flagged", func(t *testing.T) {
+		server := NewServer(":8080", service, WithMaxListDevices(5))
+		w, devices, truncated := list(t, server, "")
+		if truncated || w.Header().Get(TruncatedHeader) != "" || len(devices) != 5 {
+			t.Errorf("expected 5 devices without the flag, got %d (truncated %v)", len(devices), truncated)
+		}
+		if strings.Contains(w.Body.String(), `"truncated"`) {
+			t.Errorf("expected no truncated field, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("fields apply to truncated lists", func(t *testing.T) {
+		server := NewServer(":8080", service, WithMaxListDevices(2))
+		_, devices, truncated := list(t, server, "?fields=id")
+		if !truncated || len(devices) != 2 || devices[0].ID != "device-0" || devices[0].Algorithm != "" {
+			t.Errorf("expected 2 sparse devices flagged as truncated, got %+v (truncated %v)", devices, truncated)
+		}
+	})
+
+	t.Run("zero lists every device", func(t *testing.T) {
+		server := NewServer(":8080", service, WithMaxListDevices(0))
+		if _, devices, truncated := list(t, server, ""); truncated || len(devices) != 5 {
+			t.Errorf("expected 5 devices without the flag, got %d (truncated %v)", len(devices), truncated)
+		}
+	})
+}
+
+func TestIntrospectSignature(t *testing.T) {
+	server, service := setupTestServer()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-intro", Algorithm: "ECC"})
+	signed, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Format: model.SignatureFormatTagged})
+
+	introspect := func(t *testing.T, body string) (int, model.IntrospectReport) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/introspect", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		var response struct {
+			Data model.IntrospectReport `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Data
+	}
+
+	t.Run("identifies a known device", func(t *testing.T) {
+		body, _ := json.Marshal(model.IntrospectRequest{SignedData: signed.SignedData, Signature: signed.TaggedSignature})
+		code, report := introspect(t, string(body))
+		if code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+		if !report.Identified || report.DeviceID != device.ID {
+			t.Errorf("expected %s to be identified, got %+v", device.ID, report)
+		}
+		if report.Chain == nil || report.Chain.Counter != 0 || report.Chain.Data != "payload" {
+			t.Errorf("expected counter 0 and data payload, got %+v", report.Chain)
+		}
+	})
+
+	t.Run("random signature is unidentified", func(t *testing.T) {
+		keyPair, _ := (&signingcrypto.ECCGenerator{}).Generate()
+		signature, _ := signingcrypto.NewECDSASigner(keyPair.Private).Sign([]byte(signed.SignedData))
+		body, _ := json.Marshal(model.IntrospectRequest{SignedData: signed.SignedData, Signature: base64.StdEncoding.EncodeToString(signature)})
+		code, report := introspect(t, string(body))
+		if code != http.StatusOK || report.Identified || report.DevicesScanned != 1 {
+			t.Errorf("expected an unidentified report after scanning 1 device, got %d %+v", code, report)
+		}
+	})
+
+	t.Run("extra segments are not parsed", func(t *testing.T) {
+		withAAD, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", AAD: "context"})
+		keyPair, _ := (&signingcrypto.ECCGenerator{}).Generate()
+		signature, _ := signingcrypto.NewECDSASigner(keyPair.Private).Sign([]byte(withAAD.SignedData))
+		body, _ := json.Marshal(model.IntrospectRequest{SignedData: withAAD.SignedData, Signature: base64.StdEncoding.EncodeToString(signature)})
+		code, report := introspect(t, string(body))
+		if code != http.StatusOK || report.Chain != nil || !strings.Contains(report.ParseError, "ambiguous") {
+			t.Errorf("expected an ambiguous parse error without chain, got %d %+v", code, report)
+		}
+	})
+
+	t.Run("scan without kid is capped", func(t *testing.T) {
+		server, service := setupTestServer(domain.WithMaxIntrospectScan(1))
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-scan-1", Algorithm: "ECC"})
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-scan-2", Algorithm: "ECC"})
+		signed, _ := service.SignData(model.SignDataOptions{DeviceID: "device-scan-2", Data: "payload"})
+
+		body, _ := json.Marshal(model.IntrospectRequest{SignedData: signed.SignedData, Signature: signed.Signature})
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v0/introspect", bytes.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d without kid, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		body, _ = json.Marshal(model.IntrospectRequest{SignedData: signed.SignedData, Signature: signed.Signature, KID: "device-scan-2"})
+		w = httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v0/introspect", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d with kid, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("status codes", func(t *testing.T) {
+		for body, expected := range map[string]int{
+			`{"signed_data":"0_a_b"}`:                                    http.StatusBadRequest,
+			`{"signed_data":"0_a_b","signature":"%%%"}`:                  http.StatusBadRequest,
+			`{"signed_data":"0_a_b","signature":"AAAA","kid":"missing"}`: http.StatusNotFound,
+		} {
+			if code, _ := introspect(t, body); code != expected {
+				t.Errorf("expected status %d for %s, got %d", expected, body, code)
+			}
+		}
+	})
+}
+
+func TestDeviceKeyStrength(t *testing.T) {
+	server, service := setupTestServer()
+	handler := server.Handler()
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-strength-rsa", Algorithm: "RSA", KeySize: 2048})
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-strength-ecc", Algorithm: "ECC", Curve: "P-384"})
+
+	weakKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	der, _ := x509.MarshalPKIXPublicKey(&weakKey.PublicKey)
+	if _, err := service.CreateDevice(model.CreateDeviceOptions{
+		ID:                 "device-strength-weak",
+		Algorithm:          "RSA",
+		ImportPublicKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}); err != nil {
+		t.Fatalf("failed to import weak key: %v", err)
+	}
+
+	strength := func(t *testing.T, id string) model.KeyStrength {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/"+id+"/strength", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data model.KeyStrength `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	t.Run("RSA-2048 is ok", func(t *testing.T) {
+		expected := model.KeyStrength{Algorithm: "RSA", KeySize: 2048, Hash: "SHA-256", KeyBits: 112, SecurityBits: 112, Rating: model.KeyRatingOK}
+		if got := strength(t, "device-strength-rsa"); got != expected {
+			t.Errorf("expected %+v, got %+v", expected, got)
+		}
+	})
+
+	t.Run("P-384 is ok", func(t *testing.T) {
+		expected := model.KeyStrength{Algorithm: "ECC", Curve: "P-384", Hash: "SHA-384", KeyBits: 192, SecurityBits: 192, Rating: model.KeyRatingOK}
+		if got := strength(t, "device-strength-ecc"); got != expected {
+			t.Errorf("expected %+v, got %+v", expected, got)
+		}
+	})
+
+	t.Run("imported RSA-1024 is weak", func(t *testing.T) {
+		got := strength(t, "device-strength-weak")
+		if got.KeySize != 1024 || got.SecurityBits != 80 || got.Rating != model.KeyRatingWeak {
+			t.Errorf("expected a weak 1024-bit key, got %+v", got)
+		}
+	})
+
+	t.Run("unknown device returns 404", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/devices/missing/strength", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestSignDataPreSignHook(t *testing.T) {
+	server, service := setupTestServer(domain.WithPreSignHook(func(deviceID string, data []byte) error {
+		if string(data) == "deny" {
+			return errors.New("data rejected by policy")
+		}
+		return nil
+	}))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-hook", Algorithm: "ECC"})
+
+	sign := func(data string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(model.SignDataRequest{Data: data})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-hook/sign", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := sign("allow"); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w := sign("deny")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	var response ErrorResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Errors) != 1 || !strings.Contains(response.Errors[0], "data rejected by policy") {
+		t.Errorf("expected the hook's message, got %v", response.Errors)
+	}
+	if device.SignatureCounter != 1 {
+		t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+	}
+}
+
+func TestSignDataPostSignHook(t *testing.T) {
+	var seen *model.SignDataResponse
+	server, service := setupTestServer(domain.WithPostSignHook(func(deviceID string, resp *model.SignDataResponse) error {
+		seen = resp
+		panic("notary unreachable")
+	}))
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-post-hook", Algorithm: "ECC"})
+
+	body, _ := json.Marshal(model.SignDataRequest{Data: "invoice"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-post-hook/sign", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Data model.SignDataResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if seen == nil || seen.Signature != response.Data.Signature || response.Data.Signature == "" {
+		t.Errorf("expected the hook to see the returned signature, got %+v", seen)
+	}
+}
+
+func TestSignDataExpiresIn(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	server, service := setupTestServer(domain.WithClock(clock))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-expiry", Algorithm: "ECC"})
+	initial := device.LastSignature
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-expiry/sign", strings.NewReader(`{"data":"ticket","expires_in":60}`))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var signed struct {
+		Data model.SignDataResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&signed)
+	if expected := clock.Now().Add(time.Minute); signed.Data.ExpiresAt == nil || !signed.Data.ExpiresAt.Equal(expected) {
+		t.Fatalf("expected expires_at %v, got %v", expected, signed.Data.ExpiresAt)
+	}
+
+	verify := func() model.VerifyResult {
+		body, _ := json.Marshal([]model.VerifySignatureRequest{{
+			Data: "ticket", Signature: signed.Data.Signature, Counter: 0, LastSignature: initial, ExpiresAt: signed.Data.ExpiresAt,
+		}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-expiry/verify/batch", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		var response struct {
+			Data []model.VerifyResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if len(response.Data) != 1 {
+			t.Fatalf("expected 1 result, got %s", w.Body.String())
+		}
+		return response.Data[0]
+	}
+
+	if result := verify(); !result.Valid {
+		t.Errorf("expected a valid signature before expiry, got %+v", result)
+	}
+	clock.Advance(2 * time.Minute)
+	if result := verify(); result.Valid || result.Reason != model.VerifyReasonExpired {
+		t.Errorf("expected an expired signature, got %+v", result)
+	}
+
+	for _, body := range []string{`{"data":"ticket","expires_in":-1}`, `{"data":"ticket","expires_in":9223372036854775807}`} {
+		req = httptest.NewRequest(http.MethodPost, "/api/v0/devices/device-expiry/sign", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestVerifyAt(t *testing.T) {
+	server, service := setupTestServer()
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-verify-at", Algorithm: "ECC"})
+	first, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+	service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "second"})
+
+	verifyAt := func(path string, req model.VerifyAtRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(body)))
+		return w
+	}
+
+	t.Run("verifies a historical signature by counter", func(t *testing.T) {
+		w := verifyAt("/api/v0/devices/device-verify-at/verify-at", model.VerifyAtRequest{Counter: 0, Data: "first", Signature: first.Signature})
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data model.VerifyResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if !response.Data.Valid {
+			t.Errorf("expected a valid signature, got %+v", response.Data)
+		}
+	})
+
+	t.Run("tampered data is invalid", func(t *testing.T) {
+		w := verifyAt("/api/v0/devices/device-verify-at/verify-at", model.VerifyAtRequest{Counter: 0, Data: "forged", Signature: first.Signature})
+		var response struct {
+			Data model.VerifyResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if w.Code != http.StatusOK || response.Data.Valid {
+			t.Errorf("expected an invalid signature, got %d %+v", w.Code, response.Data)
+		}
+	})
+
+	t.Run("counter not in history", func(t *testing.T) {
+		w := verifyAt("/api/v0/devices/device-verify-at/verify-at", model.VerifyAtRequest{Counter: 7, Data: "first", Signature: first.Signature})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		w := verifyAt("/api/v0/devices/missing/verify-at", model.VerifyAtRequest{Data: "first", Signature: first.Signature})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
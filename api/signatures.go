@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/gorilla/mux"
+)
+
+// ListSignatures handles GET /api/v0/devices/{id}/signatures to return the device's signature
+// history in counter order. With ?purpose=..., only records labeled with that purpose are
+// returned. Returns 404 if the device does not exist.
+func (s *Server) ListSignatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	history, err := s.signDeviceService.SignatureHistory(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to list signatures",
+			})
+		}
+		return
+	}
+
+	if purpose := r.URL.Query().Get("purpose"); purpose != "" {
+		history = domain.FilterByPurpose(history, purpose)
+	}
+	if history == nil {
+		history = []model.SignatureRecord{}
+	}
+	s.writeResponse(w, http.StatusOK, history)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// DeviceSize handles GET /api/v0/devices/{id}/size to estimate the device's storage footprint
+// for capacity planning. Returns 404 if the device does not exist.
+func (s *Server) DeviceSize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	size, err := s.signDeviceService.DeviceSize(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to estimate device size",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, size)
+}
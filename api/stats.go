@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// DefaultTopDevices is the number of devices ranked when the request omits "top".
+const DefaultTopDevices = 5
+
+// SignatureStats handles GET /api/v0/stats/signatures to report the total number of
+// signatures produced since startup and the top-N devices by signature count.
+// The optional "top" query parameter controls N.
+func (s *Server) SignatureStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	topN := DefaultTopDevices
+	if raw := r.URL.Query().Get("top"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{
+				"Query parameter 'top' must be a non-negative integer",
+			})
+			return
+		}
+		topN = parsed
+	}
+
+	stats, err := s.signDeviceService.SignatureStats(topN)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{
+			"Failed to get signature stats",
+		})
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, stats)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// DeviceKeyStrength handles GET /api/v0/devices/{id}/strength to assess the device's key for
+// audits: algorithm, key size or curve, estimated security bits and an "ok"/"weak" rating.
+// Returns 404 if the device does not exist.
+func (s *Server) DeviceKeyStrength(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	strength, err := s.signDeviceService.DeviceKeyStrength(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to assess key strength",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, strength)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/gorilla/mux"
+)
+
+// TenantHeader names the tenant a request acts for. Requests without it are unscoped and see
+// every device, so deployments relying on tenant isolation must set it at a trusted proxy.
+const TenantHeader = "X-Tenant-ID"
+
+// operatorRoutes span every tenant's devices or change global settings, so tenant-scoped
+// requests are refused rather than answered with other tenants' data.
+var operatorRoutes = map[string]bool{
+	"/api/v0/events":                true,
+	"/api/v0/events/stream":         true,
+	"/api/v0/stats/signatures":      true,
+	"/api/v0/metrics":               true,
+	"/api/v0/admin/flags":           true,
+	"/api/v0/admin/devices/at-risk": true,
+}
+
+// requestTenant returns the tenant named by the request, or "" if it is unscoped.
+func requestTenant(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(TenantHeader))
+}
+
+// createTenant resolves the tenant a device created by the request belongs to: the request's
+// tenant, or the body's tenant_id on unscoped requests. A body naming another tenant than the
+// request fails.
+func createTenant(r *http.Request, bodyTenant string) (string, error) {
+	tenant := requestTenant(r)
+	if tenant == "" {
+		return bodyTenant, nil
+	}
+	if bodyTenant != "" && bodyTenant != tenant {
+		return "", errors.New("tenant_id does not match the " + TenantHeader + " header")
+	}
+	return tenant, nil
+}
+
+// tenantMiddleware scopes routes addressing a device by {id} to the request's tenant. Devices
+// of other tenants are answered with 404 like unknown devices, not 403, so their IDs cannot be
+// enumerated. Operator routes answer tenant-scoped requests with 403. Unscoped requests pass
+// through unchanged.
+func (s *Server) tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, id := requestTenant(r), mux.Vars(r)["id"]
+		if tenant != "" && operatorRoutes[routeTemplate(r)] {
+			WriteErrorResponse(w, http.StatusForbidden, []string{
+				"Not available to tenant-scoped requests",
+			})
+			return
+		}
+		if tenant != "" && id != "" {
+			if _, err := s.signDeviceService.GetTenantDevice(tenant, id); errors.Is(err, domain.ErrDeviceNotFound) {
+				WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
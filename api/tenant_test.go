@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestTenantScoping(t *testing.T) {
+	server, _ := setupTestServer()
+	handler := server.Handler()
+
+	do := func(method, path, tenant, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if tenant != "" {
+			req.Header.Set(TenantHeader, tenant)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct{ tenant, id string }{{"tenant-a", "device-tenant-a"}, {"tenant-b", "device-tenant-b"}} {
+		w := do(http.MethodPost, "/api/v0/devices", tc.tenant, `{"id":"`+tc.id+`","algorithm":"ECC"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Data.TenantID != tc.tenant {
+			t.Fatalf("expected tenant %s, got %q", tc.tenant, response.Data.TenantID)
+		}
+	}
+
+	t.Run("taken IDs look the same whichever tenant holds them", func(t *testing.T) {
+		own := do(http.MethodPost, "/api/v0/devices", "tenant-a", `{"id":"device-tenant-a","algorithm":"ECC"}`)
+		foreign := do(http.MethodPost, "/api/v0/devices", "tenant-a", `{"id":"device-tenant-b","algorithm":"ECC"}`)
+		if own.Code != http.StatusConflict || foreign.Code != http.StatusConflict {
+			t.Fatalf("expected status %d for both, got %d and %d", http.StatusConflict, own.Code, foreign.Code)
+		}
+		if own.Body.String() != foreign.Body.String() || strings.Contains(foreign.Body.String(), "device-tenant-b") {
+			t.Errorf("expected identical responses without the ID, got %q and %q", own.Body.String(), foreign.Body.String())
+		}
+	})
+
+	t.Run("tenant cannot retrieve another tenant's device", func(t *testing.T) {
+		if w := do(http.MethodGet, "/api/v0/devices/device-tenant-b", "tenant-a", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := do(http.MethodGet, "/api/v0/devices/device-tenant-a", "tenant-a", ""); w.Code != http.StatusOK {
+			t.Errorf("expected status %d for own device, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("tenant cannot sign with another tenant's device", func(t *testing.T) {
+		if w := do(http.MethodPost, "/api/v0/devices/device-tenant-b/sign", "tenant-a", `{"data":"payload"}`); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		var head struct {
+			Data model.ChainHeadResponse `json:"data"`
+		}
+		json.NewDecoder(do(http.MethodGet, "/api/v0/devices/device-tenant-b/counter", "tenant-b", "").Body).Decode(&head)
+		if head.Data.Counter != 0 {
+			t.Errorf("expected tenant-b's counter to stay 0, got %d", head.Data.Counter)
+		}
+		if w := do(http.MethodPost, "/api/v0/devices/device-tenant-a/sign", "tenant-a", `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Errorf("expected status %d for own device, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("cross-tenant and unknown devices look alike", func(t *testing.T) {
+		foreign := do(http.MethodGet, "/api/v0/devices/device-tenant-b/counter", "tenant-a", "")
+		missing := do(http.MethodGet, "/api/v0/devices/device-missing/counter", "tenant-a", "")
+		if foreign.Code != missing.Code || foreign.Body.String() != missing.Body.String() {
+			t.Errorf("expected identical responses, got %d %s and %d %s", foreign.Code, foreign.Body, missing.Code, missing.Body)
+		}
+	})
+
+	t.Run("listings only show the tenant's devices", func(t *testing.T) {
+		var devices struct {
+			Data []model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(do(http.MethodGet, "/api/v0/devices", "tenant-a", "").Body).Decode(&devices)
+		if len(devices.Data) != 1 || devices.Data[0].ID != "device-tenant-a" {
+			t.Errorf("expected only device-tenant-a, got %+v", devices.Data)
+		}
+
+		var batch struct {
+			Data map[string]*model.DeviceResponse `json:"data"`
+		}
+		body := `{"ids":["device-tenant-a","device-tenant-b"]}`
+		json.NewDecoder(do(http.MethodPost, "/api/v0/devices/batch-get", "tenant-a", body).Body).Decode(&batch)
+		if batch.Data["device-tenant-a"] == nil || batch.Data["device-tenant-b"] != nil {
+			t.Errorf("expected only device-tenant-a resolved, got %+v", batch.Data)
+		}
+
+		var chains struct {
+			Data model.ChainTipsPage `json:"data"`
+		}
+		json.NewDecoder(do(http.MethodGet, "/api/v0/chains", "tenant-b", "").Body).Decode(&chains)
+		if len(chains.Data.Chains) != 1 || chains.Data.Chains[0].ID != "device-tenant-b" {
+			t.Errorf("expected only device-tenant-b, got %+v", chains.Data.Chains)
+		}
+
+		if w := do(http.MethodGet, "/api/v0/devices/compare?a=device-tenant-a&b=device-tenant-b", "tenant-a", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d comparing with a foreign device, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("unscoped requests see every device", func(t *testing.T) {
+		var devices struct {
+			Data []model.DeviceResponse `json:"data"`
+		}
+		json.NewDecoder(do(http.MethodGet, "/api/v0/devices", "", "").Body).Decode(&devices)
+		if len(devices.Data) != 2 {
+			t.Errorf("expected 2 devices, got %d", len(devices.Data))
+		}
+	})
+
+	t.Run("body tenant conflicting with the header is rejected", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/v0/devices", "tenant-a", `{"id":"device-tenant-c","algorithm":"ECC","tenant_id":"tenant-b"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("tenant cannot reach operator endpoints", func(t *testing.T) {
+		for _, route := range []struct{ method, path, body string }{
+			{http.MethodGet, "/api/v0/stats/signatures", ""},
+			{http.MethodGet, "/api/v0/metrics", ""},
+			{http.MethodGet, "/api/v0/events", ""},
+			{http.MethodGet, "/api/v0/events/stream", ""},
+			{http.MethodGet, "/api/v0/admin/devices/at-risk", ""},
+			{http.MethodGet, "/api/v0/admin/flags", ""},
+			{http.MethodPost, "/api/v0/admin/flags", `{"signing_disabled":true}`},
+		} {
+			w := do(route.method, route.path, "tenant-a", route.body)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s %s: expected status %d, got %d", route.method, route.path, http.StatusForbidden, w.Code)
+			}
+			if strings.Contains(w.Body.String(), "device-tenant-b") {
+				t.Errorf("%s %s: leaked another tenant's device: %s", route.method, route.path, w.Body.String())
+			}
+		}
+		if w := do(http.MethodGet, "/api/v0/stats/signatures", "", ""); w.Code != http.StatusOK {
+			t.Errorf("expected unscoped stats to stay available, got %d", w.Code)
+		}
+		if w := do(http.MethodPost, "/api/v0/devices/device-tenant-b/sign", "tenant-b", `{"data":"payload"}`); w.Code != http.StatusOK {
+			t.Errorf("expected signing to stay enabled, got %d", w.Code)
+		}
+	})
+}
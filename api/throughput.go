@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/gorilla/mux"
+)
+
+// DefaultThroughputWindow is used when the window query parameter is omitted.
+const DefaultThroughputWindow = time.Minute
+
+// SignatureThroughput handles GET /api/v0/devices/{id}/throughput?window=60s to count the
+// device's signatures in the trailing window. Supported windows are 1m, 5m, 15m, 1h and 24h.
+func (s *Server) SignatureThroughput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	window := DefaultThroughputWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{
+				"Invalid window parameter",
+			})
+			return
+		}
+		window = parsed
+	}
+
+	count, err := s.signDeviceService.SignatureThroughput(mux.Vars(r)["id"], window)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeviceNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		case errors.Is(err, domain.ErrUnsupportedWindow):
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to compute throughput",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, model.ThroughputResponse{
+		Window:     window.String(),
+		Signatures: count,
+	})
+}
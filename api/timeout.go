@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// streamingRoutes are never given a timeout, as their responses are open-ended by design or
+// flushed to the client as they are produced.
+var streamingRoutes = map[string]bool{
+	"/api/v0/events":                      true,
+	"/api/v0/events/stream":               true,
+	"/api/v0/devices/{id}/signatures.csv": true,
+}
+
+// timeoutWriter buffers a handler's response so it can be discarded once the handler runs out
+// of time. Writes after the timeout fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 && !w.timedOut {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// routeTemplate returns the path template of the request's matched route, or its path when no
+// route matched.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// routeTimeout returns the time allowed for the request's route: its entry in the per-route
+// timeouts, keyed by path template, else the global timeout. Zero means unbounded.
+func (s *Server) routeTimeout(r *http.Request) time.Duration {
+	template := routeTemplate(r)
+	if streamingRoutes[template] {
+		return 0
+	}
+	if timeout, ok := s.routeTimeouts[template]; ok {
+		return timeout
+	}
+	return s.handlerTimeout
+}
+
+// timeoutMiddleware bounds the total duration of each handler, like http.TimeoutHandler, but
+// answers an overrun with 503 in the JSON error envelope. The request context is cancelled at
+// the deadline, so context-aware work such as signing is abandoned rather than committed late.
+// Signing checks the context under the signing lock just before committing; a signature whose
+// commit was already under way when the deadline passed is kept although the client gets 503,
+// and a retry carrying the same operation_id returns it. A panic in the handler is re-raised on
+// the serving goroutine for recoveryMiddleware, or logged if the request had already timed out.
+func (s *Server) timeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := s.routeTimeout(r)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					tw.mu.Lock()
+					timedOut := tw.timedOut
+					tw.mu.Unlock()
+					if timedOut {
+						logLatePanic(r, rec, debug.Stack())
+						return
+					}
+					panicked <- rec
+				}
+			}()
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case rec := <-panicked:
+			panic(rec)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for name, values := range tw.header {
+				w.Header()[name] = values
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			// A panic racing the deadline is no longer re-raised; make sure it is not lost.
+			select {
+			case rec := <-panicked:
+				logLatePanic(r, rec, nil)
+			default:
+			}
+			WriteErrorResponse(w, http.StatusServiceUnavailable, []string{
+				"Request timed out",
+			})
+		}
+	})
+}
+
+// logLatePanic logs a handler panic that happened after its request was answered with a timeout.
+func logLatePanic(r *http.Request, rec interface{}, stack []byte) {
+	log.Printf("panic serving %s %s after timeout: %v\n%s", r.Method, r.URL.Path, rec, stack)
+}
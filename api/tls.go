@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+)
+
+// contextKey keys the values the server attaches to request contexts.
+type contextKey int
+
+const clientCertSubjectKey contextKey = iota
+
+// tlsConfig returns the TLS configuration for serving, or nil when TLS is not configured.
+// With client CAs configured, clients must present a certificate issued by one of them.
+func (s *Server) tlsConfig() *tls.Config {
+	if s.tlsCertificate == nil {
+		return nil
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{*s.tlsCertificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if s.clientCAs != nil {
+		config.ClientCAs = s.clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config
+}
+
+// clientCertMiddleware attaches the subject of the verified client certificate, if any, to
+// the request context so handlers can record who made a request.
+func clientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			subject := r.TLS.VerifiedChains[0][0].Subject.String()
+			r = r.WithContext(context.WithValue(r.Context(), clientCertSubjectKey, subject))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientCertSubject returns the subject of the request's verified client certificate, e.g.
+// "CN=billing,O=Example", and false when the request carried none.
+func ClientCertSubject(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(clientCertSubjectKey).(string)
+	return subject, ok
+}
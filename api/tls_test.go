@@ -0,0 +1,154 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/persistence"
+)
+
+// testCA is a throwaway certificate authority issuing test certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// issue returns a certificate for commonName, usable by servers on 127.0.0.1 and by clients.
+func (ca *testCA) issue(t *testing.T, commonName string) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Example"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	serverCA := newTestCA(t, "Server CA")
+	clientCA := newTestCA(t, "Client CA")
+	untrustedCA := newTestCA(t, "Untrusted CA")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	service := domain.NewSignatureDeviceService(persistence.NewInMemoryStorage())
+	server := NewServer(":8080", service, WithTLS(serverCA.issue(t, "signing-service")), WithClientCAs(clientCA.pool()))
+	go server.Serve(listener)
+	defer listener.Close()
+	url := "https://" + listener.Addr().String() + "/api/v0/health"
+
+	get := func(certificates ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{
+			Timeout: 2 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      serverCA.pool(),
+				Certificates: certificates,
+			}},
+		}
+		return client.Get(url)
+	}
+
+	t.Run("valid client certificate", func(t *testing.T) {
+		resp, err := get(clientCA.issue(t, "billing"))
+		if err != nil {
+			t.Fatalf("expected request to succeed, got %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		if resp, err := get(); err == nil {
+			resp.Body.Close()
+			t.Error("expected request without a client certificate to be rejected")
+		}
+	})
+
+	t.Run("client certificate from an untrusted CA", func(t *testing.T) {
+		if resp, err := get(untrustedCA.issue(t, "intruder")); err == nil {
+			resp.Body.Close()
+			t.Error("expected request with an untrusted certificate to be rejected")
+		}
+	})
+}
+
+func TestClientCertSubject(t *testing.T) {
+	ca := newTestCA(t, "Client CA")
+	certificate := ca.issue(t, "billing")
+	leaf, _ := x509.ParseCertificate(certificate.Certificate[0])
+
+	var subject string
+	var found bool
+	handler := clientCertMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, found = ClientCertSubject(r.Context())
+	}))
+
+	t.Run("verified certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/health", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, ca.cert}}}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !found || subject != "CN=billing,O=Example" {
+			t.Errorf("expected subject CN=billing,O=Example, got %q (found %v)", subject, found)
+		}
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/health", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if found {
+			t.Errorf("expected no subject, got %q", subject)
+		}
+	})
+}
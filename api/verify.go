@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
+	"github.com/gorilla/mux"
+)
+
+// MaxBatchVerifyEntries caps the number of signatures accepted by a single batch verify request.
+const MaxBatchVerifyEntries = 1000
+
+// VerifyBatch handles POST /api/v0/devices/{id}/verify/batch to verify many signatures at once.
+// Accepts an array of {data, signature, counter, last_signature} and returns a parallel array
+// of {valid, error, reason} results. Returns 404 if the device does not exist.
+func (s *Server) VerifyBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req []model.VerifySignatureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+	if len(req) > MaxBatchVerifyEntries {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			fmt.Sprintf("Batch exceeds maximum of %d entries", MaxBatchVerifyEntries),
+		})
+		return
+	}
+
+	opts := model.BatchVerifyOptions{
+		DeviceID: mux.Vars(r)["id"],
+		Entries:  make([]model.VerifySignatureOptions, len(req)),
+	}
+	for i := range req {
+		opts.Entries[i] = req[i].ToOptions()
+	}
+
+	results, err := s.signDeviceService.VerifySignatures(opts)
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to verify signatures",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, results)
+}
+
+// VerifyAt handles POST /api/v0/devices/{id}/verify-at to verify a signature given {counter,
+// data, signature}; the last_signature, nonce, AAD and expiry bound at that counter are taken
+// from the device's history. Returns {valid, error, reason}, or 404 if the device does not
+// exist or its history holds no record for the counter.
+func (s *Server) VerifyAt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	var req model.VerifyAtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{
+			"Invalid request body",
+		})
+		return
+	}
+
+	opts := req.ToOptions()
+	opts.DeviceID = mux.Vars(r)["id"]
+	result, err := s.signDeviceService.VerifyAt(opts)
+	if err != nil {
+		if errors.Is(err, domain.ErrDeviceNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Device not found"})
+		} else if errors.Is(err, domain.ErrCounterNotInHistory) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{"Counter not found in history"})
+		} else {
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{
+				"Failed to verify signature",
+			})
+		}
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, result)
+}
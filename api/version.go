@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// Build metadata, injected at build time via
+// -ldflags "-X github.com/bayuhutajulu/signing-service/api.Version=...".
+var (
+	Version   = "dev"
+	Commit    = "dev"
+	BuildTime = "dev"
+)
+
+type VersionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// VersionInfo handles GET /api/v0/version to report which build is deployed.
+func (s *Server) VersionInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, []string{
+			http.StatusText(http.StatusMethodNotAllowed),
+		})
+		return
+	}
+
+	s.writeResponse(w, http.StatusOK, VersionResponse{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	})
+}
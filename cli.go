@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+// Exit codes of the verify subcommand.
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitUsage   = 2
+)
+
+// runVerify implements `signing-service verify`: it checks a base64 signature over the given
+// data (the device's signed_data) against a PEM public key and prints "valid" or "invalid".
+// Data is read from stdin when -data is omitted. It returns the process exit code.
+func runVerify(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	publicKeyPath := flags.String("pubkey", "", "path to the PEM encoded public key")
+	data := flags.String("data", "", "signed data; read from stdin when omitted")
+	signatureB64 := flags.String("signature", "", "base64 encoded signature (standard or URL-safe, padding optional)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *publicKeyPath == "" || *signatureB64 == "" {
+		fmt.Fprintln(stderr, "verify: -pubkey and -signature are required")
+		flags.Usage()
+		return exitUsage
+	}
+
+	pemData, err := os.ReadFile(*publicKeyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: failed to read public key: %v\n", err)
+		return exitUsage
+	}
+	publicKey, err := signingcrypto.ParsePublicKeyPEM(pemData)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: failed to parse public key: %v\n", err)
+		return exitUsage
+	}
+	verifier, err := signingcrypto.NewVerifier(publicKey)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: %v\n", err)
+		return exitUsage
+	}
+
+	signedData := []byte(*data)
+	if !isFlagSet(flags, "data") {
+		signedData, err = io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "verify: failed to read data from stdin: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	signature, err := signingcrypto.DecodeSignature(*signatureB64)
+	if err != nil {
+		fmt.Fprintln(stdout, "invalid")
+		return exitInvalid
+	}
+	if err := verifier.Verify(signedData, signature); err != nil {
+		fmt.Fprintln(stdout, "invalid")
+		return exitInvalid
+	}
+
+	fmt.Fprintln(stdout, "valid")
+	return exitValid
+}
+
+// isFlagSet reports whether the named flag was passed explicitly.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+func TestRunVerify(t *testing.T) {
+	keyPair, err := (&signingcrypto.ECCGenerator{}).Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(keyPair.Public)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+
+	data := "0_payload_ZGV2aWNlLTAwMQ=="
+	signature, _ := signingcrypto.NewECDSASigner(keyPair.Private).Sign([]byte(data))
+	signatureB64 := base64.StdEncoding.EncodeToString(signature)
+
+	t.Run("known-good signature", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runVerify([]string{"-pubkey", keyPath, "-data", data, "-signature", signatureB64}, nil, &stdout, &stderr)
+
+		if code != exitValid {
+			t.Errorf("expected exit code %d, got %d (%s)", exitValid, code, stderr.String())
+		}
+		if strings.TrimSpace(stdout.String()) != "valid" {
+			t.Errorf("expected output valid, got %q", stdout.String())
+		}
+	})
+
+	t.Run("data from stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runVerify([]string{"-pubkey", keyPath, "-signature", signatureB64}, strings.NewReader(data), &stdout, &stderr)
+
+		if code != exitValid {
+			t.Errorf("expected exit code %d, got %d (%s)", exitValid, code, stderr.String())
+		}
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		tampered := append([]byte(nil), signature...)
+		tampered[len(tampered)-1] ^= 0xff
+
+		var stdout, stderr bytes.Buffer
+		code := runVerify([]string{"-pubkey", keyPath, "-data", data, "-signature", base64.StdEncoding.EncodeToString(tampered)}, nil, &stdout, &stderr)
+
+		if code != exitInvalid {
+			t.Errorf("expected exit code %d, got %d", exitInvalid, code)
+		}
+		if strings.TrimSpace(stdout.String()) != "invalid" {
+			t.Errorf("expected output invalid, got %q", stdout.String())
+		}
+	})
+
+	t.Run("missing flags", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := runVerify([]string{"-data", data}, nil, &stdout, &stderr); code != exitUsage {
+			t.Errorf("expected exit code %d, got %d", exitUsage, code)
+		}
+	})
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/api"
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	"github.com/bayuhutajulu/signing-service/domain"
+)
+
+// Environment variables read at startup to standardize key generation across a deployment.
+const (
+	EnvDefaultAlgorithm   = "SIGNING_DEFAULT_ALGORITHM"
+	EnvRSAKeySize         = "SIGNING_RSA_KEY_SIZE"
+	EnvECCCurve           = "SIGNING_ECC_CURVE"
+	EnvVerifyCacheSize    = "SIGNING_VERIFY_CACHE_SIZE"
+	EnvVerifyCacheTTL     = "SIGNING_VERIFY_CACHE_TTL"
+	EnvMaxConcurrentSigns = "SIGNING_MAX_CONCURRENT_SIGNS"
+	EnvSignSlotWait       = "SIGNING_SIGN_SLOT_WAIT"
+	EnvRateLimit          = "SIGNING_RATE_LIMIT"
+	EnvRateBurst          = "SIGNING_RATE_BURST"
+	EnvDeviceIDPattern    = "SIGNING_DEVICE_ID_PATTERN"
+	EnvPrintableOnly      = "SIGNING_PRINTABLE_ONLY"
+	EnvReadOnly           = "SIGNING_READ_ONLY"
+	EnvMaxSignDataLength  = "SIGNING_MAX_DATA_LENGTH"
+	EnvRejectEmptyData    = "SIGNING_REJECT_EMPTY_DATA"
+	EnvAPIKeys            = "SIGNING_API_KEYS"
+	EnvAuthBypass         = "SIGNING_AUTH_BYPASS"
+	EnvResponseHeaders    = "SIGNING_RESPONSE_HEADERS"
+	EnvUnwrapResponses    = "SIGNING_UNWRAP_RESPONSES"
+	EnvKeyGenWorkers      = "SIGNING_KEYGEN_WORKERS"
+	EnvKeyGenQueue        = "SIGNING_KEYGEN_QUEUE"
+	EnvMaxHistoryEntries  = "SIGNING_MAX_HISTORY_ENTRIES"
+	EnvDeviceIDStrategy   = "SIGNING_DEVICE_ID_STRATEGY"
+	EnvMaxConnections     = "SIGNING_MAX_CONNECTIONS"
+	EnvTLSCertFile        = "SIGNING_TLS_CERT_FILE"
+	EnvTLSKeyFile         = "SIGNING_TLS_KEY_FILE"
+	EnvTLSClientCAFile    = "SIGNING_TLS_CLIENT_CA_FILE"
+	EnvMetricsDeviceLimit = "SIGNING_METRICS_DEVICE_LIMIT"
+	EnvLabelTemplate      = "SIGNING_DEFAULT_LABEL_TEMPLATE"
+	EnvReconcileInterval  = "SIGNING_RECONCILE_INTERVAL"
+	EnvResponseKeyFile    = "SIGNING_RESPONSE_KEY_FILE"
+	EnvResponseKeyID      = "SIGNING_RESPONSE_KEY_ID"
+	EnvMaxListDevices     = "SIGNING_MAX_LIST_DEVICES"
+	EnvHandlerTimeout     = "SIGNING_HANDLER_TIMEOUT"
+	EnvRouteTimeouts      = "SIGNING_ROUTE_TIMEOUTS"
+	EnvStorageCapacity    = "SIGNING_STORAGE_CAPACITY"
+	EnvJWKSHosts          = "SIGNING_JWKS_HOSTS"
+)
+
+// DefaultResponseKeyID names the response signing key in X-Response-Signature headers unless
+// configured.
+const DefaultResponseKeyID = "server"
+
+// DefaultSignSlotWait is how long a sign request waits for a free slot before failing with 503.
+const DefaultSignSlotWait = 100 * time.Millisecond
+
+// DefaultKeyGenQueue is how many create requests may wait for a key generation worker.
+const DefaultKeyGenQueue = 64
+
+// loadKeyGenerationDefaults reads and validates the key generation defaults from the environment.
+func loadKeyGenerationDefaults() (domain.KeyGenerationDefaults, error) {
+	defaults := domain.KeyGenerationDefaults{
+		Algorithm: os.Getenv(EnvDefaultAlgorithm),
+		ECCCurve:  os.Getenv(EnvECCCurve),
+	}
+
+	if raw := os.Getenv(EnvRSAKeySize); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil {
+			return defaults, fmt.Errorf("%s must be an integer: %w", EnvRSAKeySize, err)
+		}
+		defaults.RSAKeySize = size
+	}
+
+	if err := defaults.Validate(); err != nil {
+		return defaults, err
+	}
+	return defaults, nil
+}
+
+// loadVerifyCacheOption reads the verification cache size and TTL from the environment.
+// The cache stays disabled unless a positive size is configured.
+func loadVerifyCacheOption() (domain.ServiceOption, error) {
+	size := 0
+	if raw := os.Getenv(EnvVerifyCacheSize); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvVerifyCacheSize)
+		}
+		size = parsed
+	}
+
+	var ttl time.Duration
+	if raw := os.Getenv(EnvVerifyCacheTTL); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative duration", EnvVerifyCacheTTL)
+		}
+		ttl = parsed
+	}
+
+	return domain.WithVerifyCache(size, ttl), nil
+}
+
+// loadSignLimitOption reads the global signing concurrency limit from the environment.
+// Signing stays unbounded unless a positive limit is configured.
+func loadSignLimitOption() (domain.ServiceOption, error) {
+	limit := 0
+	if raw := os.Getenv(EnvMaxConcurrentSigns); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvMaxConcurrentSigns)
+		}
+		limit = parsed
+	}
+
+	wait := DefaultSignSlotWait
+	if raw := os.Getenv(EnvSignSlotWait); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative duration", EnvSignSlotWait)
+		}
+		wait = parsed
+	}
+
+	return domain.WithMaxConcurrentSigns(limit, wait), nil
+}
+
+// loadKeyGenPoolOption reads the key generation worker count and queue size from the environment.
+// Key generation stays on the request goroutine unless a positive worker count is configured.
+func loadKeyGenPoolOption() (domain.ServiceOption, error) {
+	workers := 0
+	if raw := os.Getenv(EnvKeyGenWorkers); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvKeyGenWorkers)
+		}
+		workers = parsed
+	}
+
+	queue := DefaultKeyGenQueue
+	if raw := os.Getenv(EnvKeyGenQueue); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvKeyGenQueue)
+		}
+		queue = parsed
+	}
+
+	return domain.WithKeyGenerationPool(workers, queue), nil
+}
+
+// loadMaxConnectionsOption reads the cap on simultaneously open connections from the
+// environment. Connections stay unlimited unless a positive cap is configured.
+func loadMaxConnectionsOption() (api.ServerOption, error) {
+	limit := 0
+	if raw := os.Getenv(EnvMaxConnections); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvMaxConnections)
+		}
+		limit = parsed
+	}
+	return api.WithMaxConnections(limit), nil
+}
+
+// loadTLSOptions reads the server certificate and key files and, for mutual TLS, the client
+// CA bundle from the environment, all PEM encoded. The server speaks plain HTTP unless a
+// certificate is configured; a client CA requires a certificate.
+func loadTLSOptions() ([]api.ServerOption, error) {
+	certFile, keyFile := os.Getenv(EnvTLSCertFile), os.Getenv(EnvTLSKeyFile)
+	caFile := os.Getenv(EnvTLSClientCAFile)
+	if certFile == "" && keyFile == "" {
+		if caFile != "" {
+			return nil, fmt.Errorf("%s requires %s and %s", EnvTLSClientCAFile, EnvTLSCertFile, EnvTLSKeyFile)
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("%s and %s must be set together", EnvTLSCertFile, EnvTLSKeyFile)
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	opts := []api.ServerOption{api.WithTLS(certificate)}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", EnvTLSClientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no PEM certificates", EnvTLSClientCAFile)
+		}
+		opts = append(opts, api.WithClientCAs(pool))
+	}
+	return opts, nil
+}
+
+// loadResponseSignerOption reads the PEM private key that signs response bodies, and the key
+// ID announced with it, from the environment. Responses are unsigned unless a key is configured.
+func loadResponseSignerOption() (api.ServerOption, error) {
+	keyFile := os.Getenv(EnvResponseKeyFile)
+	if keyFile == "" {
+		if os.Getenv(EnvResponseKeyID) != "" {
+			return nil, fmt.Errorf("%s requires %s", EnvResponseKeyID, EnvResponseKeyFile)
+		}
+		return api.WithResponseSigner(nil), nil
+	}
+	keyID := os.Getenv(EnvResponseKeyID)
+	if keyID == "" {
+		keyID = DefaultResponseKeyID
+	}
+
+	pem, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", EnvResponseKeyFile, err)
+	}
+	key, err := signingcrypto.ParsePrivateKeyPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvResponseKeyFile, err)
+	}
+	signer, err := signingcrypto.NewResponseSigner(key, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvResponseKeyFile, err)
+	}
+	return api.WithResponseSigner(signer), nil
+}
+
+// loadMetricsDeviceLimitOption reads the cap on devices exported with per-device metric series
+// from the environment. Unset keeps api.DefaultMetricsDeviceLimit.
+func loadMetricsDeviceLimitOption() (api.ServerOption, error) {
+	limit := api.DefaultMetricsDeviceLimit
+	if raw := os.Getenv(EnvMetricsDeviceLimit); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvMetricsDeviceLimit)
+		}
+		limit = parsed
+	}
+	return api.WithMetricsDeviceLimit(limit), nil
+}
+
+// loadMaxListDevicesOption reads the cap on devices returned by a list request from the
+// environment. Unset keeps api.DefaultMaxListDevices.
+func loadMaxListDevicesOption() (api.ServerOption, error) {
+	limit := api.DefaultMaxListDevices
+	if raw := os.Getenv(EnvMaxListDevices); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvMaxListDevices)
+		}
+		limit = parsed
+	}
+	return api.WithMaxListDevices(limit), nil
+}
+
+// loadTimeoutOptions reads the global handler timeout and the per-route overrides, a JSON
+// object of path templates to durations, from the environment. Unset leaves handlers unbounded.
+func loadTimeoutOptions() ([]api.ServerOption, error) {
+	var timeout time.Duration
+	if raw := os.Getenv(EnvHandlerTimeout); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative duration", EnvHandlerTimeout)
+		}
+		timeout = parsed
+	}
+
+	var routeTimeouts map[string]time.Duration
+	if raw := os.Getenv(EnvRouteTimeouts); raw != "" {
+		var durations map[string]string
+		if err := json.Unmarshal([]byte(raw), &durations); err != nil {
+			return nil, fmt.Errorf("%s must be a JSON object of route templates to durations: %w", EnvRouteTimeouts, err)
+		}
+		routeTimeouts = make(map[string]time.Duration, len(durations))
+		for route, value := range durations {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("%s: timeout of %s must be a non-negative duration", EnvRouteTimeouts, route)
+			}
+			routeTimeouts[route] = parsed
+		}
+	}
+	return []api.ServerOption{api.WithHandlerTimeout(timeout), api.WithRouteTimeouts(routeTimeouts)}, nil
+}
+
+// loadUnwrapResponsesOption reads whether successful responses omit the {"data": ...} envelope.
+func loadUnwrapResponsesOption() (api.ServerOption, error) {
+	unwrap := false
+	if raw := os.Getenv(EnvUnwrapResponses); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean", EnvUnwrapResponses)
+		}
+		unwrap = parsed
+	}
+	return api.WithUnwrappedResponses(unwrap), nil
+}
+
+// loadRateLimitOption reads the global request rate (per second) and burst from the environment.
+// Requests stay unlimited unless a positive rate is configured; the burst defaults to the rate.
+func loadRateLimitOption() (api.ServerOption, error) {
+	var rate float64
+	if raw := os.Getenv(EnvRateLimit); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative number", EnvRateLimit)
+		}
+		rate = parsed
+	}
+
+	burst := int(math.Ceil(rate))
+	if raw := os.Getenv(EnvRateBurst); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return nil, fmt.Errorf("%s must be a positive integer", EnvRateBurst)
+		}
+		burst = parsed
+	}
+
+	return api.WithRateLimit(rate, burst), nil
+}
+
+// loadDeviceIDStrategyOption reads how IDs are generated for devices created without one.
+// Unset keeps empty IDs as given.
+func loadDeviceIDStrategyOption() (domain.ServiceOption, error) {
+	strategy := os.Getenv(EnvDeviceIDStrategy)
+	if err := domain.ValidateIDStrategy(strategy); err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvDeviceIDStrategy, err)
+	}
+	return domain.WithIDStrategy(strategy), nil
+}
+
+// loadLabelTemplateOption reads the template applied to devices created without a label.
+func loadLabelTemplateOption() (domain.ServiceOption, error) {
+	template := os.Getenv(EnvLabelTemplate)
+	if err := domain.ValidateLabelTemplate(template); err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvLabelTemplate, err)
+	}
+	return domain.WithDefaultLabelTemplate(template), nil
+}
+
+// loadDeviceIDPolicyOption reads the device ID policy from the environment. IDs stay unvalidated
+// unless a pattern is configured; the value "default" selects domain.DefaultDeviceIDPattern.
+func loadDeviceIDPolicyOption() (domain.ServiceOption, error) {
+	raw := os.Getenv(EnvDeviceIDPattern)
+	if raw == "" {
+		return domain.WithDeviceIDPolicy(nil), nil
+	}
+	if raw == "default" {
+		raw = domain.DefaultDeviceIDPattern
+	}
+
+	pattern, err := regexp.Compile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a valid regular expression: %w", EnvDeviceIDPattern, err)
+	}
+	return domain.WithDeviceIDPolicy(pattern), nil
+}
+
+// loadPrintableOnlyOption reads whether device IDs and labels must be printable.
+func loadPrintableOnlyOption() (domain.ServiceOption, error) {
+	enabled := false
+	if raw := os.Getenv(EnvPrintableOnly); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean", EnvPrintableOnly)
+		}
+		enabled = parsed
+	}
+	return domain.WithPrintableOnly(enabled), nil
+}
+
+// loadReadOnlyOption reads whether this instance runs as a read-only replica.
+func loadReadOnlyOption() (domain.ServiceOption, error) {
+	readOnly := false
+	if raw := os.Getenv(EnvReadOnly); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean", EnvReadOnly)
+		}
+		readOnly = parsed
+	}
+	return domain.WithReadOnly(readOnly), nil
+}
+
+// loadRejectEmptyDataOption reads whether sign requests with empty data are rejected.
+func loadRejectEmptyDataOption() (domain.ServiceOption, error) {
+	reject := false
+	if raw := os.Getenv(EnvRejectEmptyData); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean", EnvRejectEmptyData)
+		}
+		reject = parsed
+	}
+	return domain.WithRejectEmptyData(reject), nil
+}
+
+// loadMaxSignDataLengthOption reads the maximum sign data length in bytes from the environment.
+// Zero removes the cap; unset keeps domain.DefaultMaxSignDataLength.
+func loadMaxSignDataLengthOption() (domain.ServiceOption, error) {
+	limit := domain.DefaultMaxSignDataLength
+	if raw := os.Getenv(EnvMaxSignDataLength); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvMaxSignDataLength)
+		}
+		limit = parsed
+	}
+	return domain.WithMaxSignDataLength(limit), nil
+}
+
+// loadMaxHistoryEntriesOption reads the service-wide history retention from the environment.
+// Zero or unset keeps every record.
+func loadMaxHistoryEntriesOption() (domain.ServiceOption, error) {
+	limit := 0
+	if raw := os.Getenv(EnvMaxHistoryEntries); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", EnvMaxHistoryEntries)
+		}
+		limit = parsed
+	}
+	return domain.WithMaxHistoryEntries(limit), nil
+}
+
+// loadStorageCapacity reads how many devices the in-memory storage preallocates room for.
+// Zero or unset leaves the storage unsized.
+func loadStorageCapacity() (int, error) {
+	raw := os.Getenv(EnvStorageCapacity)
+	if raw == "" {
+		return 0, nil
+	}
+	capacity, err := strconv.Atoi(raw)
+	if err != nil || capacity < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", EnvStorageCapacity)
+	}
+	return capacity, nil
+}
+
+// loadReconcileInterval reads how often the background reconciliation job runs. Zero or unset
+// disables it.
+func loadReconcileInterval() (time.Duration, error) {
+	raw := os.Getenv(EnvReconcileInterval)
+	if raw == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration", EnvReconcileInterval)
+	}
+	return interval, nil
+}
+
+// loadAuthOptions reads the accepted API keys and the unauthenticated path prefixes from the
+// environment, both comma-separated. Authentication stays disabled unless keys are configured.
+func loadAuthOptions() []api.ServerOption {
+	opts := []api.ServerOption{api.WithAPIKeys(splitList(os.Getenv(EnvAPIKeys)))}
+	if raw, ok := os.LookupEnv(EnvAuthBypass); ok {
+		opts = append(opts, api.WithAuthBypass(splitList(raw)))
+	}
+	return opts
+}
+
+// loadJWKSHostsOption reads the comma-separated hosts remote JWKS may be fetched from.
+func loadJWKSHostsOption() api.ServerOption {
+	return api.WithJWKSHosts(splitList(os.Getenv(EnvJWKSHosts)))
+}
+
+// splitList splits a comma-separated value, dropping blanks and surrounding whitespace.
+func splitList(raw string) []string {
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadResponseHeadersOption reads the headers added to every response from the environment,
+// given as a JSON object such as {"X-Frame-Options": "DENY"}.
+func loadResponseHeadersOption() (api.ServerOption, error) {
+	headers := map[string]string{}
+	if raw := os.Getenv(EnvResponseHeaders); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+			return nil, fmt.Errorf("%s must be a JSON object of header names to values: %w", EnvResponseHeaders, err)
+		}
+	}
+	return api.WithResponseHeaders(headers), nil
+}
@@ -0,0 +1,193 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Object identifiers used by the detached CMS SignedData structure (RFC 5652, RFC 5754, RFC 5758).
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+)
+
+// cmsHashOIDs maps each supported hash to its digest and ECDSA signature algorithm OIDs.
+var cmsHashOIDs = map[crypto.Hash]struct{ digest, ecdsa asn1.ObjectIdentifier }{
+	crypto.SHA256: {oidSHA256, oidECDSAWithSHA256},
+	crypto.SHA384: {oidSHA384, oidECDSAWithSHA384},
+	crypto.SHA512: {oidSHA512, oidECDSAWithSHA512},
+}
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     cmsSignedData `asn1:"explicit,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapsulatedContentInfo
+	Certificates     asn1.RawValue   `asn1:"tag:0"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+// cmsEncapsulatedContentInfo omits eContent, which makes the structure detached.
+type cmsEncapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                cmsIssuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+// DetachedCMS is the parsed form of a structure produced by BuildDetachedCMS.
+type DetachedCMS struct {
+	Certificate *x509.Certificate
+	Signature   []byte
+	Hash        crypto.Hash // Digest algorithm the signature was made over
+}
+
+// NewSelfSignedCertificate issues a self-signed certificate for the key pair so the public key
+// can travel inside a CMS structure. It returns the DER encoded certificate.
+func NewSelfSignedCertificate(privateKey interface{}, commonName string) ([]byte, error) {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	return x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+}
+
+// BuildDetachedCMS wraps a signature produced by RSASigner or ECDSASigner in a detached CMS
+// SignedData structure (RFC 5652) carrying the signer's certificate. No signed attributes are
+// included, so the signature covers the SHA-256 digest of the content itself.
+func BuildDetachedCMS(signature []byte, certificateDER []byte) ([]byte, error) {
+	return BuildDetachedCMSHash(signature, certificateDER, crypto.SHA256)
+}
+
+// BuildDetachedCMSHash is BuildDetachedCMS for a signature over the hash digest of the content.
+func BuildDetachedCMSHash(signature []byte, certificateDER []byte, hash crypto.Hash) ([]byte, error) {
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	oids, ok := cmsHashOIDs[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedHash, hash)
+	}
+
+	var signatureAlgorithm pkix.AlgorithmIdentifier
+	switch certificate.PublicKey.(type) {
+	case *rsa.PublicKey:
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	case *ecdsa.PublicKey:
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oids.ecdsa}
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", certificate.PublicKey)
+	}
+	digestAlgorithm := pkix.AlgorithmIdentifier{Algorithm: oids.digest}
+
+	return asn1.Marshal(cmsContentInfo{
+		ContentType: oidSignedData,
+		Content: cmsSignedData{
+			Version:          1,
+			DigestAlgorithms: []pkix.AlgorithmIdentifier{digestAlgorithm},
+			EncapContentInfo: cmsEncapsulatedContentInfo{EContentType: oidData},
+			Certificates: asn1.RawValue{
+				Class:      asn1.ClassContextSpecific,
+				Tag:        0,
+				IsCompound: true,
+				Bytes:      certificate.Raw,
+			},
+			SignerInfos: []cmsSignerInfo{{
+				Version: 1,
+				SID: cmsIssuerAndSerialNumber{
+					Issuer:       asn1.RawValue{FullBytes: certificate.RawIssuer},
+					SerialNumber: certificate.SerialNumber,
+				},
+				DigestAlgorithm:    digestAlgorithm,
+				SignatureAlgorithm: signatureAlgorithm,
+				Signature:          signature,
+			}},
+		},
+	})
+}
+
+// ParseDetachedCMS extracts the certificate and signature from a structure produced by BuildDetachedCMS.
+func ParseDetachedCMS(der []byte) (*DetachedCMS, error) {
+	var contentInfo cmsContentInfo
+	rest, err := asn1.Unmarshal(der, &contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CMS: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after CMS structure")
+	}
+	if !contentInfo.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unexpected CMS content type %v", contentInfo.ContentType)
+	}
+
+	signedData := contentInfo.Content
+	if len(signedData.SignerInfos) != 1 {
+		return nil, fmt.Errorf("expected exactly one signer, got %d", len(signedData.SignerInfos))
+	}
+	certificate, err := x509.ParseCertificate(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedded certificate: %w", err)
+	}
+
+	signerInfo := signedData.SignerInfos[0]
+	var hash crypto.Hash
+	for candidate, oids := range cmsHashOIDs {
+		if signerInfo.DigestAlgorithm.Algorithm.Equal(oids.digest) {
+			hash = candidate
+		}
+	}
+	if hash == 0 {
+		return nil, fmt.Errorf("%w: digest algorithm %v", ErrUnsupportedHash, signerInfo.DigestAlgorithm.Algorithm)
+	}
+
+	return &DetachedCMS{
+		Certificate: certificate,
+		Signature:   signerInfo.Signature,
+		Hash:        hash,
+	}, nil
+}
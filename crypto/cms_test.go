@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetachedCMS(t *testing.T) {
+	data := []byte("0_transaction-data_ZGV2aWNlLTAwMQ==")
+
+	rsaKeys, err := (&RSAGenerator{}).Generate()
+	if err != nil {
+		t.Fatalf("failed to generate RSA key pair: %v", err)
+	}
+	eccKeys, err := (&ECCGenerator{}).Generate()
+	if err != nil {
+		t.Fatalf("failed to generate ECC key pair: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		privateKey interface{}
+		signer     Signer
+	}{
+		{name: "RSA", privateKey: rsaKeys.Private, signer: NewRSASigner(rsaKeys.Private)},
+		{name: "ECC", privateKey: eccKeys.Private, signer: NewECDSASigner(eccKeys.Private)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" round trip verifies", func(t *testing.T) {
+			signature, err := tt.signer.Sign(data)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			certificate, err := NewSelfSignedCertificate(tt.privateKey, "device-001")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			der, err := BuildDetachedCMS(signature, certificate)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			parsed, err := ParseDetachedCMS(der)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(parsed.Signature, signature) {
+				t.Error("expected embedded signature to match")
+			}
+			if parsed.Certificate.Subject.CommonName != "device-001" {
+				t.Errorf("expected common name device-001, got %s", parsed.Certificate.Subject.CommonName)
+			}
+			verifier, err := NewVerifier(parsed.Certificate.PublicKey)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if err := verifier.Verify(data, parsed.Signature); err != nil {
+				t.Errorf("expected embedded signature to verify, got %v", err)
+			}
+		})
+	}
+
+	t.Run("rejects malformed input", func(t *testing.T) {
+		if _, err := ParseDetachedCMS([]byte("not cms")); err == nil {
+			t.Error("expected error for malformed CMS")
+		}
+	})
+}
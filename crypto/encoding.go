@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// ErrInvalidSignatureEncoding is returned when a signature is not valid base64 in any accepted form.
+var ErrInvalidSignatureEncoding = errors.New("invalid signature encoding: expected standard or URL-safe base64, with or without padding")
+
+// signatureEncodings lists the base64 variants accepted for submitted signatures.
+var signatureEncodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// DecodeSignature decodes a base64 signature in standard or URL-safe form, with or without
+// padding. The service itself always emits standard padded base64; the other forms are
+// accepted because clients and transports commonly re-encode signatures.
+func DecodeSignature(encoded string) ([]byte, error) {
+	for _, encoding := range signatureEncodings {
+		if signature, err := encoding.DecodeString(encoded); err == nil {
+			return signature, nil
+		}
+	}
+	return nil, ErrInvalidSignatureEncoding
+}
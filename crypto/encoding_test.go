@@ -0,0 +1,37 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestDecodeSignature(t *testing.T) {
+	// 0xfb 0xff forces '+' and '/' in standard base64 and padding at this length.
+	signature := []byte{0xfb, 0xff, 0x01, 0x02, 0x03}
+
+	encodings := map[string]*base64.Encoding{
+		"standard":     base64.StdEncoding,
+		"standard raw": base64.RawStdEncoding,
+		"URL-safe":     base64.URLEncoding,
+		"URL-safe raw": base64.RawURLEncoding,
+	}
+	for name, encoding := range encodings {
+		t.Run("decodes "+name, func(t *testing.T) {
+			decoded, err := DecodeSignature(encoding.EncodeToString(signature))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !bytes.Equal(decoded, signature) {
+				t.Errorf("expected %x, got %x", signature, decoded)
+			}
+		})
+	}
+
+	t.Run("rejects non-base64 input", func(t *testing.T) {
+		if _, err := DecodeSignature("not base64!"); !errors.Is(err, ErrInvalidSignatureEncoding) {
+			t.Errorf("expected ErrInvalidSignatureEncoding, got %v", err)
+		}
+	})
+}
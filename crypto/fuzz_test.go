@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"testing"
+)
+
+// FuzzSignVerify asserts that every signature produced by the RSA and ECDSA signers, in
+// randomized and deterministic mode, verifies against the signing key's public key.
+func FuzzSignVerify(f *testing.F) {
+	rsaKeys, err := (&RSAGenerator{}).Generate()
+	if err != nil {
+		f.Fatalf("failed to generate RSA key pair: %v", err)
+	}
+	eccKeys, err := (&ECCGenerator{Curve: elliptic.P256()}).Generate()
+	if err != nil {
+		f.Fatalf("failed to generate ECC key pair: %v", err)
+	}
+
+	signers := []struct {
+		name      string
+		signer    Signer
+		publicKey interface{}
+	}{
+		{"RSA", NewRSASigner(rsaKeys.Private), rsaKeys.Public},
+		{"ECDSA", NewECDSASigner(eccKeys.Private), eccKeys.Public},
+		{"deterministic ECDSA", NewDeterministicECDSASigner(eccKeys.Private), eccKeys.Public},
+	}
+
+	f.Add([]byte(""))
+	f.Add([]byte("0_transaction-data_ZGV2aWNlLTAwMQ=="))
+	f.Add([]byte{0x00, 0xff, 0x80, 0x7f})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, s := range signers {
+			signature, err := s.signer.Sign(data)
+			if err != nil {
+				t.Fatalf("%s: failed to sign %q: %v", s.name, data, err)
+			}
+			if err := VerifySignature(s.publicKey, data, signature); err != nil {
+				t.Errorf("%s: signature over %q does not verify: %v", s.name, data, err)
+			}
+		}
+	})
+}
@@ -5,15 +5,60 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"fmt"
 )
 
+// DefaultRSAKeySize is used when an RSAGenerator does not specify Bits.
+const DefaultRSAKeySize = 512
+
+// DefaultECCCurve is the curve name used when an ECCGenerator does not specify Curve.
+const DefaultECCCurve = "P-384"
+
+// supportedRSAKeySizes lists the RSA modulus sizes accepted by ValidateRSAKeySize.
+var supportedRSAKeySizes = []int{512, 1024, 2048, 3072, 4096}
+
+// ValidateRSAKeySize checks that bits is one of the supported RSA modulus sizes.
+func ValidateRSAKeySize(bits int) error {
+	for _, size := range supportedRSAKeySizes {
+		if bits == size {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported RSA key size %d: must be one of %v", bits, supportedRSAKeySizes)
+}
+
+// supportedCurves lists the curve names accepted by ParseCurve.
+var supportedCurves = []string{"P-256", "P-384", "P-521"}
+
+// ParseCurve maps a NIST curve name ("P-256", "P-384", "P-521") to its elliptic.Curve.
+func ParseCurve(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported ECC curve %q: must be one of P-256, P-384, P-521", name)
+	}
+}
+
 // RSAGenerator generates a RSA key pair.
-type RSAGenerator struct{}
+type RSAGenerator struct {
+	// Bits is the modulus size; zero means DefaultRSAKeySize.
+	Bits int
+}
 
 // Generate generates a new RSAKeyPair.
 func (g *RSAGenerator) Generate() (*RSAKeyPair, error) {
-	// Security has been ignored for the sake of simplicity.
-	key, err := rsa.GenerateKey(rand.Reader, 512)
+	bits := g.Bits
+	if bits == 0 {
+		// Security has been ignored for the sake of simplicity.
+		bits = DefaultRSAKeySize
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
 		return nil, err
 	}
@@ -25,12 +70,20 @@ func (g *RSAGenerator) Generate() (*RSAKeyPair, error) {
 }
 
 // ECCGenerator generates an ECC key pair.
-type ECCGenerator struct{}
+type ECCGenerator struct {
+	// Curve is the elliptic curve to use; nil means P-384.
+	Curve elliptic.Curve
+}
 
 // Generate generates a new ECCKeyPair.
 func (g *ECCGenerator) Generate() (*ECCKeyPair, error) {
-	// Security has been ignored for the sake of simplicity.
-	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	curve := g.Curve
+	if curve == nil {
+		// Security has been ignored for the sake of simplicity.
+		curve = elliptic.P384()
+	}
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
 	if err != nil {
 		return nil, err
 	}
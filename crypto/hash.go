@@ -0,0 +1,108 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for crypto.Hash.New
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512 for crypto.Hash.New
+	"errors"
+	"fmt"
+)
+
+// Hash names accepted for device signatures.
+const (
+	HashSHA256 = "SHA-256"
+	HashSHA384 = "SHA-384"
+	HashSHA512 = "SHA-512"
+)
+
+// DefaultHash is used when no hash policy applies, and by devices created before the hash
+// became configurable.
+const DefaultHash = HashSHA256
+
+// ErrUnsupportedHash is returned when a hash is unknown or not usable with a key.
+var ErrUnsupportedHash = errors.New("unsupported hash")
+
+// pkcs1DigestInfoLen is the length of the DER DigestInfo prefix PKCS#1 v1.5 puts in front of
+// a SHA-2 digest.
+const pkcs1DigestInfoLen = 19
+
+// ParseHash maps a hash name ("SHA-256", "SHA-384", "SHA-512") to its crypto.Hash. The empty
+// name selects DefaultHash.
+func ParseHash(name string) (crypto.Hash, error) {
+	switch name {
+	case "", HashSHA256:
+		return crypto.SHA256, nil
+	case HashSHA384:
+		return crypto.SHA384, nil
+	case HashSHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("%w %q: must be one of SHA-256, SHA-384, SHA-512", ErrUnsupportedHash, name)
+	}
+}
+
+// PolicyHash returns the default hash of the registered algorithm for keys on curve, which is
+// empty for algorithms without curves: the curve's entry in the algorithm's CurveHashes, else
+// its DefaultHash, else the package DefaultHash.
+func PolicyHash(algorithm, curve string) string {
+	info, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return DefaultHash
+	}
+	if hash, ok := info.CurveHashes[curve]; ok {
+		return hash
+	}
+	if info.DefaultHash != "" {
+		return info.DefaultHash
+	}
+	return DefaultHash
+}
+
+// KeyCurve returns the curve name of an ECDSA private or public key, e.g. "P-384", or "" for
+// other keys.
+func KeyCurve(key interface{}) string {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return k.Curve.Params().Name
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().Name
+	default:
+		return ""
+	}
+}
+
+// CheckHash reports whether hash is one of the registered algorithm's hashes and usable with
+// publicKey. RSA keys must be large enough to hold the PKCS#1 v1.5 encoded digest.
+func CheckHash(algorithm, hash string, publicKey interface{}) error {
+	h, err := ParseHash(hash)
+	if err != nil {
+		return err
+	}
+	info, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return fmt.Errorf("%w: unknown algorithm %q", ErrUnsupportedHash, algorithm)
+	}
+	supported := false
+	for _, name := range info.Hashes {
+		supported = supported || name == hash
+	}
+	if !supported {
+		return fmt.Errorf("%w %q for %s: must be one of %v", ErrUnsupportedHash, hash, algorithm, info.Hashes)
+	}
+	if key, ok := publicKey.(*rsa.PublicKey); ok && key.Size() < pkcs1DigestInfoLen+h.Size()+11 {
+		return fmt.Errorf("%w %q: %d-bit RSA key is too small", ErrUnsupportedHash, hash, key.N.BitLen())
+	}
+	return nil
+}
+
+// digest hashes data with hash; the zero hash selects SHA-256.
+func digest(hash crypto.Hash, data []byte) (crypto.Hash, []byte) {
+	if hash == 0 {
+		hash = crypto.SHA256
+	}
+	h := hash.New()
+	h.Write(data)
+	return hash, h.Sum(nil)
+}
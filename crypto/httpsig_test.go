@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResponseSigner(t *testing.T) {
+	body := []byte(`{"data":{"signature":"MEUCIQ...","signed_data":"0_payload_ZGV2aWNl"}}`)
+	response := SignedResponse{
+		Method:      "POST",
+		Target:      "/api/v0/devices/device-001/sign",
+		Status:      200,
+		ContentType: "application/json",
+		Body:        body,
+	}
+
+	t.Run("signs the known response", func(t *testing.T) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		signer, err := NewResponseSigner(key, "server-1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		header, err := signer.Header(response)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		keyID, tagged, err := ParseResponseSignature(header)
+		if err != nil || keyID != "server-1" || !strings.HasPrefix(tagged, "ECDSA-SHA256:") {
+			t.Fatalf("unexpected header %q: %v", header, err)
+		}
+		_, signature, _ := DecodeTaggedSignature(tagged)
+		base := "POST /api/v0/devices/device-001/sign\n200\napplication/json\n" + string(body)
+		digest := sha256.Sum256([]byte(base))
+		if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+			t.Error("expected the signature to verify over the request target, status, type and body")
+		}
+		if err := VerifyResponseSignature(header, response, signer.PublicKey()); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("detects a tampered body", func(t *testing.T) {
+		keyPair, _ := (&RSAGenerator{}).Generate()
+		signer, _ := NewResponseSigner(keyPair.Private, "server")
+		header, _ := signer.Header(response)
+
+		tampered := response
+		tampered.Body = []byte(strings.Replace(string(body), "0_payload", "1_payload", 1))
+		if err := VerifyResponseSignature(header, tampered, keyPair.Public); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("detects a replayed response", func(t *testing.T) {
+		keyPair, _ := (&ECCGenerator{}).Generate()
+		signer, _ := NewResponseSigner(keyPair.Private, "server")
+		header, _ := signer.Header(response)
+
+		otherStatus, otherTarget, otherType := response, response, response
+		otherStatus.Status = 201
+		otherTarget.Target = "/api/v0/devices/device-002/sign"
+		otherType.ContentType = "text/plain"
+		for _, replayed := range []SignedResponse{otherStatus, otherTarget, otherType} {
+			if err := VerifyResponseSignature(header, replayed, keyPair.Public); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature for %+v, got %v", replayed, err)
+			}
+		}
+	})
+
+	t.Run("follows the curve hash policy", func(t *testing.T) {
+		key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		signer, _ := NewResponseSigner(key, "server")
+		header, _ := signer.Header(response)
+		if !strings.Contains(header, `signature="ECDSA-SHA384:`) {
+			t.Errorf("expected an ECDSA-SHA384 signature, got %q", header)
+		}
+		if err := VerifyResponseSignature(header, response, &key.PublicKey); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("rejects malformed headers", func(t *testing.T) {
+		for _, header := range []string{"", `keyid="server"`, `keyid=server,signature="abc"`} {
+			if _, _, err := ParseResponseSignature(header); !errors.Is(err, ErrMalformedSignatureHeader) {
+				t.Errorf("expected ErrMalformedSignatureHeader for %q, got %v", header, err)
+			}
+		}
+	})
+}
+
+func TestParsePrivateKeyPEM(t *testing.T) {
+	keyPair, _ := (&ECCGenerator{}).Generate()
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(keyPair.Private)
+	sec1, _ := x509.MarshalECPrivateKey(keyPair.Private)
+
+	for _, block := range []*pem.Block{{Type: "PRIVATE KEY", Bytes: pkcs8}, {Type: "EC PRIVATE KEY", Bytes: sec1}} {
+		key, err := ParsePrivateKeyPEM(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatalf("expected no error for %s, got %v", block.Type, err)
+		}
+		if !keyPair.Private.Equal(key) {
+			t.Errorf("expected parsed %s to equal original", block.Type)
+		}
+	}
+
+	if _, err := ParsePrivateKeyPEM([]byte("not pem")); err == nil {
+		t.Error("expected an error for data without a PEM block")
+	}
+}
@@ -0,0 +1,214 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// jsonWebKey holds the members of an RFC 7517 JSON Web Key needed for RSA and EC keys. D, P
+// and Q are only present in private keys; the CRT parameters are recomputed instead of read.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d"`
+	P   string `json:"p"`
+	Q   string `json:"q"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// ParseJWKS decodes an RFC 7517 JSON Web Key Set and returns its RSA and EC public keys by
+// key ID. Keys without a kid and key types other than RSA and EC (e.g. symmetric "oct" keys)
+// are skipped; a malformed RSA or EC key fails the whole set.
+func ParseJWKS(data []byte) (map[string]interface{}, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" {
+			continue
+		}
+
+		var key interface{}
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaPublicKey()
+		case "EC":
+			key, err = jwk.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// ParseJWK decodes a single RFC 7517 RSA or EC JSON Web Key. A key carrying the private member
+// "d" yields an *rsa.PrivateKey or *ecdsa.PrivateKey, checked for consistency with its public
+// members; any other key yields its *rsa.PublicKey or *ecdsa.PublicKey.
+func ParseJWK(data []byte) (interface{}, error) {
+	var jwk jsonWebKey
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, fmt.Errorf("failed to decode JWK: %w", err)
+	}
+
+	var key interface{}
+	var err error
+	switch {
+	case jwk.Kty == "RSA" && jwk.D != "":
+		key, err = jwk.rsaPrivateKey()
+	case jwk.Kty == "RSA":
+		key, err = jwk.rsaPublicKey()
+	case jwk.Kty == "EC" && jwk.D != "":
+		key, err = jwk.ecdsaPrivateKey()
+	case jwk.Kty == "EC":
+		key, err = jwk.ecdsaPublicKey()
+	case jwk.Kty == "":
+		return nil, fmt.Errorf("missing key type (kty)")
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeJWKInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := decodeJWKInt(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("unsupported exponent %s", e)
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jsonWebKey) rsaPrivateKey() (*rsa.PrivateKey, error) {
+	public, err := k.rsaPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	d, err := decodeJWKInt(k.D)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private exponent: %w", err)
+	}
+	p, err := decodeJWKInt(k.P)
+	if err != nil {
+		return nil, fmt.Errorf("invalid first prime factor: %w", err)
+	}
+	q, err := decodeJWKInt(k.Q)
+	if err != nil {
+		return nil, fmt.Errorf("invalid second prime factor: %w", err)
+	}
+
+	key := &rsa.PrivateKey{PublicKey: *public, D: d, Primes: []*big.Int{p, q}}
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("inconsistent private key: %w", err)
+	}
+	key.Precompute()
+	return key, nil
+}
+
+func (k jsonWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	curve, ecdhCurve, err := jwkCurve(k.Crv)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("coordinates must be %d bytes", size)
+	}
+
+	// The ecdh package rejects points that are not on the curve.
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid curve point: %w", err)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+func (k jsonWebKey) ecdsaPrivateKey() (*ecdsa.PrivateKey, error) {
+	public, err := k.ecdsaPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	_, ecdhCurve, _ := jwkCurve(k.Crv)
+
+	d, err := base64.RawURLEncoding.DecodeString(k.D)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	// The ecdh package rejects scalars outside [1, n-1]; the derived point must be the public one.
+	private, err := ecdhCurve.NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	size := (public.Curve.Params().BitSize + 7) / 8
+	point := append(append([]byte{4}, public.X.FillBytes(make([]byte, size))...), public.Y.FillBytes(make([]byte, size))...)
+	if !bytes.Equal(private.PublicKey().Bytes(), point) {
+		return nil, fmt.Errorf("inconsistent private key: does not match x and y")
+	}
+	return &ecdsa.PrivateKey{PublicKey: *public, D: new(big.Int).SetBytes(d)}, nil
+}
+
+// jwkCurve maps a JWK curve name to its elliptic and ecdh curves.
+func jwkCurve(name string) (elliptic.Curve, ecdh.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), ecdh.P256(), nil
+	case "P-384":
+		return elliptic.P384(), ecdh.P384(), nil
+	case "P-521":
+		return elliptic.P521(), ecdh.P521(), nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported curve %q", name)
+	}
+}
+
+// decodeJWKInt decodes a base64url encoded big-endian unsigned integer.
+func decodeJWKInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,138 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseJWKS(t *testing.T) {
+	rsaKeys, _ := (&RSAGenerator{}).Generate()
+	eccKeys, _ := (&ECCGenerator{}).Generate()
+	size := (eccKeys.Public.Curve.Params().BitSize + 7) / 8
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "n": encode(rsaKeys.Public.N.Bytes()), "e": encode(big.NewInt(int64(rsaKeys.Public.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-384", "x": encode(eccKeys.Public.X.FillBytes(make([]byte, size))), "y": encode(eccKeys.Public.Y.FillBytes(make([]byte, size)))},
+		{"kty": "oct", "kid": "hmac-1", "k": "c2VjcmV0"},
+		{"kty": "RSA", "n": encode(rsaKeys.Public.N.Bytes()), "e": "AQAB"},
+	}}
+
+	t.Run("parses RSA and EC keys by kid", func(t *testing.T) {
+		data, _ := json.Marshal(set)
+
+		keys, err := ParseJWKS(data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(keys) != 2 {
+			t.Fatalf("expected 2 keys, got %d", len(keys))
+		}
+		if !rsaKeys.Public.Equal(keys["rsa-1"]) {
+			t.Error("expected rsa-1 to match the RSA public key")
+		}
+		if !eccKeys.Public.Equal(keys["ec-1"]) {
+			t.Error("expected ec-1 to match the ECDSA public key")
+		}
+	})
+
+	t.Run("rejects points off the curve", func(t *testing.T) {
+		data := []byte(`{"keys":[{"kty":"EC","kid":"bad","crv":"P-256","x":"` + encode(make([]byte, 32)) + `","y":"` + encode(make([]byte, 32)) + `"}]}`)
+
+		if _, err := ParseJWKS(data); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("rejects malformed JSON", func(t *testing.T) {
+		if _, err := ParseJWKS([]byte("not json")); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestParseJWK(t *testing.T) {
+	rsaKeys, _ := (&RSAGenerator{}).Generate()
+	eccKeys, _ := (&ECCGenerator{}).Generate()
+	size := (eccKeys.Public.Curve.Params().BitSize + 7) / 8
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	ecJWK := map[string]string{
+		"kty": "EC",
+		"crv": "P-384",
+		"x":   encode(eccKeys.Public.X.FillBytes(make([]byte, size))),
+		"y":   encode(eccKeys.Public.Y.FillBytes(make([]byte, size))),
+		"d":   encode(eccKeys.Private.D.FillBytes(make([]byte, size))),
+	}
+	rsaJWK := map[string]string{
+		"kty": "RSA",
+		"n":   encode(rsaKeys.Public.N.Bytes()),
+		"e":   encode(big.NewInt(int64(rsaKeys.Public.E)).Bytes()),
+		"d":   encode(rsaKeys.Private.D.Bytes()),
+		"p":   encode(rsaKeys.Private.Primes[0].Bytes()),
+		"q":   encode(rsaKeys.Private.Primes[1].Bytes()),
+	}
+	marshal := func(jwk map[string]string, drop ...string) []byte {
+		copied := make(map[string]string, len(jwk))
+		for k, v := range jwk {
+			copied[k] = v
+		}
+		for _, k := range drop {
+			delete(copied, k)
+		}
+		data, _ := json.Marshal(copied)
+		return data
+	}
+
+	t.Run("parses EC private key", func(t *testing.T) {
+		key, err := ParseJWK(marshal(ecJWK))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !eccKeys.Private.Equal(key) {
+			t.Error("expected the ECDSA private key")
+		}
+	})
+
+	t.Run("parses RSA private key", func(t *testing.T) {
+		key, err := ParseJWK(marshal(rsaJWK))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !rsaKeys.Private.Equal(key) {
+			t.Error("expected the RSA private key")
+		}
+	})
+
+	t.Run("parses public-only keys", func(t *testing.T) {
+		key, err := ParseJWK(marshal(ecJWK, "d"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !eccKeys.Public.Equal(key) {
+			t.Error("expected the ECDSA public key")
+		}
+	})
+
+	t.Run("rejects invalid keys", func(t *testing.T) {
+		otherKeys, _ := (&ECCGenerator{}).Generate()
+		mismatched := marshal(ecJWK)
+		mismatched = []byte(strings.Replace(string(mismatched), ecJWK["d"], encode(otherKeys.Private.D.FillBytes(make([]byte, size))), 1))
+
+		invalid := map[string][]byte{
+			"missing kty":          marshal(ecJWK, "kty"),
+			"missing RSA prime":    marshal(rsaJWK, "q"),
+			"missing EC y":         marshal(ecJWK, "y"),
+			"mismatched EC scalar": mismatched,
+			"symmetric key":        []byte(`{"kty":"oct","k":"c2VjcmV0"}`),
+		}
+		for name, data := range invalid {
+			if _, err := ParseJWK(data); err == nil {
+				t.Errorf("%s: expected error, got nil", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidMultibase is returned when a multibase string uses an unsupported base or holds
+// characters outside its alphabet.
+var ErrInvalidMultibase = errors.New("invalid multibase encoding")
+
+// MultibaseBase58BTC is the multibase prefix of base58btc, the encoding used for multibase
+// signatures.
+const MultibaseBase58BTC = 'z'
+
+// base58Alphabet is the Bitcoin base58 alphabet, which leaves out 0, O, I and l.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// EncodeBase58 encodes data in base58btc. Leading zero bytes are kept as leading '1's.
+func EncodeBase58(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	var digits []byte
+	n := new(big.Int).SetBytes(data)
+	radix, mod := big.NewInt(58), new(big.Int)
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		digits = append(digits, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		digits = append(digits, base58Alphabet[0])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// DecodeBase58 decodes a base58btc string produced by EncodeBase58.
+func DecodeBase58(encoded string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(encoded) && encoded[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range encoded[zeros:] {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("%w: invalid base58 character %q", ErrInvalidMultibase, c)
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(digit)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// EncodeMultibase encodes data as multibase base58btc: 'z' followed by the base58btc digits,
+// as used by decentralized identity documents and proofs.
+func EncodeMultibase(data []byte) string {
+	return string(MultibaseBase58BTC) + EncodeBase58(data)
+}
+
+// DecodeMultibase decodes a multibase string. Only base58btc ('z') is supported.
+func DecodeMultibase(encoded string) ([]byte, error) {
+	if encoded == "" || encoded[0] != MultibaseBase58BTC {
+		return nil, fmt.Errorf("%w: expected base58btc prefix %q", ErrInvalidMultibase, MultibaseBase58BTC)
+	}
+	return DecodeBase58(encoded[1:])
+}
@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMultibase(t *testing.T) {
+	t.Run("known base58btc vectors", func(t *testing.T) {
+		vectors := map[string]string{
+			"":            "",
+			"hello world": "StV1DL6CwTryKyV",
+			"\x00\x00abc": "11ZiCa",
+		}
+		for input, expected := range vectors {
+			if got := EncodeBase58([]byte(input)); got != expected {
+				t.Errorf("%q: expected %q, got %q", input, expected, got)
+			}
+		}
+	})
+
+	t.Run("round trips signatures", func(t *testing.T) {
+		signature := []byte{0x00, 0x30, 0x45, 0x02, 0x21, 0x00, 0xfb, 0xff, 0x01}
+
+		encoded := EncodeMultibase(signature)
+		if encoded[0] != 'z' {
+			t.Fatalf("expected base58btc prefix 'z', got %q", encoded)
+		}
+
+		decoded, err := DecodeMultibase(encoded)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !bytes.Equal(decoded, signature) {
+			t.Errorf("expected %x, got %x", signature, decoded)
+		}
+	})
+
+	t.Run("rejects other bases and invalid characters", func(t *testing.T) {
+		for _, encoded := range []string{"", "mAQID", "z0OIl"} {
+			if _, err := DecodeMultibase(encoded); !errors.Is(err, ErrInvalidMultibase) {
+				t.Errorf("%q: expected ErrInvalidMultibase, got %v", encoded, err)
+			}
+		}
+	})
+}
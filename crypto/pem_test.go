@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestParsePublicKeyPEM(t *testing.T) {
+	t.Run("PKIX ECDSA key", func(t *testing.T) {
+		keyPair, _ := (&ECCGenerator{}).Generate()
+		der, _ := x509.MarshalPKIXPublicKey(keyPair.Public)
+		data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+		key, err := ParsePublicKeyPEM(data)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !keyPair.Public.Equal(key) {
+			t.Error("expected parsed key to equal original")
+		}
+	})
+
+	t.Run("PKCS1 RSA key", func(t *testing.T) {
+		keyPair, _ := (&RSAGenerator{}).Generate()
+		data := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(keyPair.Public)})
+
+		key, err := ParsePublicKeyPEM(data)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !keyPair.Public.Equal(key) {
+			t.Error("expected parsed key to equal original")
+		}
+	})
+
+	t.Run("rejects non-PEM input", func(t *testing.T) {
+		if _, err := ParsePublicKeyPEM([]byte("not a key")); err == nil {
+			t.Error("expected error for non-PEM input")
+		}
+	})
+
+	t.Run("rejects private key blocks", func(t *testing.T) {
+		data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{0x30}})
+
+		if _, err := ParsePublicKeyPEM(data); err == nil {
+			t.Error("expected error for private key block")
+		}
+	})
+}
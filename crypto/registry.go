@@ -0,0 +1,119 @@
+package crypto
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AlgorithmInfo describes a signing algorithm and the parameters it accepts.
+// Exactly one of the key size or curve fields is relevant for a given algorithm.
+type AlgorithmInfo struct {
+	Name               string
+	DefaultKeySize     int
+	KeySizes           []int
+	DefaultCurve       string
+	Curves             []string
+	Hashes             []string
+	DefaultHash        string            // Hash of new keys unless CurveHashes names one; empty is SHA-256
+	CurveHashes        map[string]string // Default hash per curve, e.g. SHA-384 for P-384
+	SignatureEncodings []string
+	SignatureTag       string            // Prefix identifying the scheme over SHA-256 in tagged signatures; empty if untaggable
+	HashTags           map[string]string // Tags of the scheme over hashes other than SHA-256
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]AlgorithmInfo{}
+)
+
+func init() {
+	MustRegisterAlgorithm(AlgorithmInfo{
+		Name:               "RSA",
+		DefaultKeySize:     DefaultRSAKeySize,
+		KeySizes:           supportedRSAKeySizes,
+		Hashes:             []string{HashSHA256, HashSHA384, HashSHA512},
+		DefaultHash:        HashSHA256,
+		SignatureEncodings: []string{"PKCS#1 v1.5"},
+		SignatureTag:       "RSA-PKCS1-SHA256",
+		HashTags:           map[string]string{HashSHA384: "RSA-PKCS1-SHA384", HashSHA512: "RSA-PKCS1-SHA512"},
+	})
+	MustRegisterAlgorithm(AlgorithmInfo{
+		Name:         "ECC",
+		DefaultCurve: DefaultECCCurve,
+		Curves:       supportedCurves,
+		Hashes:       []string{HashSHA256, HashSHA384, HashSHA512},
+		DefaultHash:  HashSHA256,
+		// Match the digest to the curve's security level.
+		CurveHashes:        map[string]string{"P-256": HashSHA256, "P-384": HashSHA384, "P-521": HashSHA512},
+		SignatureEncodings: []string{"ASN.1 DER (r, s)"},
+		SignatureTag:       "ECDSA-SHA256",
+		HashTags:           map[string]string{HashSHA384: "ECDSA-SHA384", HashSHA512: "ECDSA-SHA512"},
+	})
+}
+
+// RegisterAlgorithm adds an algorithm to the registry. Names must be unique.
+func RegisterAlgorithm(info AlgorithmInfo) error {
+	if info.Name == "" {
+		return fmt.Errorf("algorithm name is required")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[info.Name]; exists {
+		return fmt.Errorf("algorithm %s already registered", info.Name)
+	}
+	registry[info.Name] = info
+	return nil
+}
+
+// MustRegisterAlgorithm is like RegisterAlgorithm but panics on error. Intended for init.
+func MustRegisterAlgorithm(info AlgorithmInfo) {
+	if err := RegisterAlgorithm(info); err != nil {
+		panic(err)
+	}
+}
+
+// LookupAlgorithm returns the registered algorithm with the given name.
+func LookupAlgorithm(name string) (AlgorithmInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := registry[name]
+	return info, ok
+}
+
+// lookupAlgorithmByTag returns the registered algorithm with tag among its signature tags,
+// along with the hash the tag stands for.
+func lookupAlgorithmByTag(tag string) (AlgorithmInfo, string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, info := range registry {
+		if info.SignatureTag != "" && info.SignatureTag == tag {
+			return info, DefaultHash, true
+		}
+		for hash, hashTag := range info.HashTags {
+			if hashTag == tag {
+				return info, hash, true
+			}
+		}
+	}
+	return AlgorithmInfo{}, "", false
+}
+
+// Algorithms returns all registered algorithms sorted by name.
+func Algorithms() []AlgorithmInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	algorithms := make([]AlgorithmInfo, 0, len(registry))
+	for _, info := range registry {
+		algorithms = append(algorithms, info)
+	}
+	sort.Slice(algorithms, func(i, j int) bool {
+		return algorithms[i].Name < algorithms[j].Name
+	})
+	return algorithms
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import "testing"
+
+func TestAlgorithmRegistry(t *testing.T) {
+	t.Run("built-in algorithms are registered", func(t *testing.T) {
+		rsaInfo, ok := LookupAlgorithm("RSA")
+		if !ok {
+			t.Fatal("expected RSA to be registered")
+		}
+		if rsaInfo.DefaultKeySize != DefaultRSAKeySize {
+			t.Errorf("expected default key size %d, got %d", DefaultRSAKeySize, rsaInfo.DefaultKeySize)
+		}
+
+		eccInfo, ok := LookupAlgorithm("ECC")
+		if !ok {
+			t.Fatal("expected ECC to be registered")
+		}
+		if eccInfo.DefaultCurve != DefaultECCCurve {
+			t.Errorf("expected default curve %s, got %s", DefaultECCCurve, eccInfo.DefaultCurve)
+		}
+	})
+
+	t.Run("registered algorithm is listed", func(t *testing.T) {
+		if err := RegisterAlgorithm(AlgorithmInfo{Name: "TEST-REGISTRY"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() {
+			registryMu.Lock()
+			delete(registry, "TEST-REGISTRY")
+			registryMu.Unlock()
+		})
+
+		found := false
+		for _, info := range Algorithms() {
+			if info.Name == "TEST-REGISTRY" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("expected registered algorithm to be listed")
+		}
+	})
+
+	t.Run("duplicate name is rejected", func(t *testing.T) {
+		if err := RegisterAlgorithm(AlgorithmInfo{Name: "RSA"}); err == nil {
+			t.Error("expected error for duplicate registration")
+		}
+	})
+}
+
+func TestPolicyHash(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		curve     string
+		expected  string
+	}{
+		{"ECC", "P-256", HashSHA256},
+		{"ECC", "P-384", HashSHA384},
+		{"ECC", "P-521", HashSHA512},
+		{"RSA", "", HashSHA256},
+		{"UNKNOWN", "", DefaultHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm+" "+tt.curve, func(t *testing.T) {
+			if got := PolicyHash(tt.algorithm, tt.curve); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
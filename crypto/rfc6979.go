@@ -0,0 +1,115 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// ecdsaSignature mirrors the ASN.1 structure produced by ecdsa.SignASN1.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// signDeterministic produces an ASN.1 DER encoded ECDSA signature over hash, a digest made
+// with hashFunc, using a nonce derived per RFC 6979 (HMAC-DRBG with hashFunc), so identical
+// input always yields an identical signature and no randomness source is consulted.
+func signDeterministic(privateKey *ecdsa.PrivateKey, hashFunc crypto.Hash, hash []byte) ([]byte, error) {
+	curve := privateKey.Curve
+	n := curve.Params().N
+	if n.Sign() == 0 {
+		return nil, errors.New("invalid curve order")
+	}
+
+	e := bits2int(hash, n)
+	nextK := rfc6979Nonces(privateKey.D, n, hashFunc, hash)
+	for {
+		k := nextK()
+
+		x, _ := curve.ScalarBaseMult(k.Bytes())
+		r := new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		s := new(big.Int).Mul(r, privateKey.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		return asn1.Marshal(ecdsaSignature{R: r, S: s})
+	}
+}
+
+// rfc6979Nonces returns a generator yielding the sequence of candidate nonces defined
+// in RFC 6979 section 3.2, with HMAC over hashFunc. Callers draw another candidate if one
+// is unusable.
+func rfc6979Nonces(x, q *big.Int, hashFunc crypto.Hash, hash []byte) func() *big.Int {
+	qlen := q.BitLen()
+	rolen := (qlen + 7) / 8
+
+	v := make([]byte, hashFunc.Size())
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, hashFunc.Size())
+
+	mac := func(key []byte, parts ...[]byte) []byte {
+		h := hmac.New(hashFunc.New, key)
+		for _, p := range parts {
+			h.Write(p)
+		}
+		return h.Sum(nil)
+	}
+
+	privOctets := int2octets(x, rolen)
+	hashOctets := int2octets(new(big.Int).Mod(bits2int(hash, q), q), rolen)
+
+	k = mac(k, v, []byte{0x00}, privOctets, hashOctets)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, privOctets, hashOctets)
+	v = mac(k, v)
+
+	first := true
+	return func() *big.Int {
+		for {
+			if !first {
+				k = mac(k, v, []byte{0x00})
+				v = mac(k, v)
+			}
+			first = false
+
+			var t []byte
+			for len(t)*8 < qlen {
+				v = mac(k, v)
+				t = append(t, v...)
+			}
+
+			candidate := bits2int(t, q)
+			if candidate.Sign() > 0 && candidate.Cmp(q) < 0 {
+				return candidate
+			}
+		}
+	}
+}
+
+// bits2int converts a byte string to an integer, keeping only the leftmost qlen bits.
+func bits2int(b []byte, q *big.Int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if excess := len(b)*8 - q.BitLen(); excess > 0 {
+		v.Rsh(v, uint(excess))
+	}
+	return v
+}
+
+// int2octets encodes v as a big-endian byte string of exactly size bytes.
+func int2octets(v *big.Int, size int) []byte {
+	out := make([]byte, size)
+	return v.FillBytes(out)
+}
@@ -5,7 +5,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
+	"fmt"
 )
 
 // Signer defines a contract for cryptographic signing operations.
@@ -14,9 +14,11 @@ type Signer interface {
 	Sign(dataToBeSigned []byte) ([]byte, error)
 }
 
-// RSASigner implements signing using RSA with PKCS#1 v1.5 and SHA-256.
+// RSASigner implements signing using RSA with PKCS#1 v1.5, over SHA-256 unless created
+// with another hash.
 type RSASigner struct {
 	privateKey *rsa.PrivateKey
+	hash       crypto.Hash // Zero selects SHA-256
 }
 
 // NewRSASigner creates an RSA signer with the provided private key.
@@ -26,16 +28,19 @@ func NewRSASigner(privateKey *rsa.PrivateKey) *RSASigner {
 	}
 }
 
-// Sign generates an RSA signature by hashing data with SHA-256 then signing with PKCS#1v15.
-// Returns raw signature bytes. The hash[:] slice conversion is required by the signing API.
+// Sign generates an RSA signature by hashing data with the signer's hash then signing with
+// PKCS#1v15. Returns raw signature bytes.
 func (s *RSASigner) Sign(dataTobeSigned []byte) ([]byte, error) {
-	hash := sha256.Sum256(dataTobeSigned)
-	return rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hash[:])
+	hash, hashed := digest(s.hash, dataTobeSigned)
+	return rsa.SignPKCS1v15(rand.Reader, s.privateKey, hash, hashed)
 }
 
-// ECDSASigner implements signing using ECDSA with SHA-256 and ASN.1 encoding.
+// ECDSASigner implements signing using ECDSA with ASN.1 encoding, over SHA-256 unless
+// created with another hash.
 type ECDSASigner struct {
-	privateKey *ecdsa.PrivateKey
+	privateKey    *ecdsa.PrivateKey
+	deterministic bool
+	hash          crypto.Hash // Zero selects SHA-256
 }
 
 // NewECDSASigner creates an ECDSA signer with the provided private key.
@@ -45,9 +50,35 @@ func NewECDSASigner(privateKey *ecdsa.PrivateKey) *ECDSASigner {
 	}
 }
 
-// Sign generates an ECDSA signature by hashing data with SHA-256 then signing with ASN.1 encoding.
-// Returns ASN.1 DER encoded signature bytes. Unlike RSA, ECDSA includes randomness per signature.
+// NewDeterministicECDSASigner creates an ECDSA signer that derives nonces per RFC 6979,
+// so signing the same data twice yields the same signature.
+func NewDeterministicECDSASigner(privateKey *ecdsa.PrivateKey) *ECDSASigner {
+	return &ECDSASigner{
+		privateKey:    privateKey,
+		deterministic: true,
+	}
+}
+
+// NewSigner creates the signer for privateKey, an RSA or ECDSA private key, hashing data with
+// hash. Deterministic selects RFC 6979 nonces for ECDSA keys and is ignored for RSA.
+func NewSigner(privateKey interface{}, hash crypto.Hash, deterministic bool) (Signer, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return &RSASigner{privateKey: key, hash: hash}, nil
+	case *ecdsa.PrivateKey:
+		return &ECDSASigner{privateKey: key, deterministic: deterministic, hash: hash}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+}
+
+// Sign generates an ECDSA signature by hashing data with the signer's hash then signing with
+// ASN.1 encoding. Returns ASN.1 DER encoded signature bytes. Unlike RSA, ECDSA includes
+// randomness per signature unless the signer was created in deterministic mode.
 func (s *ECDSASigner) Sign(dataTobeSigned []byte) ([]byte, error) {
-	hash := sha256.Sum256(dataTobeSigned)
-	return ecdsa.SignASN1(rand.Reader, s.privateKey, hash[:])
+	hash, hashed := digest(s.hash, dataTobeSigned)
+	if s.deterministic {
+		return signDeterministic(s.privateKey, hash, hashed)
+	}
+	return ecdsa.SignASN1(rand.Reader, s.privateKey, hashed)
 }
@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestECDSASigner(t *testing.T) {
+	generator := &ECCGenerator{}
+	keyPair, err := generator.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	data := []byte("0_transaction-data_ZGV2aWNlLTAwMQ==")
+	hash := sha256.Sum256(data)
+
+	t.Run("deterministic mode produces identical signatures", func(t *testing.T) {
+		signer := NewDeterministicECDSASigner(keyPair.Private)
+
+		first, err := signer.Sign(data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		second, err := signer.Sign(data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !bytes.Equal(first, second) {
+			t.Error("expected identical signatures in deterministic mode")
+		}
+		if !ecdsa.VerifyASN1(keyPair.Public, hash[:], first) {
+			t.Error("expected deterministic signature to verify")
+		}
+	})
+
+	t.Run("deterministic mode differs per input", func(t *testing.T) {
+		signer := NewDeterministicECDSASigner(keyPair.Private)
+
+		first, _ := signer.Sign(data)
+		second, _ := signer.Sign([]byte("1_other-data_c2lnbmF0dXJl"))
+
+		if bytes.Equal(first, second) {
+			t.Error("expected different signatures for different input")
+		}
+	})
+
+	t.Run("randomized mode produces different signatures", func(t *testing.T) {
+		signer := NewECDSASigner(keyPair.Private)
+
+		first, err := signer.Sign(data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		second, err := signer.Sign(data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if bytes.Equal(first, second) {
+			t.Error("expected different signatures in randomized mode")
+		}
+		if !ecdsa.VerifyASN1(keyPair.Public, hash[:], first) {
+			t.Error("expected randomized signature to verify")
+		}
+	})
+}
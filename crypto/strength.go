@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+)
+
+// MinimumSecurityBits is the security strength below which a key is considered weak, following
+// NIST SP 800-57, which disallows less than 112 bits for signature generation.
+const MinimumSecurityBits = 112
+
+// rsaSecurityBits maps RSA modulus sizes to their estimated security strength per NIST SP
+// 800-57 Part 1, Table 2. Sizes between entries get the strength of the next smaller one.
+var rsaSecurityBits = []struct{ modulus, bits int }{
+	{15360, 256},
+	{7680, 192},
+	{3072, 128},
+	{2048, 112},
+	{1024, 80},
+}
+
+// eccSecurityBits maps curve order sizes to their estimated security strength per the same
+// table, so P-521 is rated 256 bits rather than half its order size.
+var eccSecurityBits = []struct{ order, bits int }{
+	{512, 256},
+	{384, 192},
+	{256, 128},
+	{224, 112},
+	{160, 80},
+}
+
+// weakRSASecurityBits is the strength assumed for moduli below 1024 bits, which are factorable
+// with modest resources.
+const weakRSASecurityBits = 56
+
+// KeySecurityBits estimates the security strength, in bits, of an RSA or ECDSA public key: the
+// NIST SP 800-57 equivalent for the RSA modulus or ECDSA curve order size.
+func KeySecurityBits(publicKey interface{}) (int, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		size := key.N.BitLen()
+		for _, entry := range rsaSecurityBits {
+			if size >= entry.modulus {
+				return entry.bits, nil
+			}
+		}
+		return weakRSASecurityBits, nil
+	case *ecdsa.PublicKey:
+		size := key.Curve.Params().N.BitLen()
+		for _, entry := range eccSecurityBits {
+			if size >= entry.order {
+				return entry.bits, nil
+			}
+		}
+		return size / 2, nil
+	default:
+		return 0, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// HashSecurityBits returns the collision resistance, in bits, of a supported hash, which bounds
+// the strength of signatures over its digests.
+func HashSecurityBits(hash string) (int, error) {
+	h, err := ParseHash(hash)
+	if err != nil {
+		return 0, err
+	}
+	return h.Size() * 8 / 2, nil
+}
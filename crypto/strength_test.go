@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestKeySecurityBits(t *testing.T) {
+	rsaKey := func(bits int) interface{} {
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			t.Fatalf("failed to generate RSA key: %v", err)
+		}
+		return &key.PublicKey
+	}
+	eccKey := func(curve elliptic.Curve) interface{} {
+		key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		return &key.PublicKey
+	}
+
+	cases := []struct {
+		name     string
+		key      interface{}
+		expected int
+	}{
+		{"RSA-512", rsaKey(512), 56},
+		{"RSA-1024", rsaKey(1024), 80},
+		{"RSA-2048", rsaKey(2048), 112},
+		{"RSA-3072", rsaKey(3072), 128},
+		{"P-256", eccKey(elliptic.P256()), 128},
+		{"P-384", eccKey(elliptic.P384()), 192},
+		{"P-521", eccKey(elliptic.P521()), 256},
+	}
+	for _, tc := range cases {
+		bits, err := KeySecurityBits(tc.key)
+		if err != nil || bits != tc.expected {
+			t.Errorf("%s: expected %d bits, got %d (%v)", tc.name, tc.expected, bits, err)
+		}
+	}
+
+	if _, err := KeySecurityBits("not a key"); err == nil {
+		t.Error("expected an error for an unsupported key")
+	}
+}
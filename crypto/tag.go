@@ -0,0 +1,73 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownSignatureTag is returned when a tagged signature names no registered algorithm.
+var ErrUnknownSignatureTag = errors.New("unknown signature algorithm tag")
+
+// signatureTagSeparator ends the algorithm tag of a tagged signature. It never occurs in
+// base64, so a signature containing it is always tagged.
+const signatureTagSeparator = ":"
+
+// TagSignature prefixes a base64 signature with the tag of the algorithm that produced it over
+// a SHA-256 digest, e.g. "ECDSA-SHA256:MEUCIQ...", making the signature self-describing.
+func TagSignature(algorithm, signature string) (string, error) {
+	return TagHashSignature(algorithm, DefaultHash, signature)
+}
+
+// TagHashSignature is TagSignature for a signature over a hash digest, e.g.
+// "ECDSA-SHA384:MGUCMQ...". The empty hash stands for DefaultHash.
+func TagHashSignature(algorithm, hash, signature string) (string, error) {
+	info, ok := LookupAlgorithm(algorithm)
+	tag := info.SignatureTag
+	if hash != "" && hash != DefaultHash {
+		tag = info.HashTags[hash]
+	}
+	if !ok || tag == "" {
+		return "", fmt.Errorf("no signature tag for algorithm %q with hash %q", algorithm, hash)
+	}
+	return tag + signatureTagSeparator + signature, nil
+}
+
+// DecodeTaggedSignature decodes a signature that may carry an algorithm tag. It returns the
+// name of the tagged algorithm, or "" for a plain base64 signature, and the raw signature.
+func DecodeTaggedSignature(encoded string) (string, []byte, error) {
+	algorithm, _, signature, err := DecodeTaggedSignatureHash(encoded)
+	return algorithm, signature, err
+}
+
+// DecodeTaggedSignatureHash is DecodeTaggedSignature also returning the hash named by the tag,
+// or "" for a plain base64 signature.
+func DecodeTaggedSignatureHash(encoded string) (string, string, []byte, error) {
+	algorithm, hash := "", ""
+	if tag, rest, tagged := strings.Cut(encoded, signatureTagSeparator); tagged {
+		info, tagHash, ok := lookupAlgorithmByTag(tag)
+		if !ok {
+			return "", "", nil, fmt.Errorf("%w: %q", ErrUnknownSignatureTag, tag)
+		}
+		algorithm, hash, encoded = info.Name, tagHash, rest
+	}
+	signature, err := DecodeSignature(encoded)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return algorithm, hash, signature, nil
+}
+
+// KeyAlgorithm reports the registered algorithm a private or public key belongs to, or "" if unknown.
+func KeyAlgorithm(key interface{}) string {
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return "RSA"
+	case *ecdsa.PrivateKey, *ecdsa.PublicKey:
+		return "ECC"
+	default:
+		return ""
+	}
+}
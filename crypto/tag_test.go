@@ -0,0 +1,62 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestTaggedSignature(t *testing.T) {
+	signature := []byte{0xfb, 0xff, 0x01, 0x02, 0x03}
+	encoded := base64.StdEncoding.EncodeToString(signature)
+
+	t.Run("tags and decodes each algorithm", func(t *testing.T) {
+		tags := map[string]string{"RSA": "RSA-PKCS1-SHA256", "ECC": "ECDSA-SHA256"}
+		for algorithm, tag := range tags {
+			tagged, err := TagSignature(algorithm, encoded)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tagged != tag+":"+encoded {
+				t.Errorf("expected %q, got %q", tag+":"+encoded, tagged)
+			}
+
+			decodedAlgorithm, decoded, err := DecodeTaggedSignature(tagged)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if decodedAlgorithm != algorithm {
+				t.Errorf("expected algorithm %s, got %s", algorithm, decodedAlgorithm)
+			}
+			if !bytes.Equal(decoded, signature) {
+				t.Errorf("expected %x, got %x", signature, decoded)
+			}
+		}
+	})
+
+	t.Run("untagged signature decodes without algorithm", func(t *testing.T) {
+		algorithm, decoded, err := DecodeTaggedSignature(encoded)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if algorithm != "" {
+			t.Errorf("expected no algorithm, got %s", algorithm)
+		}
+		if !bytes.Equal(decoded, signature) {
+			t.Errorf("expected %x, got %x", signature, decoded)
+		}
+	})
+
+	t.Run("rejects unknown tag", func(t *testing.T) {
+		if _, _, err := DecodeTaggedSignature("DSA-SHA1:" + encoded); !errors.Is(err, ErrUnknownSignatureTag) {
+			t.Errorf("expected ErrUnknownSignatureTag, got %v", err)
+		}
+	})
+
+	t.Run("rejects unregistered algorithm", func(t *testing.T) {
+		if _, err := TagSignature("DSA", encoded); err == nil {
+			t.Error("expected error for unregistered algorithm")
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSignature is returned when a signature does not match the data and key.
+var ErrInvalidSignature = errors.New("signature verification failed")
+
+// Verifier defines a contract for checking signatures produced by a Signer.
+// Verify returns nil when the signature is valid for the given data.
+type Verifier interface {
+	Verify(signedData []byte, signature []byte) error
+}
+
+// RSAVerifier verifies RSA PKCS#1 v1.5 signatures over SHA-256 digests, unless created with
+// another hash.
+type RSAVerifier struct {
+	publicKey *rsa.PublicKey
+	hash      crypto.Hash // Zero selects SHA-256
+}
+
+// NewRSAVerifier creates an RSA verifier for the provided public key.
+func NewRSAVerifier(publicKey *rsa.PublicKey) *RSAVerifier {
+	return &RSAVerifier{
+		publicKey: publicKey,
+	}
+}
+
+// Verify checks an RSA signature produced by RSASigner.
+func (v *RSAVerifier) Verify(signedData []byte, signature []byte) error {
+	hash, hashed := digest(v.hash, signedData)
+	if err := rsa.VerifyPKCS1v15(v.publicKey, hash, hashed, signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ECDSAVerifier verifies ASN.1 encoded ECDSA signatures over SHA-256 digests, unless created
+// with another hash.
+type ECDSAVerifier struct {
+	publicKey *ecdsa.PublicKey
+	hash      crypto.Hash // Zero selects SHA-256
+}
+
+// NewECDSAVerifier creates an ECDSA verifier for the provided public key.
+func NewECDSAVerifier(publicKey *ecdsa.PublicKey) *ECDSAVerifier {
+	return &ECDSAVerifier{
+		publicKey: publicKey,
+	}
+}
+
+// Verify checks an ECDSA signature produced by ECDSASigner, in either randomized or deterministic mode.
+func (v *ECDSAVerifier) Verify(signedData []byte, signature []byte) error {
+	_, hashed := digest(v.hash, signedData)
+	if !ecdsa.VerifyASN1(v.publicKey, hashed, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifySignature checks signature over signedData with publicKey, an RSA or ECDSA public key,
+// using the verifier NewVerifier picks for it.
+func VerifySignature(publicKey interface{}, signedData []byte, signature []byte) error {
+	verifier, err := NewVerifier(publicKey)
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(signedData, signature)
+}
+
+// NewVerifier picks the verifier matching the concrete type of publicKey, for signatures over
+// SHA-256 digests.
+func NewVerifier(publicKey interface{}) (Verifier, error) {
+	return NewHashVerifier(publicKey, crypto.SHA256)
+}
+
+// NewHashVerifier picks the verifier matching the concrete type of publicKey, for signatures
+// over hash digests.
+func NewHashVerifier(publicKey interface{}, hash crypto.Hash) (Verifier, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &RSAVerifier{publicKey: key, hash: hash}, nil
+	case *ecdsa.PublicKey:
+		return &ECDSAVerifier{publicKey: key, hash: hash}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// AttestationDigest is the hex SHA-256 over "<external_signature>_<timestamp>_<counter>",
+// with the timestamp in RFC 3339 (nanosecond precision, UTC). It is the data segment that
+// gets chained and signed for an attestation.
+func AttestationDigest(externalSignature string, timestamp time.Time, counter int64) string {
+	payload := externalSignature + "_" + timestamp.UTC().Format(time.RFC3339Nano) + "_" + strconv.FormatInt(counter, 10)
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// AttestSignature timestamps an external signature by signing a digest of it, the current
+// time, and the device counter as the next link in the device's chain. The attestation
+// increments the counter like a regular signature and is recorded in history as an attestation.
+// It counts against the device's signing quota, failing with ErrQuotaExceeded once it is used up.
+// The pre-sign hook is consulted with the external signature as data, and the post-sign hook is
+// told about the new signature, as for SignData. Devices created with RequireNonce need a fresh
+// nonce, bound into the signed data after the digest, exactly as for SignData.
+func (s *SignatureDeviceService) AttestSignature(opts model.AttestOptions) (*model.AttestResponse, error) {
+	resp, err := s.attestSignature(opts)
+	if err != nil {
+		return nil, err
+	}
+	s.runPostSignHook(opts.DeviceID, &model.SignDataResponse{
+		Signature:  resp.Signature,
+		SignedData: resp.SignedData,
+		DataHash:   dataHash(resp.Digest),
+	})
+	return resp, nil
+}
+
+// attestSignature implements AttestSignature; the signing slot and lock are released when it
+// returns.
+func (s *SignatureDeviceService) attestSignature(opts model.AttestOptions) (*model.AttestResponse, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	if s.flags.signingDisabled.Load() {
+		return nil, ErrSigningDisabled
+	}
+	if err := s.checkPreSignHook(opts.DeviceID, opts.Signature); err != nil {
+		return nil, err
+	}
+	release, err := s.acquireSignSlot(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(opts.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	if err := s.checkNonce(device, opts.Nonce); err != nil {
+		return nil, err
+	}
+	timestamp := s.clock.Now().UTC()
+	quota, err := s.quotaWindow(device, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	digest := AttestationDigest(opts.Signature, timestamp, device.SignatureCounter)
+	record, err := s.signAndChain(context.Background(), device, model.RecordTypeAttestation, digest, "", opts.Nonce, "", timestamp, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Nonce != "" {
+		s.rememberNonce(device.ID, opts.Nonce)
+	}
+	if quota != nil {
+		quota.record(timestamp)
+	}
+
+	return &model.AttestResponse{
+		Signature:  record.Signature,
+		SignedData: record.SignedData,
+		Digest:     digest,
+		Nonce:      record.Nonce,
+		Counter:    record.Counter,
+		Timestamp:  timestamp,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestAttestSignature(t *testing.T) {
+	t.Run("attestation verifies and increments the counter", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-attest-001",
+			Label:     "Attest Test",
+			Algorithm: "ECC",
+		})
+		signed, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice"})
+
+		resp, err := service.AttestSignature(model.AttestOptions{
+			DeviceID:  device.ID,
+			Signature: "ZXh0ZXJuYWwtc2lnbmF0dXJl",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if resp.Counter != 1 {
+			t.Errorf("expected attestation at counter 1, got %d", resp.Counter)
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected counter 2, got %d", device.SignatureCounter)
+		}
+		if device.LastSignature != resp.Signature {
+			t.Error("expected attestation to become the chain head")
+		}
+
+		digest := AttestationDigest("ZXh0ZXJuYWwtc2lnbmF0dXJl", resp.Timestamp, resp.Counter)
+		if digest != resp.Digest {
+			t.Errorf("expected digest %s, got %s", digest, resp.Digest)
+		}
+
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: device.ID,
+			Entries: []model.VerifySignatureOptions{
+				{Data: digest, Signature: resp.Signature, Counter: resp.Counter, LastSignature: signed.Signature},
+			},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !results[0].Valid {
+			t.Errorf("expected attestation to verify, got error %s", results[0].Error)
+		}
+	})
+
+	t.Run("history distinguishes attestations from signatures", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-attest-002",
+			Label:     "Attest Test",
+			Algorithm: "RSA",
+		})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice"})
+		service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "c2ln"})
+
+		if len(device.History) != 2 {
+			t.Fatalf("expected 2 history records, got %d", len(device.History))
+		}
+		if device.History[0].Type != model.RecordTypeSignature {
+			t.Errorf("expected first record type %s, got %s", model.RecordTypeSignature, device.History[0].Type)
+		}
+		if device.History[1].Type != model.RecordTypeAttestation {
+			t.Errorf("expected second record type %s, got %s", model.RecordTypeAttestation, device.History[1].Type)
+		}
+		if device.History[1].LastSignature != device.History[0].Signature {
+			t.Error("expected attestation to chain onto the previous signature")
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		resp, err := service.AttestSignature(model.AttestOptions{DeviceID: "non-existent-device", Signature: "c2ln"})
+
+		if err == nil {
+			t.Fatal("expected error for non-existent device, got nil")
+		}
+		if resp != nil {
+			t.Errorf("expected nil response, got %v", resp)
+		}
+	})
+}
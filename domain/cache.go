@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// verifyCacheKey identifies a verification by hashing the device ID, signed payload, and signature.
+type verifyCacheKey [sha256.Size]byte
+
+type verifyCacheEntry struct {
+	key      verifyCacheKey
+	deviceID string
+	valid    bool
+	expires  time.Time
+}
+
+// verifyCache is a size-bounded LRU of verification outcomes with an optional TTL.
+// Outcomes are immutable for a given key while the device keeps its key pair, so entries are
+// only evicted, or forgotten along with a deleted device.
+type verifyCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[verifyCacheKey]*list.Element
+	now     func() time.Time
+}
+
+func newVerifyCache(size int, ttl time.Duration) *verifyCache {
+	return &verifyCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[verifyCacheKey]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+// newVerifyCacheKey hashes the fields with length prefixes so distinct tuples never collide by concatenation.
+func newVerifyCacheKey(deviceID, signedData, signature string) verifyCacheKey {
+	h := sha256.New()
+	for _, field := range []string{deviceID, signedData, signature} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write([]byte(field))
+	}
+	var key verifyCacheKey
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// get returns the cached outcome for key, if present and not expired.
+func (c *verifyCache) get(key verifyCacheKey) (valid bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return false, false
+	}
+	entry := element.Value.(*verifyCacheEntry)
+	if c.ttl > 0 && c.now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return false, false
+	}
+	c.order.MoveToFront(element)
+	return entry.valid, true
+}
+
+// put stores an outcome for the device, evicting the least recently used entry when full.
+func (c *verifyCache) put(deviceID string, key verifyCacheKey, valid bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if element, exists := c.entries[key]; exists {
+		entry := element.Value.(*verifyCacheEntry)
+		entry.valid = valid
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*verifyCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&verifyCacheEntry{key: key, deviceID: deviceID, valid: valid, expires: expires})
+}
+
+// forget drops every outcome cached for the device.
+func (c *verifyCache) forget(deviceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(*verifyCacheEntry); entry.deviceID == deviceID {
+			c.order.Remove(element)
+			delete(c.entries, entry.key)
+		}
+		element = next
+	}
+}
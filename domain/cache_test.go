@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+type countingVerifier struct {
+	inner signingcrypto.Verifier
+	calls atomic.Int32
+}
+
+func (v *countingVerifier) Verify(signedData []byte, signature []byte) error {
+	v.calls.Add(1)
+	return v.inner.Verify(signedData, signature)
+}
+
+func TestVerifyCache(t *testing.T) {
+	setup := func(t *testing.T, opts ...ServiceOption) (*SignatureDeviceService, *countingVerifier, model.VerifySignatureOptions) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage, opts...)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-cache-001",
+			Label:     "Cache Test",
+			Algorithm: "ECC",
+		})
+		initial := device.LastSignature
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		verifier := &countingVerifier{inner: device.Verifier}
+		device.Verifier = verifier
+		entry := model.VerifySignatureOptions{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: initial}
+		return service, verifier, entry
+	}
+
+	verify := func(t *testing.T, service *SignatureDeviceService, entry model.VerifySignatureOptions) model.VerifyResult {
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: "device-cache-001",
+			Entries:  []model.VerifySignatureOptions{entry},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return results[0]
+	}
+
+	t.Run("second identical verify is served from cache", func(t *testing.T) {
+		service, verifier, entry := setup(t, WithVerifyCache(16, time.Minute))
+
+		first := verify(t, service, entry)
+		second := verify(t, service, entry)
+
+		if !first.Valid || !second.Valid {
+			t.Errorf("expected both verifications to be valid, got %v and %v", first.Valid, second.Valid)
+		}
+		if calls := verifier.calls.Load(); calls != 1 {
+			t.Errorf("expected 1 verifier call, got %d", calls)
+		}
+	})
+
+	t.Run("different inputs miss the cache", func(t *testing.T) {
+		service, verifier, entry := setup(t, WithVerifyCache(16, time.Minute))
+
+		verify(t, service, entry)
+		tampered := entry
+		tampered.Data = "tampered"
+		result := verify(t, service, tampered)
+
+		if result.Valid {
+			t.Error("expected tampered entry to be invalid")
+		}
+		if calls := verifier.calls.Load(); calls != 2 {
+			t.Errorf("expected 2 verifier calls, got %d", calls)
+		}
+
+		verify(t, service, tampered)
+		if calls := verifier.calls.Load(); calls != 2 {
+			t.Errorf("expected cached invalid outcome, got %d verifier calls", calls)
+		}
+	})
+
+	t.Run("cache disabled by default", func(t *testing.T) {
+		service, verifier, entry := setup(t)
+
+		verify(t, service, entry)
+		verify(t, service, entry)
+
+		if calls := verifier.calls.Load(); calls != 2 {
+			t.Errorf("expected 2 verifier calls, got %d", calls)
+		}
+	})
+
+	t.Run("expired entries are verified again", func(t *testing.T) {
+		service, verifier, entry := setup(t, WithVerifyCache(16, time.Nanosecond))
+
+		verify(t, service, entry)
+		time.Sleep(time.Millisecond)
+		verify(t, service, entry)
+
+		if calls := verifier.calls.Load(); calls != 2 {
+			t.Errorf("expected 2 verifier calls, got %d", calls)
+		}
+	})
+}
+
+func TestVerifyCacheEviction(t *testing.T) {
+	cache := newVerifyCache(2, 0)
+	a := newVerifyCacheKey("device", "a", "sig")
+	b := newVerifyCacheKey("device", "b", "sig")
+	c := newVerifyCacheKey("device", "c", "sig")
+
+	cache.put("device", a, true)
+	cache.put("device", b, true)
+	cache.get(a)
+	cache.put("device", c, true)
+
+	if _, ok := cache.get(b); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.get(a); !ok {
+		t.Error("expected recently used entry to be retained")
+	}
+	if _, ok := cache.get(c); !ok {
+		t.Error("expected newest entry to be retained")
+	}
+}
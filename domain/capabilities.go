@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"fmt"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// DeviceCapabilities derives what a device can do right now from its state and the crypto
+// registry. Sign is false for verify-only and locked devices, on read-only replicas and
+// while signing is disabled by feature flag.
+func (s *SignatureDeviceService) DeviceCapabilities(deviceID string) (*model.DeviceCapabilities, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	signingEnabled := !s.readOnly && !s.flags.signingDisabled.Load()
+	capabilities := &model.DeviceCapabilities{
+		Sign:               device.Signer != nil && !device.Locked && signingEnabled,
+		Verify:             device.Verifier != nil || device.PublicKey != nil,
+		VerifyOnly:         device.IsVerifyOnly(),
+		Locked:             device.Locked,
+		Hashes:             []string{},
+		SignatureEncodings: []string{},
+		Formats:            []string{},
+	}
+	if info, ok := signingcrypto.LookupAlgorithm(device.Algorithm); ok {
+		capabilities.Hashes = []string{deviceHash(device)}
+		capabilities.SignatureEncodings = info.SignatureEncodings
+	}
+	if device.Signer != nil {
+		capabilities.Formats = []string{model.SignatureFormatCMS, model.SignatureFormatTagged, model.SignatureFormatMultibase}
+	}
+	return capabilities, nil
+}
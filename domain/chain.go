@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// DefaultSeparator joins the segments of the signed_data chain format when a device
+// does not configure its own.
+const DefaultSeparator = "_"
+
+// allowedSeparators lists the characters a device may use as its chain separator.
+// None of them appear in decimal counters or standard base64, so the first and last
+// separator in signed_data always delimit the counter and last_signature unambiguously.
+const allowedSeparators = "_|:;~.,#!*-"
+
+// Counter encodings a device may use for the counter segment of signed_data.
+const (
+	CounterEncodingDecimal = "decimal" // Plain decimal, the default
+	CounterEncodingPadded  = "padded"  // Decimal zero-padded to CounterPaddedWidth digits
+	CounterEncodingHex     = "hex"     // Lowercase hexadecimal
+)
+
+// CounterPaddedWidth is the fixed width of padded counters, enough for any 64-bit counter.
+const CounterPaddedWidth = 20
+
+// SignedDataParts holds the segments of a "<counter><sep><data><sep><last_signature>" string.
+type SignedDataParts struct {
+	Counter       int64
+	Data          string
+	LastSignature string
+}
+
+// ValidateSeparator checks that sep is a single character from the allowed set.
+func ValidateSeparator(sep string) error {
+	if len(sep) != 1 || !strings.Contains(allowedSeparators, sep) {
+		return fmt.Errorf("invalid separator %q: must be one of %q", sep, allowedSeparators)
+	}
+	return nil
+}
+
+// ValidateCounterEncoding checks that encoding is one of the supported counter encodings.
+func ValidateCounterEncoding(encoding string) error {
+	switch encoding {
+	case CounterEncodingDecimal, CounterEncodingPadded, CounterEncodingHex:
+		return nil
+	}
+	return fmt.Errorf("invalid counter encoding %q: must be one of %q, %q or %q",
+		encoding, CounterEncodingDecimal, CounterEncodingPadded, CounterEncodingHex)
+}
+
+// FormatCounter renders counter in the given encoding; an empty encoding means decimal.
+func FormatCounter(counter int64, encoding string) string {
+	switch encoding {
+	case CounterEncodingPadded:
+		return fmt.Sprintf("%0*d", CounterPaddedWidth, counter)
+	case CounterEncodingHex:
+		return strconv.FormatInt(counter, 16)
+	default:
+		return strconv.FormatInt(counter, 10)
+	}
+}
+
+// parseCounter is the inverse of FormatCounter.
+func parseCounter(raw, encoding string) (int64, error) {
+	switch encoding {
+	case CounterEncodingPadded:
+		if len(raw) != CounterPaddedWidth {
+			return 0, fmt.Errorf("expected %d digits, got %d", CounterPaddedWidth, len(raw))
+		}
+		return strconv.ParseInt(raw, 10, 64)
+	case CounterEncodingHex:
+		return strconv.ParseInt(raw, 16, 64)
+	default:
+		return strconv.ParseInt(raw, 10, 64)
+	}
+}
+
+// deviceSeparator returns the device's chain separator, falling back to the default for
+// devices persisted before separators were configurable.
+func deviceSeparator(device *model.SignatureDevice) string {
+	if device.Separator == "" {
+		return DefaultSeparator
+	}
+	return device.Separator
+}
+
+// deviceCounterEncoding returns the device's counter encoding, falling back to decimal for
+// devices persisted before counter encodings were configurable.
+func deviceCounterEncoding(device *model.SignatureDevice) string {
+	if device.CounterEncoding == "" {
+		return CounterEncodingDecimal
+	}
+	return device.CounterEncoding
+}
+
+// FormatSignedData builds the chained payload that gets signed, with a decimal counter.
+func FormatSignedData(counter int64, data, lastSignature, sep string) string {
+	return FormatSignedDataWithAAD(counter, data, lastSignature, "", sep, CounterEncodingDecimal)
+}
+
+// FormatSignedDataWithAAD builds the chained payload with the counter in the given encoding
+// and additional authenticated data appended as a fourth segment. An empty aad keeps the
+// three-segment format, so devices signing without AAD are unaffected.
+func FormatSignedDataWithAAD(counter int64, data, lastSignature, aad, sep, encoding string) string {
+	signedData := FormatCounter(counter, encoding) + sep + data + sep + lastSignature
+	if aad == "" {
+		return signedData
+	}
+	return signedData + sep + aad
+}
+
+// ExpirySegmentPrefix marks the expiry segment of signed_data, so it can never be produced by
+// a nonce or AAD; neither may start with it.
+const ExpirySegmentPrefix = "exp="
+
+// MaxExpiresIn caps the expires_in of a sign request, in seconds (ten years).
+const MaxExpiresIn = 10 * 365 * 24 * 60 * 60
+
+// ValidateExpiresIn checks that seconds is a usable expires_in; zero means no expiry.
+func ValidateExpiresIn(seconds int64) error {
+	if seconds < 0 || seconds > MaxExpiresIn {
+		return fmt.Errorf("%w: expires_in must be between 0 and %d seconds", ErrInvalidExpiry, MaxExpiresIn)
+	}
+	return nil
+}
+
+// checkBoundSegments rejects a nonce or AAD that starts with ExpirySegmentPrefix and could
+// therefore stand in for an expiry segment.
+func checkBoundSegments(nonce, aad string) error {
+	if strings.HasPrefix(nonce, ExpirySegmentPrefix) {
+		return fmt.Errorf("%w: must not start with %q", ErrInvalidNonce, ExpirySegmentPrefix)
+	}
+	if strings.HasPrefix(aad, ExpirySegmentPrefix) {
+		return fmt.Errorf("%w: must not start with %q", ErrInvalidAAD, ExpirySegmentPrefix)
+	}
+	return nil
+}
+
+// checkLastSignature requires a verified last_signature to be strict standard base64, as every
+// chained signature and initial last_signature is. Base64 never contains a separator, so the
+// segments bound after last_signature, the expiry first, cannot be moved into it.
+func checkLastSignature(lastSignature string) error {
+	if lastSignature == "" {
+		return fmt.Errorf("invalid last_signature: must not be empty")
+	}
+	if _, err := base64.StdEncoding.Strict().DecodeString(lastSignature); err != nil {
+		return fmt.Errorf("invalid last_signature: must be standard base64: %w", err)
+	}
+	return nil
+}
+
+// boundSegments joins the optional trailing segments of signed_data: the expiry as
+// "exp=<unix seconds>", the nonce, then the AAD. Any may be empty, in which case it is left out
+// along with its separator.
+func boundSegments(expiresAt *time.Time, nonce, aad, sep string) string {
+	var segments []string
+	if expiresAt != nil {
+		segments = append(segments, ExpirySegmentPrefix+strconv.FormatInt(expiresAt.Unix(), 10))
+	}
+	for _, segment := range []string{nonce, aad} {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, sep)
+}
+
+// ParseSignedData splits a signed_data string produced with the given separator and counter
+// encoding. The counter ends at the first separator and last_signature starts after the last
+// one, so data may itself contain the separator. It does not handle payloads carrying AAD.
+func ParseSignedData(signedData, sep, encoding string) (*SignedDataParts, error) {
+	first := strings.Index(signedData, sep)
+	last := strings.LastIndex(signedData, sep)
+	if first < 0 || first == last {
+		return nil, fmt.Errorf("malformed signed data: expected at least two %q separators", sep)
+	}
+
+	counter, err := parseCounter(signedData[:first], encoding)
+	if err != nil {
+		return nil, fmt.Errorf("malformed signed data: invalid counter: %w", err)
+	}
+
+	return &SignedDataParts{
+		Counter:       counter,
+		Data:          signedData[first+len(sep) : last],
+		LastSignature: signedData[last+len(sep):],
+	}, nil
+}
@@ -0,0 +1,106 @@
+package domain
+
+import "testing"
+
+func TestValidateSeparator(t *testing.T) {
+	t.Run("accepts allowed separators", func(t *testing.T) {
+		for _, sep := range []string{"_", "|", ":", "~"} {
+			if err := ValidateSeparator(sep); err != nil {
+				t.Errorf("expected %q to be valid, got %v", sep, err)
+			}
+		}
+	})
+
+	t.Run("rejects unsafe separators", func(t *testing.T) {
+		for _, sep := range []string{"", "||", "a", "1", "+", "/", "=", " "} {
+			if err := ValidateSeparator(sep); err == nil {
+				t.Errorf("expected %q to be rejected", sep)
+			}
+		}
+	})
+}
+
+func TestParseSignedData(t *testing.T) {
+	t.Run("round trips formatted data", func(t *testing.T) {
+		signedData := FormatSignedData(7, "invoice_2024_01", "c2lnbmF0dXJl", "|")
+
+		parts, err := ParseSignedData(signedData, "|", CounterEncodingDecimal)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if parts.Counter != 7 {
+			t.Errorf("expected counter 7, got %d", parts.Counter)
+		}
+		if parts.Data != "invoice_2024_01" {
+			t.Errorf("expected data 'invoice_2024_01', got '%s'", parts.Data)
+		}
+		if parts.LastSignature != "c2lnbmF0dXJl" {
+			t.Errorf("expected last signature 'c2lnbmF0dXJl', got '%s'", parts.LastSignature)
+		}
+	})
+
+	t.Run("data containing the separator", func(t *testing.T) {
+		parts, err := ParseSignedData("3_a_b_c_ZGV2aWNl", "_", CounterEncodingDecimal)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if parts.Data != "a_b_c" {
+			t.Errorf("expected data 'a_b_c', got '%s'", parts.Data)
+		}
+	})
+
+	t.Run("missing separators", func(t *testing.T) {
+		if _, err := ParseSignedData("3_data", "_", CounterEncodingDecimal); err == nil {
+			t.Error("expected error for single separator")
+		}
+	})
+
+	t.Run("invalid counter", func(t *testing.T) {
+		if _, err := ParseSignedData("x_data_sig", "_", CounterEncodingDecimal); err == nil {
+			t.Error("expected error for non-numeric counter")
+		}
+	})
+}
+
+func TestCounterEncoding(t *testing.T) {
+	tests := []struct {
+		encoding string
+		counter  int64
+		expected string
+	}{
+		{CounterEncodingDecimal, 42, "42"},
+		{CounterEncodingPadded, 42, "00000000000000000042"},
+		{CounterEncodingHex, 42, "2a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding+" round trips through the parser", func(t *testing.T) {
+			signedData := FormatSignedDataWithAAD(tt.counter, "data", "c2ln", "", "_", tt.encoding)
+			if expected := tt.expected + "_data_c2ln"; signedData != expected {
+				t.Errorf("expected %q, got %q", expected, signedData)
+			}
+
+			parts, err := ParseSignedData(signedData, "_", tt.encoding)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if parts.Counter != tt.counter {
+				t.Errorf("expected counter %d, got %d", tt.counter, parts.Counter)
+			}
+		})
+	}
+
+	t.Run("rejects unknown encodings", func(t *testing.T) {
+		if err := ValidateCounterEncoding("octal"); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("padded parser rejects the wrong width", func(t *testing.T) {
+		if _, err := ParseSignedData("42_data_c2ln", "_", CounterEncodingPadded); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
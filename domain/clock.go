@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Clock supplies the current time to the service, so timestamps can be controlled in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock reads the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestServiceClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("created and signed timestamps come from the clock", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-clock-001", Algorithm: "ECC"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		response := device.ToResponse()
+		if response.CreatedAt == nil || !response.CreatedAt.Equal(start) {
+			t.Errorf("expected created_at %v, got %v", start, response.CreatedAt)
+		}
+
+		clock.Advance(90 * time.Second)
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		response = device.ToResponse()
+		signedAt := start.Add(90 * time.Second)
+		if response.LastSignedAt == nil || !response.LastSignedAt.Equal(signedAt) {
+			t.Errorf("expected last_signed_at %v, got %v", signedAt, response.LastSignedAt)
+		}
+		history, _ := service.SignatureHistory(device.ID)
+		if len(history) != 1 || !history[0].SignedAt.Equal(signedAt) {
+			t.Errorf("expected history timestamp %v, got %+v", signedAt, history)
+		}
+	})
+
+	t.Run("attestation timestamp comes from the clock", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-clock-002", Algorithm: "ECC"})
+
+		resp, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "c2ln"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !resp.Timestamp.Equal(start) {
+			t.Errorf("expected timestamp %v, got %v", start, resp.Timestamp)
+		}
+	})
+
+	t.Run("throughput windows follow the clock", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-clock-003", Algorithm: "ECC"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		clock.Advance(2 * time.Minute)
+		count, _ := service.SignatureThroughput(device.ID, time.Minute)
+		if count != 0 {
+			t.Errorf("expected 0 signatures in the last minute, got %d", count)
+		}
+	})
+
+	t.Run("verify cache expiry follows the clock", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithVerifyCache(10, time.Minute), WithClock(clock))
+		key := newVerifyCacheKey("device", "data", "sig")
+		service.verifyCache.put("device", key, true)
+
+		if _, ok := service.verifyCache.get(key); !ok {
+			t.Fatal("expected cached entry before expiry")
+		}
+		clock.Advance(2 * time.Minute)
+		if _, ok := service.verifyCache.get(key); ok {
+			t.Error("expected entry to expire once the clock passes its ttl")
+		}
+	})
+}
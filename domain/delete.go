@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// DeleteDevices removes the devices with the given IDs on behalf of tenantID and reports, in
+// request order, whether each was deleted or not found. Devices of other tenants are reported
+// as not found; an empty tenantID is unscoped. The whole batch runs under the signing lock, so
+// no signature is produced on a device while it is being deleted. Each deletion is published as
+// an EventTypeDelete event. A storage failure aborts the batch and returns the results so far
+// along with the error.
+func (s *SignatureDeviceService) DeleteDevices(tenantID string, ids []string) ([]model.DeleteResult, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]model.DeleteResult, 0, len(ids))
+	for _, id := range ids {
+		result := model.DeleteResult{ID: id, Status: model.DeleteStatusNotFound}
+		_, err := s.GetTenantDevice(tenantID, id)
+		if err == nil {
+			err = s.storage.Delete(id)
+		}
+		switch {
+		case err == nil:
+			result.Status = model.DeleteStatusDeleted
+			s.forgetDevice(id)
+			s.publish(SignEvent{Type: EventTypeDelete, DeviceID: id, Timestamp: s.clock.Now()})
+		case !errors.Is(err, ErrDeviceNotFound):
+			return results, fmt.Errorf("failed to delete device %s: %w", id, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// forgetDevice drops the in-memory signing state and cached verifications of a deleted device,
+// so a device later created with the same ID starts afresh and is never judged by the old key.
+// Callers must hold s.mu.
+func (s *SignatureDeviceService) forgetDevice(id string) {
+	delete(s.nonces, id)
+	delete(s.quotas, id)
+	if s.operations != nil {
+		s.operations.forget(id)
+	}
+	if s.verifyCache != nil {
+		s.verifyCache.forget(id)
+	}
+}
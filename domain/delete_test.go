@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestDeleteDevices(t *testing.T) {
+	t.Run("reports each ID in request order", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-001", Algorithm: "ECC"})
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-002", Algorithm: "ECC"})
+
+		results, err := service.DeleteDevices("", []string{"device-del-002", "missing", "device-del-002"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		statuses := []string{model.DeleteStatusDeleted, model.DeleteStatusNotFound, model.DeleteStatusNotFound}
+		for i, result := range results {
+			if result.Status != statuses[i] {
+				t.Errorf("expected %s for result %d, got %+v", statuses[i], i, result)
+			}
+		}
+		if _, err := storage.GetDevice("device-del-002"); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected device-del-002 to be deleted, got %v", err)
+		}
+		if _, err := storage.GetDevice("device-del-001"); err != nil {
+			t.Errorf("expected device-del-001 to remain, got %v", err)
+		}
+	})
+
+	t.Run("deletions are published", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-005", Algorithm: "ECC"})
+		events := make(chan SignEvent, 4)
+		defer service.Subscribe(events)()
+
+		if _, err := service.DeleteDevices("", []string{"device-del-005", "missing"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		select {
+		case event := <-events:
+			if event.Type != EventTypeDelete || event.DeviceID != "device-del-005" {
+				t.Errorf("expected a delete event for device-del-005, got %+v", event)
+			}
+		default:
+			t.Fatal("expected a delete event")
+		}
+		if len(events) != 0 {
+			t.Errorf("expected no event for the missing device, got %+v", <-events)
+		}
+	})
+
+	t.Run("recreated device does not replay operations", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-003", Algorithm: "ECC"})
+		opts := model.SignDataOptions{DeviceID: "device-del-003", Data: "payload", OperationID: "op-1"}
+		service.SignData(opts)
+
+		service.DeleteDevices("", []string{"device-del-003"})
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-003", Algorithm: "ECC"})
+		service.SignData(opts)
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected the recreated device to sign, got counter %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("recreated device does not reuse cached verifications", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithVerifyCache(16, 0))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-004", Algorithm: "ECC"})
+		resp, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		entries := []model.VerifySignatureOptions{
+			{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: device.History[0].LastSignature},
+		}
+		if results, _ := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: device.ID, Entries: entries}); !results[0].Valid {
+			t.Fatalf("expected the signature to verify, got %+v", results[0])
+		}
+
+		service.DeleteDevices("", []string{device.ID})
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-del-004", Algorithm: "ECC"})
+		if results, _ := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: device.ID, Entries: entries}); results[0].Valid {
+			t.Errorf("expected the old key's signature to be invalid for the new device, got %+v", results[0])
+		}
+	})
+
+	t.Run("read-only service refuses", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithReadOnly(true))
+		if _, err := service.DeleteDevices("", []string{"any"}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("expected ErrReadOnly, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package domain
+
+import "errors"
+
+// ErrDeviceNotFound is returned when no device exists for the requested ID.
+var ErrDeviceNotFound = errors.New("device not found")
+
+// ErrDeviceExists is returned by storage when saving a device whose ID is already taken.
+var ErrDeviceExists = errors.New("device already exists")
+
+// ErrSigningCapacity is returned when no signing slot frees up within the configured wait.
+var ErrSigningCapacity = errors.New("signing capacity exhausted")
+
+// ErrUnsupportedFormat is returned when a sign request asks for an unknown output format.
+var ErrUnsupportedFormat = errors.New("unsupported signature format")
+
+// ErrInvalidDeviceID is returned when a device ID does not match the configured ID policy.
+var ErrInvalidDeviceID = errors.New("invalid device ID")
+
+// ErrInvalidLabel is returned when a device label contains characters the service rejects.
+var ErrInvalidLabel = errors.New("invalid device label")
+
+// ErrReadOnly is returned by write operations on a service running in read-only mode.
+var ErrReadOnly = errors.New("service is read-only")
+
+// ErrDataTooLarge is returned when sign data, AAD, nonce or purpose exceeds the maximum length.
+var ErrDataTooLarge = errors.New("data exceeds maximum length")
+
+// ErrEmptyData is returned when empty sign data is rejected by the service configuration.
+var ErrEmptyData = errors.New("data must not be empty")
+
+// ErrInvalidPublicKey is returned when an imported public key cannot be parsed.
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// ErrInvalidJWK is returned when a JSON Web Key imported as a device is malformed or unsupported.
+var ErrInvalidJWK = errors.New("invalid JWK")
+
+// ErrVerifyOnly is returned when a signing operation targets a device without a private key.
+var ErrVerifyOnly = errors.New("device is verify-only")
+
+// ErrUnsupportedWindow is returned when a throughput window is not one of the supported sizes.
+var ErrUnsupportedWindow = errors.New("unsupported throughput window")
+
+// ErrDeviceInUse is returned by maintenance operations that require a device that has not signed yet.
+var ErrDeviceInUse = errors.New("device has already signed")
+
+// ErrDeviceLocked is returned when a signing operation targets a locked device.
+var ErrDeviceLocked = errors.New("device is locked")
+
+// ErrKeyAlgorithmMismatch is returned when a device's keys do not belong to its declared algorithm.
+var ErrKeyAlgorithmMismatch = errors.New("key type does not match algorithm")
+
+// ErrDuplicateData is returned when a device that rejects duplicates is asked to re-sign data.
+var ErrDuplicateData = errors.New("data has already been signed by this device")
+
+// ErrKeyGenQueueFull is returned by CreateDevice when the key generation pool cannot take more work.
+var ErrKeyGenQueueFull = errors.New("key generation queue is full")
+
+// ErrSigningDisabled is returned by signing operations while the signing feature flag is off.
+var ErrSigningDisabled = errors.New("signing is disabled")
+
+// ErrDeviceCreationDisabled is returned by CreateDevice while the device creation feature flag is off.
+var ErrDeviceCreationDisabled = errors.New("device creation is disabled")
+
+// ErrInvalidNonce is returned when a nonce is missing, malformed or unexpected by the device.
+var ErrInvalidNonce = errors.New("invalid nonce")
+
+// ErrNonceReused is returned when a device that requires nonces is sent a nonce it has seen.
+var ErrNonceReused = errors.New("nonce has already been used by this device")
+
+// ErrCounterMismatch is returned when a sign request's expected counter differs from the device's.
+var ErrCounterMismatch = errors.New("device counter does not match expected counter")
+
+// ErrSignFailed is returned when the device's signer fails to produce a signature.
+var ErrSignFailed = errors.New("failed to sign data")
+
+// ErrStorageFailure is returned when a signed device state could not be persisted.
+var ErrStorageFailure = errors.New("failed to update device")
+
+// ErrInvalidHash is returned when a device's hash is unknown or unusable with its algorithm or key.
+var ErrInvalidHash = errors.New("invalid hash")
+
+// ErrInvalidQuota is returned when a device's signing quota or its window is invalid.
+var ErrInvalidQuota = errors.New("invalid signing quota")
+
+// ErrQuotaExceeded is returned when a device has used up its signing quota for the current window.
+var ErrQuotaExceeded = errors.New("signing quota exceeded")
+
+// ErrSignAborted is returned when a sign request's context ends before the signature is committed.
+var ErrSignAborted = errors.New("signing aborted")
+
+// ErrInvalidOperationID is returned when a sign request's operation ID is too long or malformed.
+var ErrInvalidOperationID = errors.New("invalid operation ID")
+
+// ErrOperationConflict is returned when an operation ID is reused for a different sign request.
+var ErrOperationConflict = errors.New("operation ID has already been used for a different request")
+
+// ErrInvalidIntrospection is returned when an introspection request is incomplete or undecodable.
+var ErrInvalidIntrospection = errors.New("invalid introspection request")
+
+// ErrSignDenied is returned when the pre-sign hook refuses a sign request.
+var ErrSignDenied = errors.New("signing denied by policy")
+
+// ErrInvalidExpiry is returned when a sign request's expires_in is negative or too large.
+var ErrInvalidExpiry = errors.New("invalid expiry")
+
+// ErrInvalidAAD is returned when a sign request's AAD could be mistaken for another segment.
+var ErrInvalidAAD = errors.New("invalid aad")
+
+// ErrSignatureExpired is reported for a signature verified after the expiry bound into it.
+var ErrSignatureExpired = errors.New("signature expired")
+
+// ErrCounterNotInHistory is returned when a device's history holds no record for a counter.
+var ErrCounterNotInHistory = errors.New("counter not found in history")
@@ -0,0 +1,45 @@
+package domain
+
+import "sync"
+
+// Error categories tracked per algorithm by the service.
+const (
+	ErrorCategoryKeyGen    = "keygen_failure"
+	ErrorCategorySign      = "sign_failure"
+	ErrorCategoryStorage   = "storage_failure"
+	ErrorCategoryReconcile = "reconcile_discrepancy"
+)
+
+// errorCounters counts failures by algorithm and error category.
+type errorCounters struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int64
+}
+
+func (c *errorCounters) inc(algorithm, category string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[string]map[string]int64)
+	}
+	if c.counts[algorithm] == nil {
+		c.counts[algorithm] = make(map[string]int64)
+	}
+	c.counts[algorithm][category]++
+}
+
+// snapshot returns a copy of the counters keyed by algorithm, then category.
+func (c *errorCounters) snapshot() map[string]map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make(map[string]map[string]int64, len(c.counts))
+	for algorithm, categories := range c.counts {
+		snapshot[algorithm] = make(map[string]int64, len(categories))
+		for category, count := range categories {
+			snapshot[algorithm][category] = count
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+type failingSigner struct{}
+
+func (failingSigner) Sign([]byte) ([]byte, error) {
+	return nil, errors.New("signer failure")
+}
+
+func TestErrorBreakdown(t *testing.T) {
+	t.Run("storage failures are counted per algorithm", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-errors-001", Algorithm: "ECC"})
+
+		storage.updateErr = errors.New("update failure")
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		storage.updateErr = nil
+
+		storage.saveErr = errors.New("save failure")
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-errors-002", Algorithm: "RSA"})
+		storage.saveErr = nil
+
+		stats, err := service.SignatureStats(0)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := stats.Errors["ECC"][ErrorCategoryStorage]; got != 1 {
+			t.Errorf("expected 1 ECC storage failure, got %d", got)
+		}
+		if got := stats.Errors["RSA"][ErrorCategoryStorage]; got != 1 {
+			t.Errorf("expected 1 RSA storage failure, got %d", got)
+		}
+	})
+
+	t.Run("sign failures are counted", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-errors-003", Algorithm: "RSA"})
+		device.Signer = failingSigner{}
+
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err == nil {
+			t.Fatal("expected sign error, got nil")
+		}
+
+		stats, _ := service.SignatureStats(0)
+		if got := stats.Errors["RSA"][ErrorCategorySign]; got != 1 {
+			t.Errorf("expected 1 RSA sign failure, got %d", got)
+		}
+		if got := stats.Errors["RSA"][ErrorCategoryStorage]; got != 0 {
+			t.Errorf("expected no RSA storage failures, got %d", got)
+		}
+	})
+}
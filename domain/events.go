@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// Event types carried by SignEvent.
+const (
+	EventTypeSign   = "sign"
+	EventTypeCreate = "create"
+	EventTypeDelete = "delete"
+)
+
+// SignEvent describes a device lifecycle event, delivered to in-process subscribers.
+// For signatures, Counter is the counter value embedded in the signed data.
+type SignEvent struct {
+	Type      string
+	DeviceID  string
+	Counter   int64
+	Timestamp time.Time
+}
+
+// Subscribe registers ch to receive a SignEvent after every successful signature, device
+// creation and device deletion.
+// Delivery never blocks signing: events are dropped for subscribers whose channel is full,
+// so callers wanting every event should use a buffered channel and drain it promptly.
+// The returned function removes the subscription.
+func (s *SignatureDeviceService) Subscribe(ch chan<- SignEvent) func() {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan<- SignEvent]struct{})
+	}
+	s.subscribers[ch] = struct{}{}
+
+	return func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.subscribers, ch)
+	}
+}
+
+// publish fans event out to all subscribers without blocking.
+func (s *SignatureDeviceService) publish(event SignEvent) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
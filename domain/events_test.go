@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Run("delivers event after signing", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-event-001", Algorithm: "ECC"})
+
+		events := make(chan SignEvent, 1)
+		service.Subscribe(events)
+
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		select {
+		case event := <-events:
+			if event.Type != EventTypeSign {
+				t.Errorf("expected type %s, got %s", EventTypeSign, event.Type)
+			}
+			if event.DeviceID != device.ID {
+				t.Errorf("expected device ID %s, got %s", device.ID, event.DeviceID)
+			}
+			if event.Counter != 0 {
+				t.Errorf("expected counter 0, got %d", event.Counter)
+			}
+			if event.Timestamp.IsZero() {
+				t.Error("expected timestamp to be set")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected sign event to be delivered")
+		}
+	})
+
+	t.Run("slow subscriber does not block signing", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-event-002", Algorithm: "ECC"})
+
+		blocked := make(chan SignEvent)
+		service.Subscribe(blocked)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 3; i++ {
+				service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("signing blocked on a full subscriber")
+		}
+	})
+
+	t.Run("unsubscribe stops delivery", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-event-003", Algorithm: "ECC"})
+
+		events := make(chan SignEvent, 1)
+		unsubscribe := service.Subscribe(events)
+		unsubscribe()
+
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		if len(events) != 0 {
+			t.Error("expected no event after unsubscribe")
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSignDataExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := newFakeClock(start)
+	service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-expiry-001", Algorithm: "ECC"})
+
+	resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "ticket", ExpiresIn: 3600})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expiresAt := start.Add(time.Hour)
+	if resp.ExpiresAt == nil || !resp.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("expected expires_at %v, got %v", expiresAt, resp.ExpiresAt)
+	}
+	if !strings.HasSuffix(resp.SignedData, "_exp="+strconv.FormatInt(expiresAt.Unix(), 10)) {
+		t.Errorf("expected the expiry bound into signed data, got %q", resp.SignedData)
+	}
+
+	verifyEntry := func(entry model.VerifySignatureOptions) model.VerifyResult {
+		entry.Data, entry.Signature, entry.LastSignature = "ticket", resp.Signature, device.History[0].LastSignature
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: device.ID, Entries: []model.VerifySignatureOptions{entry}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return results[0]
+	}
+	verify := func(expires time.Time) model.VerifyResult {
+		return verifyEntry(model.VerifySignatureOptions{ExpiresAt: &expires})
+	}
+
+	t.Run("signature before expiry is valid", func(t *testing.T) {
+		clock.Advance(59 * time.Minute)
+		if result := verify(expiresAt); !result.Valid {
+			t.Errorf("expected a valid signature, got %+v", result)
+		}
+	})
+
+	t.Run("altered expiry is invalid", func(t *testing.T) {
+		result := verify(expiresAt.Add(24 * time.Hour))
+		if result.Valid || result.Reason != "" {
+			t.Errorf("expected an invalid signature without a reason, got %+v", result)
+		}
+	})
+
+	t.Run("signature past expiry is expired", func(t *testing.T) {
+		clock.Advance(time.Minute)
+		result := verify(expiresAt)
+		if result.Valid || result.Reason != model.VerifyReasonExpired {
+			t.Errorf("expected an expired signature, got %+v", result)
+		}
+	})
+
+	t.Run("expiry moved into the aad or nonce is rejected", func(t *testing.T) {
+		moved := ExpirySegmentPrefix + strconv.FormatInt(expiresAt.Unix(), 10)
+		for _, entry := range []model.VerifySignatureOptions{{AAD: moved}, {Nonce: moved}} {
+			if result := verifyEntry(entry); result.Valid {
+				t.Errorf("expected the expired signature to stay invalid, got %+v", result)
+			}
+		}
+		bare := strconv.FormatInt(expiresAt.Unix(), 10)
+		if result := verifyEntry(model.VerifySignatureOptions{AAD: bare}); result.Valid {
+			t.Errorf("expected a bare expiry in the aad to be invalid, got %+v", result)
+		}
+	})
+
+	t.Run("history keeps the expiry for self-verification", func(t *testing.T) {
+		report, err := service.SelfVerify(device.ID)
+		if err != nil || !report.OK {
+			t.Errorf("expected the chain to self-verify, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("negative or oversized expiry is rejected", func(t *testing.T) {
+		for _, expiresIn := range []int64{-1, MaxExpiresIn + 1, 1 << 62} {
+			_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "ticket", ExpiresIn: expiresIn})
+			if !errors.Is(err, ErrInvalidExpiry) {
+				t.Errorf("%d: expected ErrInvalidExpiry, got %v", expiresIn, err)
+			}
+		}
+	})
+
+	t.Run("aad resembling an expiry is rejected", func(t *testing.T) {
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "ticket", AAD: "exp=1"})
+		if !errors.Is(err, ErrInvalidAAD) {
+			t.Errorf("expected ErrInvalidAAD, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,15 @@
+package domain
+
+import model "github.com/bayuhutajulu/signing-service/model"
+
+// FilterDevices returns the devices matching every criterion set in filter, in their
+// original order.
+func FilterDevices(devices []*model.SignatureDevice, filter model.DeviceFilter) []*model.SignatureDevice {
+	filtered := []*model.SignatureDevice{}
+	for _, device := range devices {
+		if filter.Matches(device) {
+			filtered = append(filtered, device)
+		}
+	}
+	return filtered
+}
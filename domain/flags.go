@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"sync/atomic"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// featureFlags holds the runtime toggles operators flip during incidents. They are stored as
+// "disabled" so the zero value leaves every feature enabled.
+type featureFlags struct {
+	signingDisabled  atomic.Bool
+	creationDisabled atomic.Bool
+}
+
+// FeatureFlags returns the current runtime feature flags.
+func (s *SignatureDeviceService) FeatureFlags() model.FeatureFlags {
+	return model.FeatureFlags{
+		Signing:        !s.flags.signingDisabled.Load(),
+		DeviceCreation: !s.flags.creationDisabled.Load(),
+	}
+}
+
+// UpdateFeatureFlags applies the flags set in update and returns the resulting flags. While
+// signing is disabled, SignData and AttestSignature fail with ErrSigningDisabled; while device
+// creation is disabled, CreateDevice fails with ErrDeviceCreationDisabled. Flags live in memory
+// only and reset on restart.
+func (s *SignatureDeviceService) UpdateFeatureFlags(update model.FeatureFlagsUpdate) model.FeatureFlags {
+	if update.Signing != nil {
+		s.flags.signingDisabled.Store(!*update.Signing)
+	}
+	if update.DeviceCreation != nil {
+		s.flags.creationDisabled.Store(!*update.DeviceCreation)
+	}
+	return s.FeatureFlags()
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestDeviceHashPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     model.CreateDeviceOptions
+		expected string
+	}{
+		{"P-256 defaults to SHA-256", model.CreateDeviceOptions{Algorithm: "ECC", Curve: "P-256"}, signingcrypto.HashSHA256},
+		{"P-384 defaults to SHA-384", model.CreateDeviceOptions{Algorithm: "ECC", Curve: "P-384"}, signingcrypto.HashSHA384},
+		{"RSA defaults to SHA-256", model.CreateDeviceOptions{Algorithm: "RSA"}, signingcrypto.HashSHA256},
+		{"explicit hash overrides the policy", model.CreateDeviceOptions{Algorithm: "ECC", Curve: "P-384", Hash: signingcrypto.HashSHA512}, signingcrypto.HashSHA512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewSignatureDeviceService(newMockStorage())
+			tt.opts.ID = "device-hash"
+			device, err := service.CreateDevice(tt.opts)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if device.Hash != tt.expected {
+				t.Errorf("expected hash %s, got %s", tt.expected, device.Hash)
+			}
+
+			resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			hash, _ := signingcrypto.ParseHash(tt.expected)
+			verifier, _ := signingcrypto.NewHashVerifier(device.PublicKey, hash)
+			signature, _ := signingcrypto.DecodeSignature(resp.Signature)
+			if err := verifier.Verify([]byte(resp.SignedData), signature); err != nil {
+				t.Errorf("expected signature over %s digest to verify, got %v", tt.expected, err)
+			}
+
+			results, err := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: device.ID, Entries: []model.VerifySignatureOptions{
+				{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: device.History[0].LastSignature},
+			}})
+			if err != nil || !results[0].Valid {
+				t.Errorf("expected the device to verify its own signature, got %+v, %v", results, err)
+			}
+		})
+	}
+
+	t.Run("unusable hashes are rejected", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		for _, opts := range []model.CreateDeviceOptions{
+			{ID: "unknown", Algorithm: "ECC", Hash: "MD5"},
+			{ID: "small-rsa", Algorithm: "RSA", KeySize: 512, Hash: signingcrypto.HashSHA512},
+		} {
+			if _, err := service.CreateDevice(opts); !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("%s: expected ErrInvalidHash, got %v", opts.ID, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// hasSignedData reports whether a regular signature over data is in the device's history.
+// The newest records are checked first, as replays usually follow the original closely.
+// Records pruned by history retention are no longer detected.
+func hasSignedData(device *model.SignatureDevice, data string) bool {
+	for i := len(device.History) - 1; i >= 0; i-- {
+		record := device.History[i]
+		if record.Type == model.RecordTypeSignature && record.Data == data {
+			return true
+		}
+	}
+	return false
+}
+
+// historyLimit returns the device's history retention, falling back to the service-wide
+// limit. Zero means unbounded.
+func (s *SignatureDeviceService) historyLimit(device *model.SignatureDevice) int {
+	if device.MaxHistoryEntries > 0 {
+		return device.MaxHistoryEntries
+	}
+	return s.maxHistoryEntries
+}
+
+// pruneHistory drops the oldest records beyond the device's retention limit. Pruned records
+// are lost for auditing; the counter and last_signature are unaffected. Reslicing keeps
+// previously returned histories intact, and append reallocates once the backing array fills,
+// releasing the pruned prefix. Callers must hold s.mu.
+func (s *SignatureDeviceService) pruneHistory(device *model.SignatureDevice) {
+	limit := s.historyLimit(device)
+	if limit <= 0 || len(device.History) <= limit {
+		return
+	}
+	excess := len(device.History) - limit
+	device.History = device.History[excess:]
+	device.PrunedHistory += int64(excess)
+}
+
+// SignatureHistory returns the device's signature history in counter order. The returned
+// slice shares storage with the device but is capped at its current length, so records
+// appended by later signatures never show through and callers can iterate without a lock.
+func (s *SignatureDeviceService) SignatureHistory(deviceID string) ([]model.SignatureRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	history := device.History
+	return history[:len(history):len(history)], nil
+}
+
+// FilterByPurpose returns the records labeled with purpose, in their original order.
+func FilterByPurpose(records []model.SignatureRecord, purpose string) []model.SignatureRecord {
+	filtered := []model.SignatureRecord{}
+	for _, record := range records {
+		if record.Purpose == purpose {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// SupportedThroughputWindows lists the trailing windows accepted by SignatureThroughput.
+var SupportedThroughputWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 24 * time.Hour}
+
+// SignatureThroughput counts the device's signatures made within the trailing window.
+// The window must be one of SupportedThroughputWindows.
+func (s *SignatureDeviceService) SignatureThroughput(deviceID string, window time.Duration) (int, error) {
+	supported := false
+	for _, w := range SupportedThroughputWindows {
+		if window == w {
+			supported = true
+		}
+	}
+	if !supported {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedWindow, window)
+	}
+
+	history, err := s.SignatureHistory(deviceID)
+	if err != nil {
+		return 0, err
+	}
+
+	since := s.clock.Now().Add(-window)
+	first := sort.Search(len(history), func(i int) bool {
+		return history[i].SignedAt.After(since)
+	})
+	return len(history) - first, nil
+}
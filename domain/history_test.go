@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSignatureThroughput(t *testing.T) {
+	t.Run("counts signatures in the trailing window", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-throughput-001", Algorithm: "ECC"})
+		for i := 0; i < 5; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		}
+		device.History[0].SignedAt = time.Now().Add(-10 * time.Minute)
+		device.History[1].SignedAt = time.Now().Add(-2 * time.Minute)
+
+		count, err := service.SignatureThroughput(device.ID, time.Minute)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if count != 3 {
+			t.Errorf("expected 3 signatures in 1m, got %d", count)
+		}
+
+		count, _ = service.SignatureThroughput(device.ID, 5*time.Minute)
+		if count != 4 {
+			t.Errorf("expected 4 signatures in 5m, got %d", count)
+		}
+	})
+
+	t.Run("idle device reports zero", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-throughput-002", Algorithm: "ECC"})
+
+		count, err := service.SignatureThroughput(device.ID, time.Hour)
+
+		if err != nil || count != 0 {
+			t.Errorf("expected 0 and no error, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("unsupported window", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-throughput-003", Algorithm: "ECC"})
+
+		if _, err := service.SignatureThroughput(device.ID, 42*time.Second); !errors.Is(err, ErrUnsupportedWindow) {
+			t.Errorf("expected ErrUnsupportedWindow, got %v", err)
+		}
+	})
+}
+
+func TestHistoryRetention(t *testing.T) {
+	t.Run("device limit caps history while the counter keeps incrementing", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-retention-001", Algorithm: "ECC", MaxHistoryEntries: 3})
+
+		var last *model.SignDataResponse
+		for i := 0; i < 10; i++ {
+			last, _ = service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		}
+
+		history, _ := service.SignatureHistory(device.ID)
+		if len(history) != 3 {
+			t.Fatalf("expected 3 retained records, got %d", len(history))
+		}
+		if history[0].Counter != 7 || history[2].Counter != 9 {
+			t.Errorf("expected counters 7..9 retained, got %d..%d", history[0].Counter, history[2].Counter)
+		}
+		if device.SignatureCounter != 10 {
+			t.Errorf("expected counter 10, got %d", device.SignatureCounter)
+		}
+		if device.LastSignature != last.Signature {
+			t.Error("expected last_signature to match the latest signature")
+		}
+		if device.PrunedHistory != 7 {
+			t.Errorf("expected 7 pruned records, got %d", device.PrunedHistory)
+		}
+		if report, _ := service.VerifyCounterIntegrity(device.ID); !report.OK {
+			t.Errorf("expected pruned counters not to be reported missing, got %v", report.Missing)
+		}
+	})
+
+	t.Run("service limit applies to devices without their own", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithMaxHistoryEntries(2))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-retention-002", Algorithm: "ECC"})
+		custom, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-retention-003", Algorithm: "ECC", MaxHistoryEntries: 4})
+
+		for i := 0; i < 5; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			service.SignData(model.SignDataOptions{DeviceID: custom.ID, Data: "payload"})
+		}
+
+		if len(device.History) != 2 {
+			t.Errorf("expected 2 retained records, got %d", len(device.History))
+		}
+		if len(custom.History) != 4 {
+			t.Errorf("expected 4 retained records, got %d", len(custom.History))
+		}
+	})
+
+	t.Run("returned history is not affected by later pruning", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-retention-004", Algorithm: "ECC", MaxHistoryEntries: 2})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "second"})
+
+		history, _ := service.SignatureHistory(device.ID)
+		for i := 0; i < 5; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "later"})
+		}
+
+		if history[0].Data != "first" || history[1].Data != "second" {
+			t.Errorf("expected earlier snapshot to be unchanged, got %q and %q", history[0].Data, history[1].Data)
+		}
+	})
+
+	t.Run("negative limit is rejected", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-retention-005", Algorithm: "ECC", MaxHistoryEntries: -1}); err == nil {
+			t.Error("expected error for negative limit, got nil")
+		}
+	})
+}
+
+func TestVerifyAt(t *testing.T) {
+	service := NewSignatureDeviceService(newMockStorage(), WithMaxHistoryEntries(3))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-verify-at-001", Algorithm: "ECC"})
+	signatures := make([]string, 5)
+	for i := range signatures {
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", AAD: "audience"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		signatures[i] = resp.Signature
+	}
+
+	t.Run("historical signature verifies by counter alone", func(t *testing.T) {
+		result, err := service.VerifyAt(model.VerifyAtOptions{DeviceID: device.ID, Counter: 3, Data: "payload", Signature: signatures[3]})
+		if err != nil || !result.Valid {
+			t.Errorf("expected a valid signature, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("signature from another counter is invalid", func(t *testing.T) {
+		result, err := service.VerifyAt(model.VerifyAtOptions{DeviceID: device.ID, Counter: 3, Data: "payload", Signature: signatures[4]})
+		if err != nil || result.Valid {
+			t.Errorf("expected an invalid signature, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("pruned counter is not found", func(t *testing.T) {
+		_, err := service.VerifyAt(model.VerifyAtOptions{DeviceID: device.ID, Counter: 1, Data: "payload", Signature: signatures[1]})
+		if !errors.Is(err, ErrCounterNotInHistory) {
+			t.Errorf("expected ErrCounterNotInHistory, got %v", err)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		_, err := service.VerifyAt(model.VerifyAtOptions{DeviceID: "missing", Counter: 0})
+		if !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"log"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// PreSignHook decides whether a device may sign data, e.g. to enforce content-based
+// authorization. Returning an error refuses the signature.
+type PreSignHook func(deviceID string, data []byte) error
+
+// WithPreSignHook installs a hook consulted by SignData and AttestSignature before anything is
+// signed; an attestation passes the external signature as data. A refusal fails the call with
+// ErrSignDenied wrapping the hook's error, leaving the counter untouched. The hook runs before
+// the signing lock is taken, so a slow hook delays only its own request.
+func WithPreSignHook(hook PreSignHook) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.preSignHook = hook
+	}
+}
+
+// PostSignHook is told about each new signature, e.g. to log it externally or notarize it.
+// It cannot affect the response: a returned error is only logged.
+type PostSignHook func(deviceID string, resp *model.SignDataResponse) error
+
+// WithPostSignHook installs a hook called by SignData and AttestSignature after a new signature
+// is committed and the signing lock is released. Errors and panics of the hook are logged and do not fail the
+// call. Responses replayed for a retried operation ID do not call the hook again.
+func WithPostSignHook(hook PostSignHook) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.postSignHook = hook
+	}
+}
+
+// checkPreSignHook runs the pre-sign hook, if any.
+func (s *SignatureDeviceService) checkPreSignHook(deviceID, data string) error {
+	if s.preSignHook == nil {
+		return nil
+	}
+	if err := s.preSignHook(deviceID, []byte(data)); err != nil {
+		return fmt.Errorf("%w: %w", ErrSignDenied, err)
+	}
+	return nil
+}
+
+// runPostSignHook runs the post-sign hook, if any, on a copy of resp so the hook cannot alter
+// what the caller receives.
+func (s *SignatureDeviceService) runPostSignHook(deviceID string, resp *model.SignDataResponse) {
+	if s.postSignHook == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("post-sign hook panicked for device %s: %v", deviceID, r)
+		}
+	}()
+	copied := *resp
+	if err := s.postSignHook(deviceID, &copied); err != nil {
+		log.Printf("post-sign hook failed for device %s: %v", deviceID, err)
+	}
+}
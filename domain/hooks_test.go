@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestPreSignHook(t *testing.T) {
+	var calls []string
+	hook := func(deviceID string, data []byte) error {
+		calls = append(calls, deviceID+":"+string(data))
+		if strings.Contains(string(data), "forbidden") {
+			return errors.New("content not allowed")
+		}
+		return nil
+	}
+	service := NewSignatureDeviceService(newMockStorage(), WithPreSignHook(hook))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-hook-001", Algorithm: "ECC"})
+
+	t.Run("allowed data is signed", func(t *testing.T) {
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+		if len(calls) != 1 || calls[0] != "device-hook-001:invoice" {
+			t.Errorf("expected the hook to see the device and data, got %v", calls)
+		}
+	})
+
+	t.Run("denied data is not signed", func(t *testing.T) {
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "forbidden content"})
+		if !errors.Is(err, ErrSignDenied) || !strings.Contains(err.Error(), "content not allowed") {
+			t.Errorf("expected ErrSignDenied with the hook's message, got %v", err)
+		}
+		if device.SignatureCounter != 1 || len(device.History) != 1 {
+			t.Errorf("expected counter 1 and one record, got %d and %d", device.SignatureCounter, len(device.History))
+		}
+	})
+
+	t.Run("denied attestation is not signed", func(t *testing.T) {
+		_, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "forbidden-signature"})
+		if !errors.Is(err, ErrSignDenied) {
+			t.Errorf("expected ErrSignDenied, got %v", err)
+		}
+		if device.SignatureCounter != 1 || len(device.History) != 1 {
+			t.Errorf("expected counter 1 and one record, got %d and %d", device.SignatureCounter, len(device.History))
+		}
+		if last := calls[len(calls)-1]; last != "device-hook-001:forbidden-signature" {
+			t.Errorf("expected the hook to see the external signature, got %q", last)
+		}
+	})
+}
+
+func TestPostSignHook(t *testing.T) {
+	var seen []model.SignDataResponse
+	var failure interface{}
+	hook := func(deviceID string, resp *model.SignDataResponse) error {
+		if deviceID != "device-hook-002" {
+			t.Errorf("expected device-hook-002, got %s", deviceID)
+		}
+		seen = append(seen, *resp)
+		resp.Signature = "tampered"
+		switch f := failure.(type) {
+		case error:
+			return f
+		case string:
+			panic(f)
+		}
+		return nil
+	}
+	service := NewSignatureDeviceService(newMockStorage(), WithPostSignHook(hook), WithOperationRecords(10, 0))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-hook-002", Algorithm: "ECC"})
+
+	t.Run("hook receives the response", func(t *testing.T) {
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice", OperationID: "op-1"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(seen) != 1 || seen[0].Signature != resp.Signature || seen[0].SignedData != resp.SignedData {
+			t.Errorf("expected the hook to see the returned response, got %+v", seen)
+		}
+		if resp.Signature == "tampered" {
+			t.Error("expected the hook not to alter the returned response")
+		}
+	})
+
+	t.Run("replayed operation does not call the hook", func(t *testing.T) {
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice", OperationID: "op-1"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(seen) != 1 {
+			t.Errorf("expected 1 hook call, got %d", len(seen))
+		}
+	})
+
+	t.Run("hook error does not fail signing", func(t *testing.T) {
+		failure = errors.New("notary unavailable")
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "receipt"})
+		if err != nil || resp == nil || resp.Signature == "" {
+			t.Fatalf("expected a signature, got %v, %v", resp, err)
+		}
+	})
+
+	t.Run("hook panic does not fail signing", func(t *testing.T) {
+		failure = "boom"
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "order"})
+		if err != nil || resp == nil || resp.Signature == "" {
+			t.Fatalf("expected a signature, got %v, %v", resp, err)
+		}
+		if device.SignatureCounter != 3 || len(seen) != 3 {
+			t.Errorf("expected counter 3 and 3 hook calls, got %d and %d", device.SignatureCounter, len(seen))
+		}
+	})
+
+	t.Run("hook is told about attestations", func(t *testing.T) {
+		failure = nil
+		resp, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "ZXh0ZXJuYWw="})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(seen) != 4 || seen[3].Signature != resp.Signature || seen[3].SignedData != resp.SignedData {
+			t.Errorf("expected the hook to see the attestation, got %+v", seen)
+		}
+	})
+}
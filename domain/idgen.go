@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Strategies for generating the ID of a device created without one.
+const (
+	IDStrategyNone        = ""     // Keep the empty ID as given
+	IDStrategyUUID        = "uuid" // Random RFC 4122 version 4 UUID
+	IDStrategyTimeOrdered = "time" // ULID-style ID that sorts by creation time
+)
+
+// MaxGeneratedIDAttempts bounds how often CreateDevice draws a generated ID that collides
+// with an existing device before giving up.
+const MaxGeneratedIDAttempts = 3
+
+// ValidateIDStrategy checks that strategy is one of the supported ID strategies.
+func ValidateIDStrategy(strategy string) error {
+	switch strategy {
+	case IDStrategyNone, IDStrategyUUID, IDStrategyTimeOrdered:
+		return nil
+	}
+	return fmt.Errorf("invalid ID strategy %q: must be %q or %q", strategy, IDStrategyUUID, IDStrategyTimeOrdered)
+}
+
+// newIDGenerator returns the generator for strategy, or nil for IDStrategyNone.
+func newIDGenerator(strategy string, now func() time.Time) func() (string, error) {
+	switch strategy {
+	case IDStrategyUUID:
+		return newUUID
+	case IDStrategyTimeOrdered:
+		return (&timeOrderedIDs{now: now}).next
+	}
+	return nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// crockfordAlphabet is Crockford's base32 alphabet, in ASCII order so encoded IDs sort like
+// the values they encode.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// timeOrderedIDs generates 26-character ULID-style IDs: a 48-bit millisecond timestamp
+// followed by 80 random bits, in Crockford base32. IDs generated within the same millisecond
+// increment the random part instead of drawing a new one, and a clock going backwards keeps
+// the last timestamp, so IDs from one generator always sort in generation order.
+type timeOrderedIDs struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+}
+
+func (g *timeOrderedIDs) next() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms > g.lastMs {
+		if _, err := rand.Read(g.entropy[:]); err != nil {
+			return "", fmt.Errorf("failed to generate ID: %w", err)
+		}
+		g.lastMs = ms
+	} else if !incrementBytes(g.entropy[:]) {
+		// The random part overflowed within one millisecond; borrow the next one.
+		g.lastMs++
+	}
+
+	var raw [16]byte
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(g.lastMs >> (40 - 8*i))
+	}
+	copy(raw[6:], g.entropy[:])
+	return encodeCrockford(raw), nil
+}
+
+// incrementBytes adds one to a big-endian number in place and reports false on overflow.
+func incrementBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCrockford encodes 128 bits as 26 Crockford base32 characters, most significant first.
+func encodeCrockford(raw [16]byte) string {
+	n := new(big.Int).SetBytes(raw[:])
+	base := big.NewInt(32)
+	digit := new(big.Int)
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		out[i] = crockfordAlphabet[digit.Int64()]
+	}
+	return string(out)
+}
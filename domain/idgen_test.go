@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestTimeOrderedIDs(t *testing.T) {
+	t.Run("IDs sort in creation order", func(t *testing.T) {
+		clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock), WithIDStrategy(IDStrategyTimeOrdered))
+
+		var ids []string
+		for i := 0; i < 20; i++ {
+			device, err := service.CreateDevice(model.CreateDeviceOptions{Algorithm: "ECC"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			ids = append(ids, device.ID)
+			// Several devices share each millisecond.
+			if i%3 == 2 {
+				clock.Advance(time.Millisecond)
+			}
+		}
+
+		if !sort.StringsAreSorted(ids) {
+			t.Errorf("expected IDs in creation order, got %v", ids)
+		}
+		pattern := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+		for _, id := range ids {
+			if !pattern.MatchString(id) {
+				t.Errorf("expected 26 Crockford base32 characters, got %q", id)
+			}
+		}
+	})
+
+	t.Run("clock going backwards keeps the order", func(t *testing.T) {
+		clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+		generate := newIDGenerator(IDStrategyTimeOrdered, clock.Now)
+
+		first, _ := generate()
+		clock.Advance(-time.Second)
+		second, _ := generate()
+
+		if second <= first {
+			t.Errorf("expected %q to sort after %q", second, first)
+		}
+	})
+
+	t.Run("timestamp prefix encodes creation time", func(t *testing.T) {
+		early := newIDGenerator(IDStrategyTimeOrdered, newFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)).Now)
+		late := newIDGenerator(IDStrategyTimeOrdered, newFakeClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)).Now)
+
+		a, _ := early()
+		b, _ := late()
+
+		if a[:10] >= b[:10] {
+			t.Errorf("expected earlier timestamp prefix, got %q and %q", a[:10], b[:10])
+		}
+	})
+}
+
+func TestIDStrategy(t *testing.T) {
+	t.Run("UUID strategy", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithIDStrategy(IDStrategyUUID))
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{Algorithm: "ECC"})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		if !pattern.MatchString(device.ID) {
+			t.Errorf("expected a version 4 UUID, got %q", device.ID)
+		}
+	})
+
+	t.Run("explicit IDs are kept", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithIDStrategy(IDStrategyTimeOrdered))
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-explicit", Algorithm: "ECC"})
+
+		if device.ID != "device-explicit" {
+			t.Errorf("expected device-explicit, got %q", device.ID)
+		}
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		if err := ValidateIDStrategy("snowflake"); err == nil {
+			t.Error("expected error for unknown strategy, got nil")
+		}
+	})
+}
+
+// uniqueStorage rejects saving a device whose ID is taken, like the real storage backends.
+type uniqueStorage struct {
+	*mockStorage
+}
+
+func (s uniqueStorage) Save(device *model.SignatureDevice) error {
+	if _, err := s.GetDevice(device.ID); err == nil {
+		return fmt.Errorf("%w: %s", ErrDeviceExists, device.ID)
+	}
+	return s.mockStorage.Save(device)
+}
+
+// sequenceIDs returns a generator handing out ids in order.
+func sequenceIDs(ids ...string) func() (string, error) {
+	next := 0
+	return func() (string, error) {
+		id := ids[next%len(ids)]
+		next++
+		return id, nil
+	}
+}
+
+func TestGeneratedIDCollision(t *testing.T) {
+	t.Run("generated ID is redrawn after a collision", func(t *testing.T) {
+		storage := uniqueStorage{newMockStorage()}
+		service := NewSignatureDeviceService(storage,
+			WithIDGenerator(sequenceIDs("device-taken", "device-fresh")),
+			WithDefaultLabelTemplate("label-{id}"),
+		)
+		storage.mockStorage.Save(&model.SignatureDevice{ID: "device-taken"})
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{Algorithm: "ECC"})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.ID != "device-fresh" {
+			t.Errorf("expected device-fresh, got %q", device.ID)
+		}
+		if expected := base64.StdEncoding.EncodeToString([]byte("device-fresh")); device.LastSignature != expected {
+			t.Errorf("expected last_signature %q, got %q", expected, device.LastSignature)
+		}
+		if device.Label != "label-device-fresh" {
+			t.Errorf("expected label-device-fresh, got %q", device.Label)
+		}
+	})
+
+	t.Run("retries are bounded", func(t *testing.T) {
+		storage := uniqueStorage{newMockStorage()}
+		service := NewSignatureDeviceService(storage, WithIDGenerator(sequenceIDs("device-taken")))
+		storage.mockStorage.Save(&model.SignatureDevice{ID: "device-taken"})
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{Algorithm: "ECC"})
+
+		if !errors.Is(err, ErrDeviceExists) {
+			t.Errorf("expected ErrDeviceExists, got %v", err)
+		}
+	})
+
+	t.Run("client-supplied collisions are not retried", func(t *testing.T) {
+		storage := uniqueStorage{newMockStorage()}
+		service := NewSignatureDeviceService(storage, WithIDGenerator(sequenceIDs("device-fresh")))
+		storage.mockStorage.Save(&model.SignatureDevice{ID: "device-taken"})
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-taken", Algorithm: "ECC"})
+
+		if !errors.Is(err, ErrDeviceExists) {
+			t.Errorf("expected ErrDeviceExists, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+// importPublicKey parses a PEM public key for a verify-only device and reports the
+// algorithm it belongs to.
+func importPublicKey(pemData string) (interface{}, string, error) {
+	publicKey, err := signingcrypto.ParsePublicKeyPEM([]byte(pemData))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+
+	algorithm := signingcrypto.KeyAlgorithm(publicKey)
+	if algorithm == "" {
+		return nil, "", fmt.Errorf("%w: unsupported key type %T", ErrInvalidPublicKey, publicKey)
+	}
+	return publicKey, algorithm, nil
+}
+
+// importJWK parses a JSON Web Key for an imported device and reports the algorithm it
+// belongs to. Private keys make signing devices and must use a supported RSA key size;
+// public keys make verify-only devices.
+func importJWK(data []byte) (interface{}, string, error) {
+	key, err := signingcrypto.ParseJWK(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+	}
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		if err := signingcrypto.ValidateRSAKeySize(rsaKey.N.BitLen()); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+		}
+	}
+	return key, signingcrypto.KeyAlgorithm(key), nil
+}
+
+// isPrivateKey reports whether key is an RSA or ECDSA private key.
+func isPrivateKey(key interface{}) bool {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		return true
+	default:
+		return false
+	}
+}
+
+// importedPublicKey returns the public half of an imported private key.
+func importedPublicKey(privateKey interface{}) interface{} {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey
+	default:
+		return nil
+	}
+}
+
+// checkKeyAlgorithm guards against a device whose keys do not belong to its declared
+// algorithm, e.g. an "RSA" device holding an ECDSA key. Nil keys are skipped, so
+// verify-only devices only have their public key checked.
+func checkKeyAlgorithm(algorithm string, keys ...interface{}) error {
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		if signingcrypto.KeyAlgorithm(key) != algorithm {
+			return fmt.Errorf("%w: %s device holds a %T", ErrKeyAlgorithmMismatch, algorithm, key)
+		}
+	}
+	return nil
+}
+
+// SamePublicKey reports whether two devices hold the same public key, comparing their
+// DER encoded SubjectPublicKeyInfo.
+func (s *SignatureDeviceService) SamePublicKey(idA, idB string) (bool, error) {
+	deviceA, err := s.storage.GetDevice(idA)
+	if err != nil {
+		return false, fmt.Errorf("failed to find device %s: %w", idA, err)
+	}
+	deviceB, err := s.storage.GetDevice(idB)
+	if err != nil {
+		return false, fmt.Errorf("failed to find device %s: %w", idB, err)
+	}
+
+	derA, err := x509.MarshalPKIXPublicKey(deviceA.PublicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode public key of %s: %w", idA, err)
+	}
+	derB, err := x509.MarshalPKIXPublicKey(deviceB.PublicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode public key of %s: %w", idB, err)
+	}
+	return bytes.Equal(derA, derB), nil
+}
+
+// deviceKeys builds the signer and verifier of a device hashing with hash. Verify-only
+// devices, without a private key, get a nil signer. Deterministic selects RFC 6979 nonces
+// for ECDSA keys.
+func deviceKeys(privateKey, publicKey interface{}, hash string, deterministic bool) (signingcrypto.Signer, signingcrypto.Verifier, error) {
+	h, err := signingcrypto.ParseHash(hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	verifier, err := signingcrypto.NewHashVerifier(publicKey, h)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build verifier: %w", err)
+	}
+	if privateKey == nil {
+		return nil, verifier, nil
+	}
+	signer, err := signingcrypto.NewSigner(privateKey, h, deterministic)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build signer: %w", err)
+	}
+	return signer, verifier, nil
+}
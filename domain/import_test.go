@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestKeyAlgorithmConsistency(t *testing.T) {
+	eccKeys, _ := (&signingcrypto.ECCGenerator{}).Generate()
+	rsaKeys, _ := (&signingcrypto.RSAGenerator{}).Generate()
+
+	t.Run("importing a key under the wrong algorithm is rejected", func(t *testing.T) {
+		der, _ := x509.MarshalPKIXPublicKey(eccKeys.Public)
+		eccPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		service := NewSignatureDeviceService(newMockStorage())
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:                 "device-import-001",
+			Algorithm:          "RSA",
+			ImportPublicKeyPEM: eccPEM,
+		})
+
+		if !errors.Is(err, ErrInvalidPublicKey) {
+			t.Errorf("expected ErrInvalidPublicKey, got %v", err)
+		}
+		if _, err := service.GetDevice("device-import-001"); err == nil {
+			t.Error("expected mismatched device not to be stored")
+		}
+	})
+
+	t.Run("matching keys pass", func(t *testing.T) {
+		if err := checkKeyAlgorithm("RSA", rsaKeys.Private, rsaKeys.Public); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if err := checkKeyAlgorithm("ECC", nil, eccKeys.Public); err != nil {
+			t.Errorf("expected no error for verify-only device, got %v", err)
+		}
+	})
+
+	t.Run("mismatched keys fail", func(t *testing.T) {
+		if err := checkKeyAlgorithm("RSA", eccKeys.Private, eccKeys.Public); !errors.Is(err, ErrKeyAlgorithmMismatch) {
+			t.Errorf("expected ErrKeyAlgorithmMismatch, got %v", err)
+		}
+		if err := checkKeyAlgorithm("ECC", eccKeys.Private, rsaKeys.Public); !errors.Is(err, ErrKeyAlgorithmMismatch) {
+			t.Errorf("expected ErrKeyAlgorithmMismatch, got %v", err)
+		}
+	})
+
+	t.Run("migration refuses mislabeled devices", func(t *testing.T) {
+		src := newMockStorage()
+		src.Save(&model.SignatureDevice{
+			ID:         "device-mislabeled",
+			Algorithm:  "RSA",
+			PrivateKey: eccKeys.Private,
+			PublicKey:  eccKeys.Public,
+		})
+		dst := newMockStorage()
+
+		report, _ := MigrateStorage(src, dst, false)
+
+		if !errors.Is(report.Failed["device-mislabeled"], ErrKeyAlgorithmMismatch) {
+			t.Errorf("expected ErrKeyAlgorithmMismatch, got %+v", report)
+		}
+		if _, err := dst.GetDevice("device-mislabeled"); err == nil {
+			t.Error("expected mislabeled device not to be migrated")
+		}
+	})
+}
+
+func TestSignDataUsesDeviceAlgorithm(t *testing.T) {
+	t.Run("device whose key disagrees with its algorithm refuses to sign", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-alg-001", Algorithm: "ECC"})
+		device.Algorithm = "RSA"
+
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		if !errors.Is(err, ErrKeyAlgorithmMismatch) {
+			t.Errorf("expected ErrKeyAlgorithmMismatch, got %v", err)
+		}
+		if device.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", device.SignatureCounter)
+		}
+	})
+}
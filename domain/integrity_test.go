@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestVerifyCounterIntegrity(t *testing.T) {
+	newSignedDevice := func(t *testing.T) (*SignatureDeviceService, *model.SignatureDevice) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-integrity-001", Algorithm: "ECC"})
+		for i := 0; i < 4; i++ {
+			if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		return service, device
+	}
+
+	t.Run("complete history is ok", func(t *testing.T) {
+		service, device := newSignedDevice(t)
+
+		report, err := service.VerifyCounterIntegrity(device.ID)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !report.OK || len(report.Missing) != 0 {
+			t.Errorf("expected ok report, got %+v", report)
+		}
+	})
+
+	t.Run("removed entry is reported", func(t *testing.T) {
+		service, device := newSignedDevice(t)
+		device.History = append(device.History[:2:2], device.History[3:]...)
+
+		report, err := service.VerifyCounterIntegrity(device.ID)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.OK {
+			t.Error("expected report to flag a gap")
+		}
+		if len(report.Missing) != 1 || report.Missing[0] != 2 {
+			t.Errorf("expected missing [2], got %v", report.Missing)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		if _, err := service.VerifyCounterIntegrity("missing"); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+}
+
+func TestSelfVerify(t *testing.T) {
+	newSignedDevice := func(t *testing.T, opts model.CreateDeviceOptions) (*SignatureDeviceService, *model.SignatureDevice) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(opts)
+		for i := 0; i < 4; i++ {
+			if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", AAD: "audience"}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "c2lnbmF0dXJl"})
+		return service, device
+	}
+
+	t.Run("healthy device passes", func(t *testing.T) {
+		for _, algorithm := range []string{"RSA", "ECC"} {
+			service, device := newSignedDevice(t, model.CreateDeviceOptions{ID: "device-self-" + algorithm, Algorithm: algorithm, CounterEncoding: CounterEncodingHex})
+
+			report, err := service.SelfVerify(device.ID)
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !report.OK || report.Verified != 5 || len(report.Failures) != 0 {
+				t.Errorf("expected 5 verified records for %s, got %+v", algorithm, report)
+			}
+		}
+	})
+
+	t.Run("corrupted signature is reported", func(t *testing.T) {
+		service, device := newSignedDevice(t, model.CreateDeviceOptions{ID: "device-self-002", Algorithm: "ECC"})
+		// Swap in another record's signature, as a corrupted store might.
+		device.History[1].Signature = device.History[2].Signature
+
+		report, _ := service.SelfVerify(device.ID)
+
+		if report.OK {
+			t.Fatal("expected corruption to be detected")
+		}
+		if report.Verified != 3 {
+			t.Errorf("expected 3 verified records, got %d", report.Verified)
+		}
+		counters := map[int64]bool{}
+		for _, failure := range report.Failures {
+			counters[failure.Counter] = true
+		}
+		if !counters[1] || !counters[2] {
+			t.Errorf("expected failures at counters 1 and 2, got %+v", report.Failures)
+		}
+	})
+
+	t.Run("corrupted data is reported", func(t *testing.T) {
+		service, device := newSignedDevice(t, model.CreateDeviceOptions{ID: "device-self-003", Algorithm: "ECC"})
+		device.History[0].Data = "tampered"
+
+		report, _ := service.SelfVerify(device.ID)
+
+		if report.OK || len(report.Failures) != 1 || report.Failures[0].Counter != 0 {
+			t.Errorf("expected a single failure at counter 0, got %+v", report.Failures)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		if _, err := service.SelfVerify("missing"); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+}
@@ -1,10 +1,43 @@
 package domain
 
-import model "github.com/bayuhutajulu/signing-service/model"
+import (
+	"context"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
 
 type ISignatureDeviceService interface {
 	CreateDevice(opts model.CreateDeviceOptions) (*model.SignatureDevice, error)
 	SignData(opts model.SignDataOptions) (*model.SignDataResponse, error)
+	SignDataContext(ctx context.Context, opts model.SignDataOptions) (*model.SignDataResponse, error)
 	GetDevice(id string) (*model.SignatureDevice, error)
 	GetAllDevices() ([]*model.SignatureDevice, error)
+	GetTenantDevice(tenantID, id string) (*model.SignatureDevice, error)
+	GetTenantDevices(tenantID string) ([]*model.SignatureDevice, error)
+	GetDevices(ids []string) (map[string]*model.SignatureDevice, error)
+	DeleteDevices(tenantID string, ids []string) ([]model.DeleteResult, error)
+	SignatureStats(topN int) (*model.SignatureStatsResponse, error)
+	VerifySignatures(opts model.BatchVerifyOptions) ([]model.VerifyResult, error)
+	VerifyAt(opts model.VerifyAtOptions) (*model.VerifyResult, error)
+	AttestSignature(opts model.AttestOptions) (*model.AttestResponse, error)
+	VerifyCounterIntegrity(deviceID string) (*model.IntegrityReport, error)
+	SelfVerify(deviceID string) (*model.SelfVerifyReport, error)
+	SelfTest() *model.SelfTestReport
+	Introspect(opts model.IntrospectOptions) (*model.IntrospectReport, error)
+	AtRiskDevices(threshold float64) ([]model.AtRiskDevice, error)
+	SignatureHistory(deviceID string) ([]model.SignatureRecord, error)
+	SamePublicKey(idA, idB string) (bool, error)
+	SignatureThroughput(deviceID string, window time.Duration) (int, error)
+	RegenerateInitialSignature(deviceID string) (*model.SignatureDevice, error)
+	Subscribe(ch chan<- SignEvent) func()
+	MigrateDeviceKeys(opts model.MigrateDeviceOptions) (*model.SignatureDevice, error)
+	SetDeviceLocked(deviceID string, locked bool) (*model.SignatureDevice, error)
+	ChainHead(id string) (*model.ChainHeadResponse, error)
+	ChainTips(tenantID, after string, limit int) (*model.ChainTipsPage, error)
+	DeviceCapabilities(deviceID string) (*model.DeviceCapabilities, error)
+	DeviceSize(deviceID string) (*model.DeviceSizeResponse, error)
+	DeviceKeyStrength(deviceID string) (*model.KeyStrength, error)
+	FeatureFlags() model.FeatureFlags
+	UpdateFeatureFlags(update model.FeatureFlagsUpdate) model.FeatureFlags
 }
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// Introspect examines a signature of unknown provenance: it looks for the device whose key
+// verifies the signature over signed_data, trying only the KID device when a hint is given and
+// otherwise every device of the tenant, and extracts the counter, data and last_signature.
+// Segments come from the identified device's history when the signature is recorded there,
+// else from parsing signed_data with the device's separator and counter encoding, or with the
+// defaults when no device is identified. Parsing is skipped when signed_data has more than two
+// separators, as data with the separator, a nonce, AAD or an expiry cannot then be told apart.
+// An unknown KID fails with ErrDeviceNotFound; an undecodable signature, or a scan without a
+// KID over more devices than the service allows, with ErrInvalidIntrospection.
+func (s *SignatureDeviceService) Introspect(opts model.IntrospectOptions) (*model.IntrospectReport, error) {
+	if opts.SignedData == "" || opts.Signature == "" {
+		return nil, fmt.Errorf("%w: signed_data and signature are required", ErrInvalidIntrospection)
+	}
+	algorithm, signature, err := signingcrypto.DecodeTaggedSignature(opts.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIntrospection, err)
+	}
+
+	var candidates []*model.SignatureDevice
+	if opts.KID != "" {
+		device, err := s.GetTenantDevice(opts.TenantID, opts.KID)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		candidates = []*model.SignatureDevice{snapshotDevice(device)}
+		s.mu.Unlock()
+	} else {
+		candidates, err = s.GetTenantDevices(opts.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		if s.maxIntrospectScan > 0 && len(candidates) > s.maxIntrospectScan {
+			return nil, fmt.Errorf("%w: kid is required when scanning more than %d devices", ErrInvalidIntrospection, s.maxIntrospectScan)
+		}
+	}
+
+	report := &model.IntrospectReport{}
+	var match *model.SignatureDevice
+	for _, device := range candidates {
+		// A tagged signature can only come from a device of the tagged algorithm.
+		if algorithm != "" && algorithm != device.Algorithm {
+			continue
+		}
+		report.DevicesScanned++
+		verifier, err := deviceVerifier(device)
+		if err != nil {
+			continue
+		}
+		if verifier.Verify([]byte(opts.SignedData), signature) == nil {
+			match = device
+			break
+		}
+	}
+
+	sep, encoding := DefaultSeparator, CounterEncodingDecimal
+	if match != nil {
+		report.Identified = true
+		report.DeviceID = match.ID
+		report.Algorithm = match.Algorithm
+		sep, encoding = deviceSeparator(match), deviceCounterEncoding(match)
+		for _, record := range match.History {
+			if record.SignedData == opts.SignedData {
+				report.Chain = &model.ChainSegments{Counter: record.Counter, Data: record.Data, LastSignature: record.LastSignature}
+				return report, nil
+			}
+		}
+	}
+	if n := strings.Count(opts.SignedData, sep); n > 2 {
+		report.ParseError = fmt.Sprintf("ambiguous signed data: %d %q separators, so the data cannot be told apart from a nonce, aad or expiry", n, sep)
+		return report, nil
+	}
+	parts, err := ParseSignedData(opts.SignedData, sep, encoding)
+	if err != nil {
+		report.ParseError = err.Error()
+		return report, nil
+	}
+	report.Chain = &model.ChainSegments{Counter: parts.Counter, Data: parts.Data, LastSignature: parts.LastSignature}
+	return report, nil
+}
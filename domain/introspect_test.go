@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestIntrospect(t *testing.T) {
+	service := NewSignatureDeviceService(newMockStorage())
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-intro-001", Algorithm: "RSA"})
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-intro-002", Algorithm: "ECC", Separator: "|"})
+	service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+	signed, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "a|b"})
+
+	t.Run("identifies the signing device", func(t *testing.T) {
+		report, err := service.Introspect(model.IntrospectOptions{SignedData: signed.SignedData, Signature: signed.Signature})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !report.Identified || report.DeviceID != device.ID || report.Algorithm != "ECC" {
+			t.Errorf("expected %s to be identified, got %+v", device.ID, report)
+		}
+		expected := model.ChainSegments{Counter: 1, Data: "a|b", LastSignature: device.History[0].Signature}
+		if report.Chain == nil || *report.Chain != expected {
+			t.Errorf("expected chain %+v, got %+v", expected, report.Chain)
+		}
+	})
+
+	t.Run("random signature is not identified", func(t *testing.T) {
+		keyPair, _ := (&signingcrypto.ECCGenerator{}).Generate()
+		signedData := "7_payload_" + base64.StdEncoding.EncodeToString([]byte("previous"))
+		signature, _ := signingcrypto.NewECDSASigner(keyPair.Private).Sign([]byte(signedData))
+
+		report, err := service.Introspect(model.IntrospectOptions{
+			SignedData: signedData,
+			Signature:  base64.StdEncoding.EncodeToString(signature),
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Identified || report.DeviceID != "" || report.DevicesScanned != 2 {
+			t.Errorf("expected no device among 2 scanned, got %+v", report)
+		}
+		if report.Chain == nil || report.Chain.Counter != 7 || report.Chain.Data != "payload" {
+			t.Errorf("expected counter 7 and data payload parsed, got %+v", report.Chain)
+		}
+	})
+
+	t.Run("kid limits the scan", func(t *testing.T) {
+		report, _ := service.Introspect(model.IntrospectOptions{SignedData: signed.SignedData, Signature: signed.Signature, KID: "device-intro-001"})
+		if report.Identified || report.DevicesScanned != 1 {
+			t.Errorf("expected only the RSA device to be scanned, without a match, got %+v", report)
+		}
+		if _, err := service.Introspect(model.IntrospectOptions{SignedData: signed.SignedData, Signature: signed.Signature, KID: "missing"}); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+
+	t.Run("unparseable signed data is reported", func(t *testing.T) {
+		report, err := service.Introspect(model.IntrospectOptions{SignedData: "no-separators", Signature: signed.Signature})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Chain != nil || report.ParseError == "" {
+			t.Errorf("expected a parse error, got %+v", report)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package domain
+
+// keygenPool runs CPU-bound key generation on a fixed set of workers fed by a bounded queue,
+// so a burst of create requests cannot spawn an unbounded amount of concurrent keygen work.
+type keygenPool struct {
+	jobs chan func()
+}
+
+func newKeygenPool(workers, queue int) *keygenPool {
+	p := &keygenPool{jobs: make(chan func(), queue)}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// run executes fn on a worker and waits for it to finish. It fails with ErrKeyGenQueueFull
+// instead of blocking when every worker is busy and the queue has no room. A nil pool runs
+// fn on the calling goroutine.
+func (p *keygenPool) run(fn func()) error {
+	if p == nil {
+		fn()
+		return nil
+	}
+
+	done := make(chan struct{})
+	select {
+	case p.jobs <- func() { defer close(done); fn() }:
+	default:
+		return ErrKeyGenQueueFull
+	}
+	<-done
+	return nil
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestKeyGenerationPool(t *testing.T) {
+	t.Run("queued creates complete and overflow is rejected", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithKeyGenerationPool(1, 1))
+
+		// Occupy the only worker until released.
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go service.keygen.run(func() {
+			close(started)
+			<-release
+		})
+		<-started
+
+		queued := make(chan error, 1)
+		go func() {
+			_, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-queued", Algorithm: "ECC"})
+			queued <- err
+		}()
+		deadline := time.Now().Add(time.Second)
+		for len(service.keygen.jobs) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("create request was never queued")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-overflow", Algorithm: "ECC"})
+		if !errors.Is(err, ErrKeyGenQueueFull) {
+			t.Errorf("expected ErrKeyGenQueueFull, got %v", err)
+		}
+
+		close(release)
+		select {
+		case err := <-queued:
+			if err != nil {
+				t.Errorf("expected queued create to succeed, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("queued create did not complete")
+		}
+		if _, err := service.GetDevice("device-overflow"); err == nil {
+			t.Error("expected rejected device not to be stored")
+		}
+	})
+
+	t.Run("pooled creates produce working devices", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithKeyGenerationPool(2, 4))
+
+		for _, algorithm := range []string{"RSA", "ECC"} {
+			device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-" + algorithm, Algorithm: algorithm})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err != nil {
+				t.Errorf("expected %s device to sign, got %v", algorithm, err)
+			}
+		}
+	})
+}
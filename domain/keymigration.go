@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// MigrateDeviceKeys moves a device's chain onto a new device with freshly generated keys,
+// e.g. to replace an RSA device with an ECC one while keeping its logical identity. The new
+// device is created as by CreateDevice, then starts at the source's counter and
+// last_signature, keeps its separator, counter encoding and signing policies, and names the
+// source in MigratedFrom. The source's history stays with the source, so the new device
+// reports the inherited counters as pruned. The source drops its private key and remains,
+// verify-only, with MigratedTo naming its successor. Signing pauses for the duration.
+func (s *SignatureDeviceService) MigrateDeviceKeys(opts model.MigrateDeviceOptions) (*model.SignatureDevice, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, err := s.storage.GetDevice(opts.SourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+	if source.IsVerifyOnly() {
+		return nil, ErrVerifyOnly
+	}
+	if source.Locked {
+		return nil, ErrDeviceLocked
+	}
+
+	label := opts.Label
+	if label == "" {
+		label = source.Label
+	}
+	device, err := s.CreateDevice(model.CreateDeviceOptions{
+		ID:                opts.ID,
+		Label:             label,
+		Algorithm:         opts.Algorithm,
+		KeySize:           opts.KeySize,
+		Curve:             opts.Curve,
+		Deterministic:     opts.Deterministic,
+		Separator:         source.Separator,
+		CounterEncoding:   source.CounterEncoding,
+		RejectDuplicates:  source.RejectDuplicates,
+		MaxHistoryEntries: source.MaxHistoryEntries,
+		RequireNonce:      source.RequireNonce,
+		TenantID:          source.TenantID,
+		SignQuota:         source.SignQuota,
+		QuotaWindow:       source.QuotaWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	device.SignatureCounter = source.SignatureCounter
+	device.LastSignature = source.LastSignature
+	device.PrunedHistory = source.SignatureCounter
+	device.FirstSignedAt = source.FirstSignedAt
+	device.LastSignedAt = source.LastSignedAt
+	device.MigratedFrom = source.ID
+	if err := s.storage.Update(device); err != nil {
+		s.storage.Delete(device.ID)
+		return nil, fmt.Errorf("%w: %w", ErrStorageFailure, err)
+	}
+
+	signer, privateKey := source.Signer, source.PrivateKey
+	source.Signer, source.PrivateKey, source.MigratedTo = nil, nil, device.ID
+	if err := s.storage.Update(source); err != nil {
+		source.Signer, source.PrivateKey, source.MigratedTo = signer, privateKey, ""
+		s.storage.Delete(device.ID)
+		return nil, fmt.Errorf("%w: %w", ErrStorageFailure, err)
+	}
+	return device, nil
+}
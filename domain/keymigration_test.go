@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestMigrateDeviceKeys(t *testing.T) {
+	setup := func(t *testing.T) (*SignatureDeviceService, *model.SignatureDevice, []*model.SignDataResponse) {
+		t.Helper()
+		service := NewSignatureDeviceService(newMockStorage())
+		source, err := service.CreateDevice(model.CreateDeviceOptions{ID: "rsa-001", Label: "Till 1", Algorithm: "RSA", Separator: "|"})
+		if err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		var signed []*model.SignDataResponse
+		for _, data := range []string{"a", "b", "c"} {
+			resp, err := service.SignData(model.SignDataOptions{DeviceID: source.ID, Data: data})
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			signed = append(signed, resp)
+		}
+		return service, source, signed
+	}
+
+	t.Run("new device continues the counter and chain", func(t *testing.T) {
+		service, source, signed := setup(t)
+
+		device, err := service.MigrateDeviceKeys(model.MigrateDeviceOptions{SourceID: source.ID, ID: "ecc-001", Algorithm: "ECC"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.Algorithm != "ECC" || device.MigratedFrom != source.ID || source.MigratedTo != device.ID {
+			t.Errorf("expected ECC device linked to %s, got algorithm %s, migrated_from %q, migrated_to %q",
+				source.ID, device.Algorithm, device.MigratedFrom, source.MigratedTo)
+		}
+		if device.SignatureCounter != 3 || device.LastSignature != signed[2].Signature {
+			t.Errorf("expected counter 3 continuing the source chain, got %d", device.SignatureCounter)
+		}
+		if device.Label != "Till 1" || device.Separator != "|" {
+			t.Errorf("expected label and separator of the source, got %q and %q", device.Label, device.Separator)
+		}
+
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "d"})
+		if err != nil {
+			t.Fatalf("expected new device to sign, got %v", err)
+		}
+		if expected := "3|d|" + signed[2].Signature; resp.SignedData != expected {
+			t.Errorf("expected signed data %q, got %q", expected, resp.SignedData)
+		}
+		report, err := service.VerifyCounterIntegrity(device.ID)
+		if err != nil || !report.OK {
+			t.Errorf("expected intact counters, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("source becomes verify-only", func(t *testing.T) {
+		service, source, signed := setup(t)
+		if _, err := service.MigrateDeviceKeys(model.MigrateDeviceOptions{SourceID: source.ID, ID: "ecc-002", Algorithm: "ECC"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !source.IsVerifyOnly() {
+			t.Error("expected source to be verify-only")
+		}
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: source.ID, Data: "d"}); !errors.Is(err, ErrVerifyOnly) {
+			t.Errorf("expected ErrVerifyOnly, got %v", err)
+		}
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: source.ID,
+			Entries:  []model.VerifySignatureOptions{{Data: "c", Signature: signed[2].Signature, Counter: 2, LastSignature: signed[1].Signature}},
+		})
+		if err != nil || !results[0].Valid {
+			t.Errorf("expected old signatures to verify, got %+v, %v", results, err)
+		}
+		if _, err := service.MigrateDeviceKeys(model.MigrateDeviceOptions{SourceID: source.ID, ID: "ecc-003"}); !errors.Is(err, ErrVerifyOnly) {
+			t.Errorf("expected second migration to fail with ErrVerifyOnly, got %v", err)
+		}
+	})
+
+	t.Run("failed creation leaves the source untouched", func(t *testing.T) {
+		service, source, _ := setup(t)
+
+		_, err := service.MigrateDeviceKeys(model.MigrateDeviceOptions{SourceID: source.ID, ID: "dsa-001", Algorithm: "DSA"})
+		if err == nil || !strings.Contains(err.Error(), "invalid algorithm") {
+			t.Fatalf("expected invalid algorithm error, got %v", err)
+		}
+		if source.IsVerifyOnly() || source.MigratedTo != "" {
+			t.Error("expected source to keep signing")
+		}
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		if _, err := service.MigrateDeviceKeys(model.MigrateDeviceOptions{SourceID: "missing", ID: "ecc-004"}); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Placeholders expanded in a default label template.
+const (
+	LabelPlaceholderID        = "{id}"
+	LabelPlaceholderAlgorithm = "{algorithm}"
+)
+
+// ValidateLabelTemplate checks that every placeholder in template is LabelPlaceholderID or
+// LabelPlaceholderAlgorithm and that braces are balanced. The empty template is valid.
+func ValidateLabelTemplate(template string) error {
+	rest := template
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			return nil
+		}
+		if rest[open] == '}' {
+			return fmt.Errorf("invalid label template %q: unmatched '}'", template)
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return fmt.Errorf("invalid label template %q: unmatched '{'", template)
+		}
+		placeholder := rest[open : open+end+1]
+		if placeholder != LabelPlaceholderID && placeholder != LabelPlaceholderAlgorithm {
+			return fmt.Errorf("invalid label template %q: unknown placeholder %s", template, placeholder)
+		}
+		rest = rest[open+end+1:]
+	}
+}
+
+// defaultLabel returns the label of a device created without one: the service's label template
+// expanded for the device, or the empty string when no template is configured.
+func (s *SignatureDeviceService) defaultLabel(id, algorithm string) string {
+	if s.labelTemplate == "" {
+		return ""
+	}
+	return strings.NewReplacer(LabelPlaceholderID, id, LabelPlaceholderAlgorithm, algorithm).Replace(s.labelTemplate)
+}
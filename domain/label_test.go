@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestDefaultLabelTemplate(t *testing.T) {
+	t.Run("template is applied when the label is empty", func(t *testing.T) {
+		tests := []struct {
+			template string
+			expected string
+		}{
+			{"device-{id}", "device-label-001"},
+			{"{algorithm}-device", "ECC-device"},
+			{"{algorithm}:{id}:{id}", "ECC:label-001:label-001"},
+		}
+		for _, tc := range tests {
+			service := NewSignatureDeviceService(newMockStorage(), WithDefaultLabelTemplate(tc.template))
+			device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "label-001", Algorithm: "ECC"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if device.Label != tc.expected {
+				t.Errorf("template %q: expected label %q, got %q", tc.template, tc.expected, device.Label)
+			}
+		}
+	})
+
+	t.Run("provided label is left untouched", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithDefaultLabelTemplate("device-{id}"))
+		device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "label-002", Algorithm: "ECC", Label: "Till 3"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.Label != "Till 3" {
+			t.Errorf("expected label %q, got %q", "Till 3", device.Label)
+		}
+	})
+
+	t.Run("label stays empty without a template", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "label-003", Algorithm: "ECC"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.Label != "" {
+			t.Errorf("expected empty label, got %q", device.Label)
+		}
+	})
+}
+
+func TestValidateLabelTemplate(t *testing.T) {
+	for _, template := range []string{"", "device", "device-{id}", "{algorithm}-{id}"} {
+		if err := ValidateLabelTemplate(template); err != nil {
+			t.Errorf("expected %q to be valid, got %v", template, err)
+		}
+	}
+	for _, template := range []string{"{name}", "device-{id", "device-}", "{{id}}"} {
+		if err := ValidateLabelTemplate(template); err == nil {
+			t.Errorf("expected %q to be rejected", template)
+		}
+	}
+}
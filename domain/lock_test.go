@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSetDeviceLocked(t *testing.T) {
+	t.Run("locked device refuses to sign until unlocked", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-lock-001", Algorithm: "ECC"})
+
+		if _, err := service.SetDeviceLocked(device.ID, true); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); !errors.Is(err, ErrDeviceLocked) {
+			t.Errorf("expected ErrDeviceLocked, got %v", err)
+		}
+		if device.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", device.SignatureCounter)
+		}
+
+		service.SetDeviceLocked(device.ID, false)
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err != nil {
+			t.Errorf("expected no error after unlock, got %v", err)
+		}
+	})
+}
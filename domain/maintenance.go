@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// RegenerateInitialSignature recomputes the base-case last_signature, base64(device_id), for a
+// device that has not signed yet, e.g. after its ID was corrected in storage. Devices with a
+// counter above zero are rejected with ErrDeviceInUse, since their chain already depends on it.
+func (s *SignatureDeviceService) RegenerateInitialSignature(deviceID string) (*model.SignatureDevice, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+	if device.SignatureCounter > 0 {
+		return nil, fmt.Errorf("%w: counter is %d", ErrDeviceInUse, device.SignatureCounter)
+	}
+
+	device.LastSignature = base64.StdEncoding.EncodeToString([]byte(device.ID))
+	if err := s.storage.Update(device); err != nil {
+		return nil, fmt.Errorf("failed to update device: %w", err)
+	}
+	return device, nil
+}
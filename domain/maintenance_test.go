@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestRegenerateInitialSignature(t *testing.T) {
+	t.Run("fresh device is reinitialized", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-typo", Algorithm: "ECC"})
+
+		delete(storage.devices, device.ID)
+		device.ID = "device-fixed"
+		storage.devices[device.ID] = device
+
+		updated, err := service.RegenerateInitialSignature("device-fixed")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		expected := base64.StdEncoding.EncodeToString([]byte("device-fixed"))
+		if updated.LastSignature != expected {
+			t.Errorf("expected last signature %s, got %s", expected, updated.LastSignature)
+		}
+	})
+
+	t.Run("used device is rejected", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-used", Algorithm: "ECC"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		lastSignature := device.LastSignature
+
+		_, err := service.RegenerateInitialSignature(device.ID)
+
+		if !errors.Is(err, ErrDeviceInUse) {
+			t.Errorf("expected ErrDeviceInUse, got %v", err)
+		}
+		if device.LastSignature != lastSignature {
+			t.Error("expected last signature to be unchanged")
+		}
+	})
+}
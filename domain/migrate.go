@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MigrationReport summarizes a MigrateStorage run.
+type MigrationReport struct {
+	Migrated int
+	Skipped  int
+	Failed   map[string]error // Per-device failures keyed by device ID
+}
+
+// MigrateStorage copies every device, including its keys, from src to dst, e.g. when moving
+// from the in-memory store to a durable backend. Devices already present in dst are skipped,
+// or replaced when overwrite is set. Devices whose keys do not match their algorithm are not
+// copied. A failure on one device does not stop the others; it is recorded in the report.
+// The returned error only reports that src could not be listed.
+func MigrateStorage(src, dst DeviceStorage, overwrite bool) (*MigrationReport, error) {
+	devices, err := src.GetAllDevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source devices: %w", err)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+
+	report := &MigrationReport{Failed: make(map[string]error)}
+	for _, device := range devices {
+		if err := checkKeyAlgorithm(device.Algorithm, device.PrivateKey, device.PublicKey); err != nil {
+			report.Failed[device.ID] = err
+			continue
+		}
+
+		if overwrite {
+			// Update only replaces existing devices; new ones fall through to Save below.
+			err := dst.Update(device)
+			if err == nil {
+				report.Migrated++
+				continue
+			}
+			if !errors.Is(err, ErrDeviceNotFound) {
+				report.Failed[device.ID] = fmt.Errorf("failed to update device: %w", err)
+				continue
+			}
+		}
+
+		_, err := dst.GetDevice(device.ID)
+		switch {
+		case err == nil:
+			report.Skipped++
+		case errors.Is(err, ErrDeviceNotFound):
+			if err := dst.Save(device); err != nil {
+				report.Failed[device.ID] = fmt.Errorf("failed to save device: %w", err)
+				continue
+			}
+			report.Migrated++
+		default:
+			report.Failed[device.ID] = fmt.Errorf("failed to look up device: %w", err)
+		}
+	}
+	return report, nil
+}
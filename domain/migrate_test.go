@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	t.Run("migrated devices keep signing", func(t *testing.T) {
+		src := newMockStorage()
+		source := NewSignatureDeviceService(src)
+		for _, opts := range []model.CreateDeviceOptions{
+			{ID: "device-rsa", Algorithm: "RSA"},
+			{ID: "device-ecc", Algorithm: "ECC"},
+		} {
+			if _, err := source.CreateDevice(opts); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		source.SignData(model.SignDataOptions{DeviceID: "device-ecc", Data: "before"})
+
+		dst := newMockStorage()
+		report, err := MigrateStorage(src, dst, false)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Migrated != 2 || report.Skipped != 0 || len(report.Failed) != 0 {
+			t.Errorf("expected 2 migrated, got %+v", report)
+		}
+
+		target := NewSignatureDeviceService(dst)
+		for _, id := range []string{"device-rsa", "device-ecc"} {
+			resp, err := target.SignData(model.SignDataOptions{DeviceID: id, Data: "after"})
+			if err != nil {
+				t.Fatalf("expected %s to sign after migration, got %v", id, err)
+			}
+			device, _ := target.GetDevice(id)
+			signature, _ := base64.StdEncoding.DecodeString(resp.Signature)
+			if err := device.Verifier.Verify([]byte(resp.SignedData), signature); err != nil {
+				t.Errorf("expected %s signature to verify, got %v", id, err)
+			}
+		}
+		migrated, _ := dst.GetDevice("device-ecc")
+		if migrated.SignatureCounter != 2 {
+			t.Errorf("expected counter to continue at 2, got %d", migrated.SignatureCounter)
+		}
+	})
+
+	t.Run("existing devices are skipped unless overwriting", func(t *testing.T) {
+		src := newMockStorage()
+		src.Save(&model.SignatureDevice{ID: "device-001", Label: "source"})
+		dst := newMockStorage()
+		dst.Save(&model.SignatureDevice{ID: "device-001", Label: "target"})
+
+		report, _ := MigrateStorage(src, dst, false)
+		if report.Skipped != 1 || report.Migrated != 0 {
+			t.Errorf("expected 1 skipped, got %+v", report)
+		}
+		if device, _ := dst.GetDevice("device-001"); device.Label != "target" {
+			t.Errorf("expected existing device to be kept, got label %q", device.Label)
+		}
+
+		report, _ = MigrateStorage(src, dst, true)
+		if report.Migrated != 1 || report.Skipped != 0 {
+			t.Errorf("expected 1 migrated, got %+v", report)
+		}
+		if device, _ := dst.GetDevice("device-001"); device.Label != "source" {
+			t.Errorf("expected device to be overwritten, got label %q", device.Label)
+		}
+	})
+
+	t.Run("overwriting copies devices missing from the target", func(t *testing.T) {
+		src := newMockStorage()
+		src.Save(&model.SignatureDevice{ID: "device-001", Label: "source"})
+		dst := newMockStorage()
+
+		report, _ := MigrateStorage(src, dst, true)
+		if report.Migrated != 1 || len(report.Failed) != 0 {
+			t.Errorf("expected 1 migrated, got %+v", report)
+		}
+		if _, err := dst.GetDevice("device-001"); err != nil {
+			t.Errorf("expected device in target, got %v", err)
+		}
+	})
+
+	t.Run("per-device failures are reported", func(t *testing.T) {
+		src := newMockStorage()
+		src.Save(&model.SignatureDevice{ID: "device-001"})
+		dst := newMockStorage()
+		dst.saveErr = errors.New("disk full")
+
+		report, err := MigrateStorage(src, dst, false)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Failed["device-001"] == nil {
+			t.Errorf("expected failure for device-001, got %+v", report)
+		}
+	})
+
+	t.Run("source listing failure", func(t *testing.T) {
+		src := newMockStorage()
+		src.getAllErr = errors.New("unavailable")
+
+		if _, err := MigrateStorage(src, newMockStorage(), false); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// DefaultNonceWindow is the number of used nonces remembered per device.
+const DefaultNonceWindow = 4096
+
+// Nonce length bounds, in characters. Nonces are ASCII letters and digits only, so they never
+// contain a chain separator.
+const (
+	MinNonceLength = 8
+	MaxNonceLength = 128
+)
+
+// ValidateNonce checks that nonce has an accepted length and contains only letters and digits.
+func ValidateNonce(nonce string) error {
+	if len(nonce) < MinNonceLength || len(nonce) > MaxNonceLength {
+		return fmt.Errorf("%w: must be %d to %d characters", ErrInvalidNonce, MinNonceLength, MaxNonceLength)
+	}
+	for _, c := range nonce {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return fmt.Errorf("%w: must contain only letters and digits", ErrInvalidNonce)
+		}
+	}
+	return nil
+}
+
+// nonceWindow remembers the most recent nonces of a device in insertion order, evicting the
+// oldest once full, so memory stays bounded regardless of how long the device signs.
+type nonceWindow struct {
+	seen  map[string]struct{}
+	order []string // Ring buffer of remembered nonces
+	next  int      // Index of the oldest nonce once the ring is full
+}
+
+func newNonceWindow(size int) *nonceWindow {
+	return &nonceWindow{seen: make(map[string]struct{}, size), order: make([]string, 0, size)}
+}
+
+func (w *nonceWindow) contains(nonce string) bool {
+	_, ok := w.seen[nonce]
+	return ok
+}
+
+func (w *nonceWindow) add(nonce string) {
+	if len(w.order) < cap(w.order) {
+		w.order = append(w.order, nonce)
+	} else {
+		delete(w.seen, w.order[w.next])
+		w.order[w.next] = nonce
+		w.next = (w.next + 1) % len(w.order)
+	}
+	w.seen[nonce] = struct{}{}
+}
+
+// checkNonce enforces the device's nonce policy: devices created with RequireNonce need a
+// valid nonce not among their remembered ones, and other devices accept none. Callers must
+// hold s.mu.
+func (s *SignatureDeviceService) checkNonce(device *model.SignatureDevice, nonce string) error {
+	if !device.RequireNonce {
+		if nonce != "" {
+			return fmt.Errorf("%w: device does not use nonces", ErrInvalidNonce)
+		}
+		return nil
+	}
+	if nonce == "" {
+		return fmt.Errorf("%w: device requires a nonce", ErrInvalidNonce)
+	}
+	if err := ValidateNonce(nonce); err != nil {
+		return err
+	}
+	if window, ok := s.nonces[device.ID]; ok && window.contains(nonce) {
+		return ErrNonceReused
+	}
+	return nil
+}
+
+// rememberNonce records a nonce after the signature using it succeeded. Callers must hold s.mu.
+func (s *SignatureDeviceService) rememberNonce(deviceID, nonce string) {
+	window, ok := s.nonces[deviceID]
+	if !ok {
+		window = newNonceWindow(s.nonceWindowSize)
+		s.nonces[deviceID] = window
+	}
+	window.add(nonce)
+}
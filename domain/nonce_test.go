@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSignDataNonce(t *testing.T) {
+	t.Run("fresh nonce is bound into signed data", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-001", Algorithm: "ECC", RequireNonce: true})
+
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Nonce: "abc12345"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !strings.HasSuffix(resp.SignedData, "_abc12345") {
+			t.Errorf("expected nonce as last segment, got %q", resp.SignedData)
+		}
+
+		results, _ := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: device.ID, Entries: []model.VerifySignatureOptions{
+			{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: device.History[0].LastSignature, Nonce: "abc12345"},
+			{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: device.History[0].LastSignature},
+		}})
+		if !results[0].Valid || results[1].Valid {
+			t.Errorf("expected verification to require the nonce, got %+v", results)
+		}
+	})
+
+	t.Run("attestations need a fresh nonce", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-006", Algorithm: "ECC", RequireNonce: true})
+
+		if _, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "ZXh0ZXJuYWw="}); !errors.Is(err, ErrInvalidNonce) {
+			t.Fatalf("expected ErrInvalidNonce without a nonce, got %v", err)
+		}
+		resp, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "ZXh0ZXJuYWw=", Nonce: "abc12345"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !strings.HasSuffix(resp.SignedData, "_abc12345") || resp.Nonce != "abc12345" {
+			t.Errorf("expected nonce bound into the attestation, got %+v", resp)
+		}
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Nonce: "abc12345"}); !errors.Is(err, ErrNonceReused) {
+			t.Errorf("expected the attestation nonce to be remembered, got %v", err)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("reused nonce is rejected", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-002", Algorithm: "ECC", RequireNonce: true})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first", Nonce: "abc12345"})
+
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "second", Nonce: "abc12345"})
+		if !errors.Is(err, ErrNonceReused) {
+			t.Errorf("expected ErrNonceReused, got %v", err)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("nonce policy", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		required, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-003", Algorithm: "ECC", RequireNonce: true})
+		plain, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-004", Algorithm: "ECC"})
+
+		cases := []model.SignDataOptions{
+			{DeviceID: required.ID, Data: "payload"},
+			{DeviceID: required.ID, Data: "payload", Nonce: "short"},
+			{DeviceID: required.ID, Data: "payload", Nonce: "abc_12345"},
+			{DeviceID: plain.ID, Data: "payload", Nonce: "abc12345"},
+		}
+		for _, opts := range cases {
+			if _, err := service.SignData(opts); !errors.Is(err, ErrInvalidNonce) {
+				t.Errorf("expected ErrInvalidNonce for %+v, got %v", opts, err)
+			}
+		}
+	})
+
+	t.Run("window forgets the oldest nonces", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithNonceWindow(2))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-nonce-005", Algorithm: "ECC", RequireNonce: true})
+		for _, nonce := range []string{"nonce0001", "nonce0002", "nonce0003"} {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Nonce: nonce})
+		}
+
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Nonce: "nonce0003"}); !errors.Is(err, ErrNonceReused) {
+			t.Errorf("expected recent nonce to be rejected, got %v", err)
+		}
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Nonce: "nonce0001"}); err != nil {
+			t.Errorf("expected evicted nonce to be accepted, got %v", err)
+		}
+		if window := service.nonces[device.ID]; len(window.seen) != 2 {
+			t.Errorf("expected 2 remembered nonces, got %d", len(window.seen))
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// Defaults for the records that make SignData idempotent per operation ID.
+const (
+	DefaultOperationRecords   = 10000
+	DefaultOperationRecordTTL = 24 * time.Hour
+)
+
+// MaxOperationIDLength caps the length, in bytes, of a client-supplied operation ID.
+const MaxOperationIDLength = 128
+
+// ValidateOperationID checks that id has an accepted length and contains only printable ASCII.
+func ValidateOperationID(id string) error {
+	if len(id) > MaxOperationIDLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrInvalidOperationID, MaxOperationIDLength)
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return fmt.Errorf("%w: must contain only printable ASCII without spaces", ErrInvalidOperationID)
+		}
+	}
+	return nil
+}
+
+type operationKey struct {
+	deviceID    string
+	operationID string
+}
+
+// operationRequest holds the fields of a sign request that shape its response; a retry must
+// match them all to be answered with the recorded response.
+type operationRequest struct {
+	data            string
+	format          string
+	aad             string
+	nonce           string
+	purpose         string
+	expiresIn       int64
+	expectedCounter int64
+	hasExpected     bool
+}
+
+func newOperationRequest(opts model.SignDataOptions) operationRequest {
+	req := operationRequest{
+		data:      opts.Data,
+		format:    opts.Format,
+		aad:       opts.AAD,
+		nonce:     opts.Nonce,
+		purpose:   opts.Purpose,
+		expiresIn: opts.ExpiresIn,
+	}
+	if opts.ExpectedCounter != nil {
+		req.expectedCounter, req.hasExpected = *opts.ExpectedCounter, true
+	}
+	return req
+}
+
+type operationRecord struct {
+	key     operationKey
+	req     operationRequest
+	resp    model.SignDataResponse
+	expires time.Time
+}
+
+// operationLog remembers the response of recent operations so a retried sign request gets the
+// original signature instead of a new one. It holds at most size records, evicting the oldest,
+// and forgets records once their TTL passes. Guarded by the service's mu.
+type operationLog struct {
+	size    int
+	ttl     time.Duration
+	order   *list.List // Front is the most recently recorded operation
+	records map[operationKey]*list.Element
+}
+
+func newOperationLog(size int, ttl time.Duration) *operationLog {
+	return &operationLog{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		records: make(map[operationKey]*list.Element),
+	}
+}
+
+// get returns the record for key unless it is missing or expired at now.
+func (l *operationLog) get(key operationKey, now time.Time) (*operationRecord, bool) {
+	element, ok := l.records[key]
+	if !ok {
+		return nil, false
+	}
+	record := element.Value.(*operationRecord)
+	if l.ttl > 0 && !now.Before(record.expires) {
+		l.order.Remove(element)
+		delete(l.records, key)
+		return nil, false
+	}
+	return record, true
+}
+
+// put records the response of an operation, evicting the oldest record when full.
+func (l *operationLog) put(key operationKey, req operationRequest, resp *model.SignDataResponse, now time.Time) {
+	record := &operationRecord{key: key, req: req, resp: *resp, expires: now.Add(l.ttl)}
+	if element, ok := l.records[key]; ok {
+		element.Value = record
+		l.order.MoveToFront(element)
+		return
+	}
+	l.records[key] = l.order.PushFront(record)
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.records, oldest.Value.(*operationRecord).key)
+	}
+}
+
+// replayOperation returns the recorded response of a previous sign request with the same
+// operation ID on the device, or nil if there is none. A retry must carry the same data, format,
+// AAD, nonce, purpose, expires_in and expected counter as the original request, otherwise it
+// fails with ErrOperationConflict. Callers must hold s.mu.
+func (s *SignatureDeviceService) replayOperation(deviceID string, opts model.SignDataOptions) (*model.SignDataResponse, error) {
+	if opts.OperationID == "" || s.operations == nil {
+		return nil, nil
+	}
+	record, ok := s.operations.get(operationKey{deviceID, opts.OperationID}, s.clock.Now())
+	if !ok {
+		return nil, nil
+	}
+	if record.req != newOperationRequest(opts) {
+		return nil, ErrOperationConflict
+	}
+	resp := record.resp
+	return &resp, nil
+}
+
+// recordOperation remembers the response of a successful sign request carrying an operation ID.
+// Callers must hold s.mu.
+func (s *SignatureDeviceService) recordOperation(deviceID string, opts model.SignDataOptions, resp *model.SignDataResponse) {
+	if opts.OperationID == "" || s.operations == nil {
+		return
+	}
+	s.operations.put(operationKey{deviceID, opts.OperationID}, newOperationRequest(opts), resp, s.clock.Now())
+}
+
+// forget drops every record of the device.
+func (l *operationLog) forget(deviceID string) {
+	for element := l.order.Front(); element != nil; {
+		next := element.Next()
+		if record := element.Value.(*operationRecord); record.key.deviceID == deviceID {
+			l.order.Remove(element)
+			delete(l.records, record.key)
+		}
+		element = next
+	}
+}
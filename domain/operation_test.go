@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSignDataOperationID(t *testing.T) {
+	t.Run("retry returns the original signature", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-001", Algorithm: "ECC"})
+
+		opts := model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-1"}
+		first, err := service.SignData(opts)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		retry, err := service.SignData(opts)
+		if err != nil {
+			t.Fatalf("expected no error on retry, got %v", err)
+		}
+		if retry.Signature != first.Signature || retry.SignedData != first.SignedData {
+			t.Errorf("expected the original response, got %+v and %+v", first, retry)
+		}
+		if device.SignatureCounter != 1 || len(device.History) != 1 {
+			t.Errorf("expected counter 1 and one record, got %d and %d", device.SignatureCounter, len(device.History))
+		}
+	})
+
+	t.Run("distinct operation IDs sign separately", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-002", Algorithm: "ECC"})
+
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-1"})
+		resp, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-2"})
+		if !strings.HasPrefix(resp.SignedData, "1_") || device.SignatureCounter != 2 {
+			t.Errorf("expected a second signature at counter 1, got %q", resp.SignedData)
+		}
+	})
+
+	t.Run("retry with different data conflicts", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-003", Algorithm: "ECC"})
+
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-1"})
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "other", OperationID: "op-1"})
+		if !errors.Is(err, ErrOperationConflict) {
+			t.Errorf("expected ErrOperationConflict, got %v", err)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("retry with other response-shaping fields conflicts", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-007", Algorithm: "ECC"})
+		expected := int64(0)
+		original := model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-1", ExpectedCounter: &expected}
+		if _, err := service.SignData(original); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		otherCounter := int64(1)
+		for name, modify := range map[string]func(*model.SignDataOptions){
+			"format":           func(o *model.SignDataOptions) { o.Format = model.SignatureFormatTagged },
+			"nonce":            func(o *model.SignDataOptions) { o.Nonce = "nonce-1" },
+			"purpose":          func(o *model.SignDataOptions) { o.Purpose = "invoice" },
+			"expires_in":       func(o *model.SignDataOptions) { o.ExpiresIn = 60 },
+			"expected counter": func(o *model.SignDataOptions) { o.ExpectedCounter = &otherCounter },
+			"no counter":       func(o *model.SignDataOptions) { o.ExpectedCounter = nil },
+		} {
+			retry := original
+			modify(&retry)
+			if _, err := service.SignData(retry); !errors.Is(err, ErrOperationConflict) {
+				t.Errorf("%s: expected ErrOperationConflict, got %v", name, err)
+			}
+		}
+
+		sameCounter := int64(0)
+		retry := original
+		retry.ExpectedCounter = &sameCounter
+		if _, err := service.SignData(retry); err != nil {
+			t.Errorf("expected an identical retry to replay, got %v", err)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("records expire after the TTL", func(t *testing.T) {
+		clock := newFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock), WithOperationRecords(10, time.Minute))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-004", Algorithm: "ECC"})
+
+		opts := model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-1"}
+		service.SignData(opts)
+		clock.Advance(time.Minute)
+		service.SignData(opts)
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected an expired operation to sign again, got counter %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("oldest records are evicted when full", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithOperationRecords(2, 0))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-005", Algorithm: "ECC"})
+
+		for _, id := range []string{"op-1", "op-2", "op-3", "op-3", "op-2"} {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: id})
+		}
+		if device.SignatureCounter != 3 {
+			t.Fatalf("expected counter 3, got %d", device.SignatureCounter)
+		}
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: "op-1"})
+		if device.SignatureCounter != 4 {
+			t.Errorf("expected the evicted operation to sign again, got counter %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("invalid operation ID", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-op-006", Algorithm: "ECC"})
+
+		for _, id := range []string{"op 1", strings.Repeat("a", MaxOperationIDLength+1)} {
+			_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", OperationID: id})
+			if !errors.Is(err, ErrInvalidOperationID) {
+				t.Errorf("expected ErrInvalidOperationID for %q, got %v", id, err)
+			}
+		}
+	})
+}
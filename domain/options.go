@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+// ServiceOption configures optional behavior of a SignatureDeviceService.
+type ServiceOption func(*SignatureDeviceService)
+
+// KeyGenerationDefaults holds the parameters applied when a create request omits them.
+// Zero values fall back to the crypto package defaults.
+type KeyGenerationDefaults struct {
+	Algorithm  string
+	RSAKeySize int
+	ECCCurve   string
+}
+
+// Validate reports the first invalid default, so misconfiguration can fail fast at startup.
+func (d KeyGenerationDefaults) Validate() error {
+	if d.Algorithm != "" && d.Algorithm != "RSA" && d.Algorithm != "ECC" {
+		return fmt.Errorf("invalid default algorithm: %s", d.Algorithm)
+	}
+	if d.RSAKeySize != 0 {
+		if err := signingcrypto.ValidateRSAKeySize(d.RSAKeySize); err != nil {
+			return fmt.Errorf("invalid default RSA key size: %w", err)
+		}
+	}
+	if d.ECCCurve != "" {
+		if _, err := signingcrypto.ParseCurve(d.ECCCurve); err != nil {
+			return fmt.Errorf("invalid default ECC curve: %w", err)
+		}
+	}
+	return nil
+}
+
+// WithKeyGenerationDefaults sets the algorithm, RSA key size, and ECC curve used when a
+// CreateDevice call leaves them empty. Callers should Validate the defaults beforehand.
+func WithKeyGenerationDefaults(defaults KeyGenerationDefaults) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.keyDefaults = defaults
+	}
+}
+
+// WithClock replaces the system clock used for every timestamp the service records,
+// e.g. with a fake clock in tests.
+func WithClock(clock Clock) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.clock = clock
+	}
+}
+
+// WithVerifyCache caches up to size verification outcomes keyed by (device, signed_data, signature).
+// A ttl of zero keeps entries until they are evicted by newer ones. A size of zero disables the cache.
+func WithVerifyCache(size int, ttl time.Duration) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		if size > 0 {
+			s.verifyCache = newVerifyCache(size, ttl)
+		}
+	}
+}
+
+// WithMaxConcurrentSigns bounds the number of SignData calls in flight. A call that cannot
+// acquire a slot within wait fails with ErrSigningCapacity. A limit of zero leaves signing unbounded.
+func WithMaxConcurrentSigns(limit int, wait time.Duration) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		if limit > 0 {
+			s.signSlots = make(chan struct{}, limit)
+			s.signSlotWait = wait
+		}
+	}
+}
+
+// DefaultDeviceIDPattern is a conservative ID policy: 1-64 ASCII letters, digits, '_' or '-'.
+const DefaultDeviceIDPattern = `^[a-zA-Z0-9_-]{1,64}$`
+
+// WithDeviceIDPolicy makes CreateDevice reject IDs that do not match pattern with
+// ErrInvalidDeviceID. A nil pattern leaves IDs unvalidated.
+func WithDeviceIDPolicy(pattern *regexp.Regexp) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.deviceIDPattern = pattern
+	}
+}
+
+// WithPrintableOnly makes CreateDevice reject IDs and labels containing non-printable
+// characters, such as newlines and other control characters, with ErrInvalidDeviceID and
+// ErrInvalidLabel. By default any characters are accepted.
+func WithPrintableOnly(enabled bool) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.printableOnly = enabled
+	}
+}
+
+// WithIDStrategy generates the ID of devices created without one, using IDStrategyUUID or
+// IDStrategyTimeOrdered. Callers should ValidateIDStrategy beforehand; IDStrategyNone keeps
+// empty IDs as given.
+func WithIDStrategy(strategy string) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.idStrategy = strategy
+	}
+}
+
+// WithIDGenerator generates the ID of devices created without one using generate, in place of
+// the configured ID strategy, e.g. to plug in an ID scheme of the deployment.
+func WithIDGenerator(generate func() (string, error)) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.generateID = generate
+	}
+}
+
+// WithDefaultLabelTemplate labels devices created without a label by expanding template, e.g.
+// "device-{id}" or "{algorithm}-device". Callers should ValidateLabelTemplate beforehand; the
+// empty template keeps such labels empty.
+func WithDefaultLabelTemplate(template string) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.labelTemplate = template
+	}
+}
+
+// WithReadOnly runs the service as a read-only replica: devices can be listed, fetched and
+// verified against, but CreateDevice, SignData and AttestSignature fail with ErrReadOnly.
+func WithReadOnly(readOnly bool) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.readOnly = readOnly
+	}
+}
+
+// WithRejectEmptyData makes SignData fail with ErrEmptyData for empty data. By default empty
+// data is signed, producing signed_data "<counter>__<last_signature>" with an empty middle segment.
+func WithRejectEmptyData(reject bool) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.rejectEmptyData = reject
+	}
+}
+
+// WithKeyGenerationPool runs CreateDevice key generation on workers goroutines fed by a queue
+// holding up to queue pending requests; creates beyond that fail with ErrKeyGenQueueFull.
+// A worker count of zero keeps key generation on the request goroutine.
+func WithKeyGenerationPool(workers, queue int) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		if workers > 0 {
+			s.keygen = newKeygenPool(workers, queue)
+		}
+	}
+}
+
+// WithMaxHistoryEntries caps the retained signature history of devices that do not set their
+// own limit. Once exceeded, the oldest records are pruned. A limit of zero keeps every record.
+func WithMaxHistoryEntries(limit int) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.maxHistoryEntries = limit
+	}
+}
+
+// WithNonceWindow sets how many used nonces are remembered per device requiring nonces.
+// Older nonces are forgotten and would be accepted again. Sizes below one keep the default.
+func WithNonceWindow(size int) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		if size > 0 {
+			s.nonceWindowSize = size
+		}
+	}
+}
+
+// WithOperationRecords sets how many sign responses are remembered by operation ID, and for
+// how long, to answer retried requests. A ttl of zero keeps records until they are evicted by
+// newer ones. A size of zero disables idempotent signing, so operation IDs are ignored.
+func WithOperationRecords(size int, ttl time.Duration) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		if size > 0 {
+			s.operations = newOperationLog(size, ttl)
+		} else {
+			s.operations = nil
+		}
+	}
+}
+
+// DefaultMaxSignDataLength is the default cap, in bytes, on data accepted by SignData.
+const DefaultMaxSignDataLength = 1 << 20
+
+// WithMaxSignDataLength caps the UTF-8 encoded length of the data, AAD, nonce and purpose
+// accepted by SignData; a longer one fails with ErrDataTooLarge. A limit of zero or less removes the cap.
+func WithMaxSignDataLength(limit int) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.maxSignDataLength = limit
+	}
+}
+
+// DefaultMaxIntrospectScan is the default number of devices Introspect scans without a KID.
+const DefaultMaxIntrospectScan = 1000
+
+// WithMaxIntrospectScan caps how many devices Introspect scans when no KID is given; a tenant
+// with more devices must name one, or introspection fails with ErrInvalidIntrospection. A
+// limit of zero or less removes the cap.
+func WithMaxIntrospectScan(limit int) ServiceOption {
+	return func(s *SignatureDeviceService) {
+		s.maxIntrospectScan = limit
+	}
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// checkPrintable reports the first character of value that is not printable, such as a
+// newline or another control character, which would corrupt logs and some storage backends.
+// Invalid UTF-8 is rejected as well.
+func checkPrintable(value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("must be valid UTF-8")
+	}
+	for i, r := range value {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("contains non-printable character %U at byte %d", r, i)
+		}
+	}
+	return nil
+}
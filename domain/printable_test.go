@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestPrintableOnly(t *testing.T) {
+	t.Run("rejects non-printable labels and IDs when enabled", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithPrintableOnly(true))
+
+		cases := []struct {
+			opts     model.CreateDeviceOptions
+			expected error
+		}{
+			{model.CreateDeviceOptions{ID: "device-print-001", Label: "Till\n3"}, ErrInvalidLabel},
+			{model.CreateDeviceOptions{ID: "device-print-002", Label: "Till\x073"}, ErrInvalidLabel},
+			{model.CreateDeviceOptions{ID: "device-print-003", Label: "Till \xff"}, ErrInvalidLabel},
+			{model.CreateDeviceOptions{ID: "device\nprint-004", Label: "Till 4"}, ErrInvalidDeviceID},
+			{model.CreateDeviceOptions{ID: "device\x00print-005", Label: "Till 5"}, ErrInvalidDeviceID},
+		}
+		for _, tc := range cases {
+			tc.opts.Algorithm = "ECC"
+			if _, err := service.CreateDevice(tc.opts); !errors.Is(err, tc.expected) {
+				t.Errorf("%q/%q: expected %v, got %v", tc.opts.ID, tc.opts.Label, tc.expected, err)
+			}
+		}
+	})
+
+	t.Run("accepts printable text when enabled", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithPrintableOnly(true))
+
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-print-006", Label: "Kasse Süd #3", Algorithm: "ECC"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("lenient by default", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-print-007", Label: "Till\n7", Algorithm: "ECC"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
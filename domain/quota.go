@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// ValidateQuota checks a device signing quota: a positive quota needs a positive window, and
+// a zero quota, meaning no limit, must not set one.
+func ValidateQuota(quota int, window time.Duration) error {
+	switch {
+	case quota < 0:
+		return fmt.Errorf("%w: quota %d must not be negative", ErrInvalidQuota, quota)
+	case quota > 0 && window <= 0:
+		return fmt.Errorf("%w: quota of %d needs a positive window", ErrInvalidQuota, quota)
+	case quota == 0 && window != 0:
+		return fmt.Errorf("%w: window set without a quota", ErrInvalidQuota)
+	}
+	return nil
+}
+
+// quotaWindow holds the times of a device's signatures within the sliding quota window ending
+// now, oldest first. A signature counts against the quota for exactly one window length after
+// it is made, so no span of that length ever holds more signatures than the quota.
+type quotaWindow struct {
+	signedAt []time.Time
+}
+
+// slide forgets the signatures made a full window or longer before now.
+func (w *quotaWindow) slide(now time.Time, length time.Duration) {
+	expired := 0
+	for expired < len(w.signedAt) && !now.Before(w.signedAt[expired].Add(length)) {
+		expired++
+	}
+	w.signedAt = w.signedAt[expired:]
+}
+
+// used returns the number of signatures in the window.
+func (w *quotaWindow) used() int {
+	return len(w.signedAt)
+}
+
+// record counts a signature made at signedAt against the quota.
+func (w *quotaWindow) record(signedAt time.Time) {
+	w.signedAt = append(w.signedAt, signedAt)
+}
+
+// quotaWindow returns the device's quota window slid to now, or ErrQuotaExceeded if the quota
+// for it is used up. Devices without a quota get a nil window. Callers must hold s.mu and
+// record a successful signature in the window themselves.
+func (s *SignatureDeviceService) quotaWindow(device *model.SignatureDevice, now time.Time) (*quotaWindow, error) {
+	if device.SignQuota <= 0 {
+		return nil, nil
+	}
+	window, ok := s.quotas[device.ID]
+	if !ok {
+		window = &quotaWindow{}
+		s.quotas[device.ID] = window
+	}
+	window.slide(now, device.QuotaWindow)
+	if window.used() >= device.SignQuota {
+		// The next signature is allowed once the oldest one leaves the window.
+		resetIn := window.signedAt[0].Add(device.QuotaWindow).Sub(now)
+		return nil, fmt.Errorf("%w: %d signatures per %s, resets in %s", ErrQuotaExceeded,
+			device.SignQuota, device.QuotaWindow, resetIn.Round(time.Second))
+	}
+	return window, nil
+}
+
+// AtRiskDevices lists the devices whose signing quota has at most threshold, a fraction of the
+// quota, left in the sliding window ending now, those closest to exhaustion first. Devices
+// without a quota are never at risk. A device's window resets when its oldest signature in the
+// window leaves it and frees a signature.
+func (s *SignatureDeviceService) AtRiskDevices(threshold float64) ([]model.AtRiskDevice, error) {
+	devices, err := s.storage.GetAllDevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all devices: %w", err)
+	}
+
+	now := s.clock.Now()
+	atRisk := []model.AtRiskDevice{}
+	s.mu.Lock()
+	for _, device := range devices {
+		if device.SignQuota <= 0 {
+			continue
+		}
+		used, resetsAt := 0, now.Add(device.QuotaWindow)
+		if window, ok := s.quotas[device.ID]; ok {
+			window.slide(now, device.QuotaWindow)
+			if window.used() > 0 {
+				used, resetsAt = window.used(), window.signedAt[0].Add(device.QuotaWindow)
+			}
+		}
+		remaining := device.SignQuota - used
+		if float64(remaining) > threshold*float64(device.SignQuota) {
+			continue
+		}
+		atRisk = append(atRisk, model.AtRiskDevice{
+			ID:             device.ID,
+			SignQuota:      device.SignQuota,
+			QuotaUsed:      used,
+			QuotaRemaining: remaining,
+			WindowResetsAt: resetsAt,
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(atRisk, func(i, j int) bool {
+		if atRisk[i].QuotaRemaining != atRisk[j].QuotaRemaining {
+			return atRisk[i].QuotaRemaining < atRisk[j].QuotaRemaining
+		}
+		return atRisk[i].ID < atRisk[j].ID
+	})
+	return atRisk, nil
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestSignQuota(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("exhausted quota recovers after the window", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID: "device-quota-001", Algorithm: "ECC", SignQuota: 2, QuotaWindow: time.Hour,
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for expected := 1; expected >= 0; expected-- {
+			resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if resp.QuotaRemaining == nil || *resp.QuotaRemaining != expected {
+				t.Errorf("expected %d remaining, got %v", expected, resp.QuotaRemaining)
+			}
+		}
+
+		clock.Advance(59 * time.Minute)
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected rejected signature to leave counter at 2, got %d", device.SignatureCounter)
+		}
+
+		clock.Advance(time.Minute)
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if err != nil {
+			t.Fatalf("expected quota to reset, got %v", err)
+		}
+		if *resp.QuotaRemaining != 1 {
+			t.Errorf("expected 1 remaining in the new window, got %d", *resp.QuotaRemaining)
+		}
+	})
+
+	t.Run("window slides across its boundary", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID: "device-quota-005", Algorithm: "ECC", SignQuota: 2, QuotaWindow: time.Hour,
+		})
+		sign := func() error {
+			_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			return err
+		}
+
+		if err := sign(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		clock.Advance(59 * time.Minute)
+		if err := sign(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Past the hour only the first signature has left the window, so a fixed window's
+		// burst of a full quota on either side of the boundary is refused.
+		clock.Advance(2 * time.Minute)
+		if err := sign(); err != nil {
+			t.Fatalf("expected the freed signature to be allowed, got %v", err)
+		}
+		err := sign()
+		if !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded across the boundary, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "resets in 58m0s") {
+			t.Errorf("expected a reset once the second signature leaves the window, got %v", err)
+		}
+	})
+
+	t.Run("attestations count against the quota", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(newFakeClock(start)))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-quota-006", Algorithm: "ECC", SignQuota: 2, QuotaWindow: time.Hour})
+
+		if _, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "ZXh0ZXJuYWw="}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if *resp.QuotaRemaining != 0 {
+			t.Errorf("expected the attestation to use quota, got %d remaining", *resp.QuotaRemaining)
+		}
+		if _, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "ZXh0ZXJuYWw="}); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected refused attestation to leave counter at 2, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("quotas are per device", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(newFakeClock(start)))
+		limited, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-quota-002", Algorithm: "ECC", SignQuota: 1, QuotaWindow: time.Hour})
+		other, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-quota-003", Algorithm: "ECC"})
+
+		service.SignData(model.SignDataOptions{DeviceID: limited.ID, Data: "payload"})
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: limited.ID, Data: "payload"}); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("expected ErrQuotaExceeded, got %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			resp, err := service.SignData(model.SignDataOptions{DeviceID: other.ID, Data: "payload"})
+			if err != nil {
+				t.Fatalf("expected unlimited device to sign, got %v", err)
+			}
+			if resp.QuotaRemaining != nil {
+				t.Errorf("expected no quota for unlimited device, got %d", *resp.QuotaRemaining)
+			}
+		}
+	})
+
+	t.Run("invalid quotas are rejected", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		for _, opts := range []model.CreateDeviceOptions{
+			{ID: "negative", Algorithm: "ECC", SignQuota: -1, QuotaWindow: time.Hour},
+			{ID: "no-window", Algorithm: "ECC", SignQuota: 10},
+			{ID: "no-quota", Algorithm: "ECC", QuotaWindow: time.Hour},
+		} {
+			if _, err := service.CreateDevice(opts); !errors.Is(err, ErrInvalidQuota) {
+				t.Errorf("%s: expected ErrInvalidQuota, got %v", opts.ID, err)
+			}
+		}
+	})
+
+	t.Run("at-risk devices exclude elapsed windows", func(t *testing.T) {
+		clock := newFakeClock(start)
+		service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-quota-004", Algorithm: "ECC", SignQuota: 2, QuotaWindow: time.Hour})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		atRisk, err := service.AtRiskDevices(0.5)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(atRisk) != 1 || !atRisk[0].WindowResetsAt.Equal(start.Add(time.Hour)) {
+			t.Fatalf("expected device at risk until the window resets, got %+v", atRisk)
+		}
+
+		clock.Advance(time.Hour)
+		if atRisk, _ := service.AtRiskDevices(0.5); len(atRisk) != 0 {
+			t.Errorf("expected no devices at risk after the window, got %+v", atRisk)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// Reconcile checks every stored device's chain head against its history: last_signature must
+// equal the newest record's signature, which must verify with the stored public key, and the
+// counter must follow that record. Devices that have not signed must hold the base-case
+// last_signature. Devices whose history was pruned away entirely are skipped. Each
+// discrepancy is counted under ErrorCategoryReconcile. Devices are checked one at a time
+// under the signing lock, so signing continues between them.
+func (s *SignatureDeviceService) Reconcile() (*model.ReconcileReport, error) {
+	devices, err := s.storage.GetAllDevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+
+	report := &model.ReconcileReport{Discrepancies: []model.ReconcileDiscrepancy{}}
+	for _, listed := range devices {
+		s.mu.Lock()
+		device, err := s.storage.GetDevice(listed.ID)
+		if err == nil {
+			device = snapshotDevice(device)
+		}
+		s.mu.Unlock()
+		if err != nil {
+			// Deleted since it was listed.
+			continue
+		}
+
+		report.Checked++
+		if err := reconcileDevice(device); err != nil {
+			s.errorCounts.inc(device.Algorithm, ErrorCategoryReconcile)
+			report.Discrepancies = append(report.Discrepancies, model.ReconcileDiscrepancy{
+				DeviceID: device.ID,
+				Error:    err.Error(),
+			})
+		}
+	}
+	return report, nil
+}
+
+// reconcileDevice reports the first inconsistency between the device's chain head and history.
+func reconcileDevice(device *model.SignatureDevice) error {
+	if len(device.History) == 0 {
+		initial := base64.StdEncoding.EncodeToString([]byte(device.ID))
+		if device.SignatureCounter == 0 && device.LastSignature != initial {
+			return fmt.Errorf("last_signature is not base64(device_id) although the device has not signed")
+		}
+		return nil
+	}
+
+	newest := device.History[len(device.History)-1]
+	if newest.Signature != device.LastSignature {
+		return fmt.Errorf("last_signature does not match the newest history entry (counter %d)", newest.Counter)
+	}
+	if newest.Counter != device.SignatureCounter-1 {
+		return fmt.Errorf("counter %d does not follow the newest history entry (counter %d)", device.SignatureCounter, newest.Counter)
+	}
+
+	verifier, err := storedKeyVerifier(device)
+	if err != nil {
+		return err
+	}
+	signature, err := signingcrypto.DecodeSignature(newest.Signature)
+	if err != nil {
+		return fmt.Errorf("newest history entry: %w", err)
+	}
+	if err := verifier.Verify([]byte(newest.SignedData), signature); err != nil {
+		return fmt.Errorf("newest history entry: %w", signingcrypto.ErrInvalidSignature)
+	}
+	return nil
+}
+
+// Reconciler runs Reconcile periodically in the background until stopped.
+type Reconciler struct {
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// StartReconciler runs a reconciliation pass every interval and hands each report to report,
+// e.g. to log discrepancies. Passes that fail to list devices are skipped. Call Stop on shutdown.
+func (s *SignatureDeviceService) StartReconciler(interval time.Duration, report func(*model.ReconcileReport)) *Reconciler {
+	r := &Reconciler{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(r.stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-r.done:
+				return
+			}
+			if result, err := s.Reconcile(); err == nil {
+				report(result)
+			}
+		}
+	}()
+	return r
+}
+
+// Stop ends the background reconciliation and waits for a running pass to finish.
+func (r *Reconciler) Stop() {
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+	<-r.stopped
+}
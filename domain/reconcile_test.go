@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Run("reports the inconsistent device", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		for _, id := range []string{"device-reconcile-001", "device-reconcile-002", "device-reconcile-003"} {
+			if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: id, Algorithm: "ECC"}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		for _, id := range []string{"device-reconcile-001", "device-reconcile-002"} {
+			if _, err := service.SignData(model.SignDataOptions{DeviceID: id, Data: "payload"}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		storage.devices["device-reconcile-002"].LastSignature = "tampered"
+
+		report, err := service.Reconcile()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Checked != 3 {
+			t.Errorf("expected 3 devices checked, got %d", report.Checked)
+		}
+		if len(report.Discrepancies) != 1 || report.Discrepancies[0].DeviceID != "device-reconcile-002" {
+			t.Fatalf("expected only device-reconcile-002 reported, got %+v", report.Discrepancies)
+		}
+
+		stats, _ := service.SignatureStats(0)
+		if got := stats.Errors["ECC"][ErrorCategoryReconcile]; got != 1 {
+			t.Errorf("expected 1 ECC reconcile discrepancy, got %d", got)
+		}
+	})
+
+	t.Run("consistent store reports nothing", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-reconcile-004", Algorithm: "RSA"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "second"})
+
+		report, err := service.Reconcile()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(report.Discrepancies) != 0 {
+			t.Errorf("expected no discrepancies, got %+v", report.Discrepancies)
+		}
+	})
+
+	t.Run("background job reports each pass until stopped", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-reconcile-005", Algorithm: "ECC"})
+		storage.devices["device-reconcile-005"].LastSignature = "tampered"
+
+		reports := make(chan *model.ReconcileReport, 1)
+		reconciler := service.StartReconciler(time.Millisecond, func(report *model.ReconcileReport) {
+			select {
+			case reports <- report:
+			default:
+			}
+		})
+		defer reconciler.Stop()
+
+		select {
+		case report := <-reports:
+			if len(report.Discrepancies) != 1 {
+				t.Errorf("expected 1 discrepancy, got %d", len(report.Discrepancies))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected a reconciliation pass, got none")
+		}
+		reconciler.Stop()
+	})
+}
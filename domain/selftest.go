@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// SelfTestDeviceID is the ID of the throwaway devices SelfTest signs with. It only appears in
+// their signed payloads and is never stored.
+const SelfTestDeviceID = "self-test"
+
+// SelfTestPayload is the fixed data SelfTest signs.
+const SelfTestPayload = "signing-service self-test"
+
+// SelfTest checks the crypto path end to end for every algorithm: it generates a throwaway key
+// pair with the configured key generation defaults, signs SelfTestPayload as the first link of
+// a fresh chain and verifies the signature against the public key. Nothing is stored,
+// published or counted, and key generation bypasses the keygen pool so a busy pool cannot
+// fail the test.
+func (s *SignatureDeviceService) SelfTest() *model.SelfTestReport {
+	report := &model.SelfTestReport{OK: true}
+	for _, algorithm := range []string{"RSA", "ECC"} {
+		start := time.Now()
+		err := s.selfTest(algorithm)
+		result := model.SelfTestResult{
+			Algorithm:  algorithm,
+			OK:         err == nil,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Error = err.Error()
+			report.OK = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// selfTest runs the sign-and-verify round trip for one algorithm.
+func (s *SignatureDeviceService) selfTest(algorithm string) error {
+	var signer signingcrypto.Signer
+	var publicKey interface{}
+	switch algorithm {
+	case "RSA":
+		keyPair, err := (&signingcrypto.RSAGenerator{Bits: s.keyDefaults.RSAKeySize}).Generate()
+		if err != nil {
+			return fmt.Errorf("failed to generate RSA key pair: %w", err)
+		}
+		signer, publicKey = signingcrypto.NewRSASigner(keyPair.Private), keyPair.Public
+	case "ECC":
+		curveName := s.keyDefaults.ECCCurve
+		if curveName == "" {
+			curveName = signingcrypto.DefaultECCCurve
+		}
+		curve, err := signingcrypto.ParseCurve(curveName)
+		if err != nil {
+			return err
+		}
+		keyPair, err := (&signingcrypto.ECCGenerator{Curve: curve}).Generate()
+		if err != nil {
+			return fmt.Errorf("failed to generate ECC key pair: %w", err)
+		}
+		signer, publicKey = signingcrypto.NewECDSASigner(keyPair.Private), keyPair.Public
+	default:
+		return fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	initialSignature := base64.StdEncoding.EncodeToString([]byte(SelfTestDeviceID))
+	signedData := []byte(FormatSignedData(0, SelfTestPayload, initialSignature, DefaultSeparator))
+	signature, err := signer.Sign(signedData)
+	if err != nil {
+		return fmt.Errorf("failed to sign: %w", err)
+	}
+	if err := signingcrypto.VerifySignature(publicKey, signedData, signature); err != nil {
+		return fmt.Errorf("failed to verify: %w", err)
+	}
+	return nil
+}
@@ -1,9 +1,18 @@
 package domain
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
 	model "github.com/bayuhutajulu/signing-service/model"
@@ -12,102 +21,653 @@ import (
 // SignatureDeviceService orchestrates device creation, signature generation with chaining,
 // and device retrieval. Uses a mutex to ensure atomic counter increments across concurrent requests.
 type SignatureDeviceService struct {
-	storage DeviceStorage
-	mu      sync.Mutex // Serializes signing operations to prevent counter gaps
+	storage           DeviceStorage
+	mu                sync.Mutex // Serializes signing operations to prevent counter gaps
+	totalSignatures   atomic.Int64
+	keyDefaults       KeyGenerationDefaults
+	verifyCache       *verifyCache
+	signSlots         chan struct{} // Semaphore bounding concurrent SignData calls; nil when unbounded
+	signSlotWait      time.Duration
+	deviceIDPattern   *regexp.Regexp // Optional ID policy enforced by CreateDevice; nil accepts any ID
+	printableOnly     bool           // Reject IDs and labels with non-printable characters
+	subMu             sync.RWMutex
+	subscribers       map[chan<- SignEvent]struct{}
+	readOnly          bool
+	maxSignDataLength int
+	rejectEmptyData   bool
+	errorCounts       errorCounters
+	keygen            *keygenPool // Bounded pool for key generation; nil generates inline
+	clock             Clock
+	maxHistoryEntries int // Service-wide history retention; zero keeps every record
+	flags             featureFlags
+	nonces            map[string]*nonceWindow // Recently used nonces per device; guarded by mu
+	nonceWindowSize   int
+	quotas            map[string]*quotaWindow // Sliding signing quota window per device; guarded by mu
+	operations        *operationLog           // Responses of recent sign requests by operation ID; guarded by mu
+	preSignHook       PreSignHook             // Policy check before signing; nil allows everything
+	postSignHook      PostSignHook            // Side effects after signing; nil does nothing
+	idStrategy        string
+	generateID        func() (string, error) // Generates IDs for devices created without one; nil keeps them empty
+	labelTemplate     string                 // Label applied to devices created without one; empty keeps it empty
+	maxIntrospectScan int                    // Devices Introspect may scan without a KID; zero scans any number
 }
 
 // NewSignatureDeviceService creates a service with the given storage implementation.
-func NewSignatureDeviceService(storage DeviceStorage) *SignatureDeviceService {
-	return &SignatureDeviceService{
-		storage: storage,
+// Options configure optional behavior; without them the service keeps its defaults.
+func NewSignatureDeviceService(storage DeviceStorage, opts ...ServiceOption) *SignatureDeviceService {
+	s := &SignatureDeviceService{
+		storage:           storage,
+		maxSignDataLength: DefaultMaxSignDataLength,
+		maxIntrospectScan: DefaultMaxIntrospectScan,
+		clock:             realClock{},
+		nonces:            make(map[string]*nonceWindow),
+		quotas:            make(map[string]*quotaWindow),
+		nonceWindowSize:   DefaultNonceWindow,
+		operations:        newOperationLog(DefaultOperationRecords, DefaultOperationRecordTTL),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.verifyCache != nil {
+		s.verifyCache.now = s.clock.Now
+	}
+	if s.generateID == nil {
+		s.generateID = newIDGenerator(s.idStrategy, s.clock.Now)
+	}
+	return s
 }
 
 // CreateDevice generates a new signature device with a cryptographic key pair.
 // Validates algorithm (RSA/ECC), generates keys, initializes counter to 0, and sets
 // last_signature to base64(device_id) for the base case. Persists device to storage.
+// Deterministic selects RFC 6979 nonces for ECC; RSA PKCS#1 v1.5 is deterministic already.
+// Separator defaults to "_" and must be a single character accepted by ValidateSeparator.
+// CounterEncoding defaults to decimal; padded and hex change how the counter is rendered.
+// Empty Algorithm, KeySize, and Curve fall back to the service's key generation defaults.
+// An empty ID is replaced by a generated one when an ID strategy is configured; should it
+// collide with an existing device, a new ID is drawn, up to MaxGeneratedIDAttempts times.
+// Collisions of client-supplied IDs fail right away.
+// An empty Label is replaced by the expanded default label template, if one is configured.
+// When an ID policy is configured, non-conforming IDs fail with ErrInvalidDeviceID.
+// With printable-only validation enabled, IDs and labels holding non-printable characters
+// fail with ErrInvalidDeviceID and ErrInvalidLabel respectively.
+// With ImportPublicKeyPEM set, no key pair is generated: the device is verify-only, its
+// algorithm follows from the imported key, and signing operations fail with ErrVerifyOnly.
+// With ImportJWK set, the device uses that RSA or EC key instead: a private JWK makes a
+// signing device, a public one a verify-only device.
 func (s *SignatureDeviceService) CreateDevice(opts model.CreateDeviceOptions) (*model.SignatureDevice, error) {
-	if opts.Algorithm != "RSA" && opts.Algorithm != "ECC" {
-		return nil, fmt.Errorf("invalid algorithm: %s", opts.Algorithm)
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	if s.flags.creationDisabled.Load() {
+		return nil, ErrDeviceCreationDisabled
+	}
+	generatedID := opts.ID == "" && s.generateID != nil
+	if generatedID {
+		id, err := s.nextGeneratedID()
+		if err != nil {
+			return nil, err
+		}
+		opts.ID = id
+	} else if err := s.checkDeviceID(opts.ID); err != nil {
+		return nil, err
+	}
+	if s.printableOnly {
+		if err := checkPrintable(opts.Label); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidLabel, err)
+		}
 	}
 
-	var signer signingcrypto.Signer
-	var privateKey, publicKey interface{}
+	algorithm := opts.Algorithm
+	if algorithm == "" && opts.ImportPublicKeyPEM == "" && len(opts.ImportJWK) == 0 {
+		algorithm = s.keyDefaults.Algorithm
+	}
+	if opts.ImportPublicKeyPEM != "" && len(opts.ImportJWK) > 0 {
+		return nil, fmt.Errorf("%w: cannot import a PEM public key and a JWK at once", ErrInvalidJWK)
+	}
 
-	switch opts.Algorithm {
-	case "RSA":
-		generator := &signingcrypto.RSAGenerator{}
-		keyPair, err := generator.Generate()
+	var importedKey, importedPrivateKey interface{}
+	if opts.ImportPublicKeyPEM != "" {
+		key, keyAlgorithm, err := importPublicKey(opts.ImportPublicKeyPEM)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate RSA key pair: %w", err)
+			return nil, err
+		}
+		if algorithm != "" && algorithm != keyAlgorithm {
+			return nil, fmt.Errorf("%w: algorithm %s does not match imported %s key", ErrInvalidPublicKey, algorithm, keyAlgorithm)
+		}
+		algorithm = keyAlgorithm
+		importedKey = key
+	}
+	if len(opts.ImportJWK) > 0 {
+		key, keyAlgorithm, err := importJWK(opts.ImportJWK)
+		if err != nil {
+			return nil, err
+		}
+		if algorithm != "" && algorithm != keyAlgorithm {
+			return nil, fmt.Errorf("%w: algorithm %s does not match imported %s key", ErrInvalidJWK, algorithm, keyAlgorithm)
+		}
+		algorithm = keyAlgorithm
+		if isPrivateKey(key) {
+			importedPrivateKey = key
+		} else {
+			importedKey = key
+		}
+	}
+
+	if algorithm != "RSA" && algorithm != "ECC" {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	separator := opts.Separator
+	if separator == "" {
+		separator = DefaultSeparator
+	}
+	if err := ValidateSeparator(separator); err != nil {
+		return nil, err
+	}
+
+	counterEncoding := opts.CounterEncoding
+	if counterEncoding == "" {
+		counterEncoding = CounterEncodingDecimal
+	}
+	if err := ValidateCounterEncoding(counterEncoding); err != nil {
+		return nil, err
+	}
+
+	if opts.MaxHistoryEntries < 0 {
+		return nil, fmt.Errorf("invalid max history entries %d: must not be negative", opts.MaxHistoryEntries)
+	}
+	if err := ValidateQuota(opts.SignQuota, opts.QuotaWindow); err != nil {
+		return nil, err
+	}
+
+	var privateKey, publicKey interface{}
+
+	switch {
+	case importedPrivateKey != nil:
+		privateKey = importedPrivateKey
+		publicKey = importedPublicKey(importedPrivateKey)
+	case importedKey != nil:
+		publicKey = importedKey
+	case algorithm == "RSA":
+		keySize := opts.KeySize
+		if keySize == 0 {
+			keySize = s.keyDefaults.RSAKeySize
+		}
+		if keySize != 0 {
+			if err := signingcrypto.ValidateRSAKeySize(keySize); err != nil {
+				return nil, err
+			}
+		}
+
+		generator := &signingcrypto.RSAGenerator{Bits: keySize}
+		var keyPair *signingcrypto.RSAKeyPair
+		var genErr error
+		if err := s.keygen.run(func() { keyPair, genErr = generator.Generate() }); err != nil {
+			return nil, err
+		}
+		if genErr != nil {
+			s.errorCounts.inc(algorithm, ErrorCategoryKeyGen)
+			return nil, fmt.Errorf("failed to generate RSA key pair: %w", genErr)
 		}
 		privateKey = keyPair.Private
 		publicKey = keyPair.Public
-		signer = signingcrypto.NewRSASigner(keyPair.Private)
-	case "ECC":
-		generator := &signingcrypto.ECCGenerator{}
-		keyPair, err := generator.Generate()
+	case algorithm == "ECC":
+		curveName := opts.Curve
+		if curveName == "" {
+			curveName = s.keyDefaults.ECCCurve
+		}
+		if curveName == "" {
+			curveName = signingcrypto.DefaultECCCurve
+		}
+		curve, err := signingcrypto.ParseCurve(curveName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate ECC key pair: %w", err)
+			return nil, err
+		}
+
+		generator := &signingcrypto.ECCGenerator{Curve: curve}
+		var keyPair *signingcrypto.ECCKeyPair
+		var genErr error
+		if err := s.keygen.run(func() { keyPair, genErr = generator.Generate() }); err != nil {
+			return nil, err
+		}
+		if genErr != nil {
+			s.errorCounts.inc(algorithm, ErrorCategoryKeyGen)
+			return nil, fmt.Errorf("failed to generate ECC key pair: %w", genErr)
 		}
 		privateKey = keyPair.Private
 		publicKey = keyPair.Public
-		signer = signingcrypto.NewECDSASigner(keyPair.Private)
+	}
+
+	if err := checkKeyAlgorithm(algorithm, privateKey, publicKey); err != nil {
+		return nil, err
+	}
+
+	// Imported keys are held by signers outside the service too, which have always hashed
+	// with the default; generated keys follow the registry's hash policy.
+	hash := opts.Hash
+	if hash == "" && importedKey == nil && importedPrivateKey == nil {
+		hash = signingcrypto.PolicyHash(algorithm, signingcrypto.KeyCurve(publicKey))
+	}
+	if hash == "" {
+		hash = signingcrypto.DefaultHash
+	}
+	if err := signingcrypto.CheckHash(algorithm, hash, publicKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	signer, verifier, err := deviceKeys(privateKey, publicKey, hash, opts.Deterministic)
+	if err != nil {
+		return nil, err
+	}
+
+	label := opts.Label
+	if label == "" {
+		label = s.defaultLabel(opts.ID, algorithm)
 	}
 
 	initialSignature := base64.StdEncoding.EncodeToString([]byte(opts.ID))
 	device := &model.SignatureDevice{
-		ID:               opts.ID,
-		Label:            opts.Label,
-		Algorithm:        opts.Algorithm,
-		SignatureCounter: 0,
-		LastSignature:    initialSignature,
-		PublicKey:        publicKey,
-		PrivateKey:       privateKey,
-		Signer:           signer,
+		ID:                opts.ID,
+		Label:             label,
+		Algorithm:         algorithm,
+		SignatureCounter:  0,
+		LastSignature:     initialSignature,
+		PublicKey:         publicKey,
+		PrivateKey:        privateKey,
+		Signer:            signer,
+		Verifier:          verifier,
+		Deterministic:     opts.Deterministic,
+		Hash:              hash,
+		Separator:         separator,
+		CounterEncoding:   counterEncoding,
+		CreatedAt:         s.clock.Now(),
+		RejectDuplicates:  opts.RejectDuplicates,
+		MaxHistoryEntries: opts.MaxHistoryEntries,
+		RequireNonce:      opts.RequireNonce,
+		TenantID:          opts.TenantID,
+		SignQuota:         opts.SignQuota,
+		QuotaWindow:       opts.QuotaWindow,
 	}
 
-	err := s.storage.Save(device)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save device: %w", err)
+	for attempt := 1; ; attempt++ {
+		err := s.storage.Save(device)
+		if err == nil {
+			break
+		}
+		if !generatedID || !errors.Is(err, ErrDeviceExists) || attempt == MaxGeneratedIDAttempts {
+			s.errorCounts.inc(algorithm, ErrorCategoryStorage)
+			return nil, fmt.Errorf("failed to save device: %w", err)
+		}
+
+		// The generated ID is taken; the key pair does not depend on it, so only the ID
+		// and what derives from it change.
+		id, err := s.nextGeneratedID()
+		if err != nil {
+			return nil, err
+		}
+		device.ID = id
+		device.LastSignature = base64.StdEncoding.EncodeToString([]byte(id))
+		if opts.Label == "" {
+			device.Label = s.defaultLabel(id, algorithm)
+		}
 	}
 
+	s.publish(SignEvent{Type: EventTypeCreate, DeviceID: device.ID, Timestamp: device.CreatedAt})
+
 	return device, nil
 }
 
-// SignData generates a signature with chaining using format: "<counter>_<data>_<last_signature>".
+// nextGeneratedID draws a device ID from the configured generator and checks it against the
+// ID policy.
+func (s *SignatureDeviceService) nextGeneratedID() (string, error) {
+	id, err := s.generateID()
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkDeviceID(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// checkDeviceID enforces the printable-only validation and the configured ID policy, if any.
+func (s *SignatureDeviceService) checkDeviceID(id string) error {
+	if s.printableOnly {
+		if err := checkPrintable(id); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDeviceID, err)
+		}
+	}
+	if s.deviceIDPattern != nil && !s.deviceIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q must match %s", ErrInvalidDeviceID, id, s.deviceIDPattern)
+	}
+	return nil
+}
+
+// SignData generates a signature with chaining using format: "<counter>_<data>_<last_signature>",
+// where "_" is replaced by the device's configured separator. Any expiry, nonce and AAD follow
+// as further segments, in that order, binding the signature to them.
 // Uses the CURRENT counter value (starting from 0), signs the data, then increments counter.
 // The mutex ensures strictly monotonic counter increments without gaps during concurrent access.
+// When a concurrency limit is configured, a signing slot is acquired before anything else.
+// Data, AAD, nonce or purpose longer than the configured maximum (in UTF-8 bytes) fails with
+// ErrDataTooLarge. Empty data is signed as "<counter>__<last_signature>" unless the service
+// rejects it with ErrEmptyData.
+// Devices created with RejectDuplicates refuse data they signed before with ErrDuplicateData.
+// The format, nonce, expected counter, operation ID and expiry are documented on
+// SignDataOptions and the quota on the device's SignQuota; they fail with ErrInvalidNonce,
+// ErrNonceReused, ErrCounterMismatch, ErrOperationConflict, ErrInvalidExpiry and
+// ErrQuotaExceeded.
+// A configured pre-sign hook may refuse the request with ErrSignDenied before anything is
+// signed. A configured post-sign hook is called with each new signature once the signing lock
+// is released.
 func (s *SignatureDeviceService) SignData(opts model.SignDataOptions) (*model.SignDataResponse, error) {
+	return s.SignDataContext(context.Background(), opts)
+}
+
+// SignDataContext is SignData bounded by ctx: once ctx is cancelled or its deadline passes,
+// waiting for a signing slot stops and a signature not yet committed is discarded, leaving
+// the counter and storage untouched. The returned error then wraps ctx.Err().
+func (s *SignatureDeviceService) SignDataContext(ctx context.Context, opts model.SignDataOptions) (*model.SignDataResponse, error) {
+	resp, signed, err := s.signData(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if signed {
+		s.runPostSignHook(opts.DeviceID, resp)
+	}
+	return resp, nil
+}
+
+// signData implements SignDataContext, reporting whether a new signature was produced rather
+// than a recorded one replayed. The signing slot and lock are released when it returns.
+func (s *SignatureDeviceService) signData(ctx context.Context, opts model.SignDataOptions) (*model.SignDataResponse, bool, error) {
+	if opts.Format != "" && opts.Format != model.SignatureFormatCMS && opts.Format != model.SignatureFormatTagged &&
+		opts.Format != model.SignatureFormatMultibase {
+		return nil, false, fmt.Errorf("%w: %s", ErrUnsupportedFormat, opts.Format)
+	}
+	if s.readOnly {
+		return nil, false, ErrReadOnly
+	}
+	if s.flags.signingDisabled.Load() {
+		return nil, false, ErrSigningDisabled
+	}
+	if err := s.checkSignFieldLengths(opts); err != nil {
+		return nil, false, err
+	}
+	if s.rejectEmptyData && opts.Data == "" {
+		return nil, false, ErrEmptyData
+	}
+	if err := ValidateOperationID(opts.OperationID); err != nil {
+		return nil, false, err
+	}
+	if err := ValidateExpiresIn(opts.ExpiresIn); err != nil {
+		return nil, false, err
+	}
+	if err := checkBoundSegments(opts.Nonce, opts.AAD); err != nil {
+		return nil, false, err
+	}
+	if err := s.checkPreSignHook(opts.DeviceID, opts.Data); err != nil {
+		return nil, false, err
+	}
+
+	release, err := s.acquireSignSlot(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := ctx.Err(); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrSignAborted, err)
+	}
 	device, err := s.storage.GetDevice(opts.DeviceID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find device: %w", err)
+		return nil, false, fmt.Errorf("failed to find device: %w", err)
+	}
+	if resp, err := s.replayOperation(device.ID, opts); resp != nil || err != nil {
+		return resp, false, err
+	}
+
+	if opts.ExpectedCounter != nil && *opts.ExpectedCounter != device.SignatureCounter {
+		return nil, false, fmt.Errorf("%w: expected %d, device is at %d", ErrCounterMismatch, *opts.ExpectedCounter, device.SignatureCounter)
+	}
+	if device.RejectDuplicates && hasSignedData(device, opts.Data) {
+		return nil, false, ErrDuplicateData
+	}
+	if err := s.checkNonce(device, opts.Nonce); err != nil {
+		return nil, false, err
+	}
+	signedAt := s.clock.Now()
+	quota, err := s.quotaWindow(device, signedAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := s.prepareFormat(device, opts.Format); err != nil {
+		return nil, false, err
+	}
+
+	var expiresAt *time.Time
+	if opts.ExpiresIn > 0 {
+		// The expiry is signed with second precision, so truncate it the same way for the response.
+		expiry := time.Unix(signedAt.Unix()+opts.ExpiresIn, 0).UTC()
+		expiresAt = &expiry
+	}
+
+	record, err := s.signAndChain(ctx, device, model.RecordTypeSignature, opts.Data, opts.AAD, opts.Nonce, opts.Purpose, signedAt, expiresAt)
+	if err != nil {
+		return nil, false, err
+	}
+	if opts.Nonce != "" {
+		s.rememberNonce(device.ID, opts.Nonce)
 	}
 
+	resp := &model.SignDataResponse{
+		Signature:  record.Signature,
+		SignedData: record.SignedData,
+		DataHash:   dataHash(opts.Data),
+		AAD:        record.AAD,
+		Nonce:      record.Nonce,
+		Purpose:    record.Purpose,
+		ExpiresAt:  record.ExpiresAt,
+	}
+	if quota != nil {
+		quota.record(signedAt)
+		remaining := device.SignQuota - quota.used()
+		resp.QuotaRemaining = &remaining
+	}
+	if opts.Format == model.SignatureFormatCMS {
+		cms, err := s.detachedCMS(device, record.Signature)
+		if err != nil {
+			return nil, false, err
+		}
+		resp.CMS = cms
+	}
+	if opts.Format == model.SignatureFormatTagged {
+		tagged, err := signingcrypto.TagHashSignature(device.Algorithm, device.Hash, record.Signature)
+		if err != nil {
+			return nil, false, err
+		}
+		resp.TaggedSignature = tagged
+	}
+	if opts.Format == model.SignatureFormatMultibase {
+		signature, err := base64.StdEncoding.DecodeString(record.Signature)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decode signature: %w", err)
+		}
+		resp.MultibaseSignature = signingcrypto.EncodeMultibase(signature)
+	}
+	s.recordOperation(device.ID, opts, resp)
+	return resp, true, nil
+}
+
+// checkSignFieldLengths applies the configured maximum length to the data and to the AAD,
+// nonce and purpose stored alongside it in the history, failing with ErrDataTooLarge.
+func (s *SignatureDeviceService) checkSignFieldLengths(opts model.SignDataOptions) error {
+	if s.maxSignDataLength <= 0 {
+		return nil
+	}
+	for _, field := range []struct{ name, value string }{
+		{"data", opts.Data}, {"aad", opts.AAD}, {"nonce", opts.Nonce}, {"purpose", opts.Purpose},
+	} {
+		if len(field.value) > s.maxSignDataLength {
+			return fmt.Errorf("%w: %s of %d bytes exceeds %d", ErrDataTooLarge, field.name, len(field.value), s.maxSignDataLength)
+		}
+	}
+	return nil
+}
+
+// dataHash returns the hex SHA-256 of the raw data, letting clients correlate signatures
+// with content without parsing signed_data.
+func dataHash(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// signAndChain signs data with the device's current counter and last signature, advances the
+// chain, appends the record to the device history, persists the device, and notifies
+// subscribers. A non-nil expiry and a non-empty nonce are bound into the signed data ahead of
+// the AAD. The purpose only labels the record and is not part of the signed data. Callers must
+// hold s.mu.
+func (s *SignatureDeviceService) signAndChain(ctx context.Context, device *model.SignatureDevice, recordType, data, aad, nonce, purpose string, signedAt time.Time, expiresAt *time.Time) (*model.SignatureRecord, error) {
 	counter := device.SignatureCounter
-	dataToBeSigned := fmt.Sprintf("%d_%s_%s", counter, opts.Data, device.LastSignature)
+	lastSignature := device.LastSignature
+	if device.Signer == nil {
+		return nil, ErrVerifyOnly
+	}
+	if device.Locked {
+		return nil, ErrDeviceLocked
+	}
+	// Requests never choose the algorithm; guard against a device whose own key disagrees
+	// with the algorithm it reports, so signatures always match the advertised scheme.
+	if err := checkKeyAlgorithm(device.Algorithm, device.PrivateKey); err != nil {
+		return nil, err
+	}
+	sep := deviceSeparator(device)
+	dataToBeSigned := FormatSignedDataWithAAD(counter, data, lastSignature, boundSegments(expiresAt, nonce, aad, sep),
+		sep, deviceCounterEncoding(device))
 	signature, err := device.Signer.Sign([]byte(dataToBeSigned))
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign data: %w", err)
+		s.errorCounts.inc(device.Algorithm, ErrorCategorySign)
+		return nil, fmt.Errorf("%w: %w", ErrSignFailed, err)
+	}
+	// Signing may take a while; past this point the chain is mutated, so give up now if the
+	// caller is gone.
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSignAborted, err)
 	}
 	device.SignatureCounter++
+	s.totalSignatures.Add(1)
+
+	if device.FirstSignedAt.IsZero() {
+		device.FirstSignedAt = signedAt
+	}
+	device.LastSignedAt = signedAt
 
 	signatureB64 := base64.StdEncoding.EncodeToString(signature)
 	device.LastSignature = signatureB64
 
+	record := model.SignatureRecord{
+		Type:          recordType,
+		Counter:       counter,
+		Data:          data,
+		LastSignature: lastSignature,
+		AAD:           aad,
+		Nonce:         nonce,
+		Purpose:       purpose,
+		ExpiresAt:     expiresAt,
+		SignedData:    dataToBeSigned,
+		Signature:     signatureB64,
+		SignedAt:      signedAt,
+	}
+	device.History = append(device.History, record)
+	s.pruneHistory(device)
+
 	err = s.storage.Update(device)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update device: %w", err)
+		s.errorCounts.inc(device.Algorithm, ErrorCategoryStorage)
+		return nil, fmt.Errorf("%w: %w", ErrStorageFailure, err)
 	}
 
-	resp := &model.SignDataResponse{
-		Signature:  signatureB64,
-		SignedData: dataToBeSigned,
+	s.publish(SignEvent{Type: EventTypeSign, DeviceID: device.ID, Counter: counter, Timestamp: signedAt})
+	return &record, nil
+}
+
+// prepareFormat checks that the device can produce the requested output format, issuing the
+// device certificate a CMS needs on first use, so a signature is never committed only for its
+// formatting to fail. Callers must hold s.mu.
+func (s *SignatureDeviceService) prepareFormat(device *model.SignatureDevice, format string) error {
+	if device.Signer == nil || device.Locked {
+		return nil // signAndChain refuses the device before anything is signed
+	}
+	switch format {
+	case model.SignatureFormatCMS:
+		if device.Certificate == nil && device.PrivateKey != nil {
+			certificate, err := signingcrypto.NewSelfSignedCertificate(device.PrivateKey, device.ID)
+			if err != nil {
+				return fmt.Errorf("failed to issue device certificate: %w", err)
+			}
+			device.Certificate = certificate
+			if err := s.storage.Update(device); err != nil {
+				device.Certificate = nil
+				return fmt.Errorf("%w: %w", ErrStorageFailure, err)
+			}
+		}
+		_, err := s.detachedCMS(device, "")
+		return err
+	case model.SignatureFormatTagged:
+		_, err := signingcrypto.TagHashSignature(device.Algorithm, device.Hash, "")
+		return err
+	}
+	return nil
+}
+
+// detachedCMS wraps a base64 signature in a detached CMS structure carrying the device's
+// self-signed certificate, which prepareFormat issues. Callers must hold s.mu.
+func (s *SignatureDeviceService) detachedCMS(device *model.SignatureDevice, signatureB64 string) (string, error) {
+	if device.Certificate == nil {
+		return "", errors.New("device has no certificate")
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureB64)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode signature: %w", err)
+	}
+	hash, err := signingcrypto.ParseHash(device.Hash)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	cms, err := signingcrypto.BuildDetachedCMSHash(signature, device.Certificate, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to build CMS: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(cms), nil
+}
+
+// acquireSignSlot takes a slot from the signing semaphore, waiting at most signSlotWait and
+// no longer than ctx allows. The returned release func must be called once signing completes.
+func (s *SignatureDeviceService) acquireSignSlot(ctx context.Context) (func(), error) {
+	if s.signSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.signSlots <- struct{}{}:
+		return func() { <-s.signSlots }, nil
+	default:
+	}
+
+	timer := time.NewTimer(s.signSlotWait)
+	defer timer.Stop()
+	select {
+	case s.signSlots <- struct{}{}:
+		return func() { <-s.signSlots }, nil
+	case <-timer.C:
+		return nil, ErrSigningCapacity
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %w", ErrSignAborted, ctx.Err())
 	}
-	return resp, nil
 }
 
 // GetDevice retrieves a device by its unique identifier.
@@ -119,11 +679,170 @@ func (s *SignatureDeviceService) GetDevice(id string) (*model.SignatureDevice, e
 	return device, nil
 }
 
-// GetAllDevices retrieves all devices from storage.
+// ChainHead returns the device's current counter and last_signature. Both are read under the
+// signing lock, so they always belong to the same link of the chain.
+func (s *SignatureDeviceService) ChainHead(id string) (*model.ChainHeadResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	head := chainHead(device)
+	return &head, nil
+}
+
+// ChainTips returns the chain heads of up to limit devices with IDs sorted after the cursor
+// after, giving a fleet-wide view of chain tips, limited to tenantID's devices unless it is
+// empty. All heads on a page are read under a single hold of the signing lock. The page's
+// Next cursor is set when more devices follow.
+func (s *SignatureDeviceService) ChainTips(tenantID, after string, limit int) (*model.ChainTipsPage, error) {
+	var devices []*model.SignatureDevice
+	var err error
+	if tenantID == "" {
+		devices, err = s.storage.GetAllDevices()
+	} else {
+		devices, err = s.GetTenantDevices(tenantID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all devices: %w", err)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	start := sort.Search(len(devices), func(i int) bool { return devices[i].ID > after })
+	devices = devices[start:]
+
+	page := &model.ChainTipsPage{Chains: []model.ChainTip{}}
+	if limit > 0 && len(devices) > limit {
+		devices = devices[:limit]
+		page.Next = devices[limit-1].ID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, device := range devices {
+		page.Chains = append(page.Chains, model.ChainTip{ID: device.ID, ChainHeadResponse: chainHead(device)})
+	}
+	return page, nil
+}
+
+// chainHead reads the device's counter and last_signature. Callers must hold s.mu.
+func chainHead(device *model.SignatureDevice) model.ChainHeadResponse {
+	return model.ChainHeadResponse{
+		Counter:       device.SignatureCounter,
+		LastSignature: device.LastSignature,
+	}
+}
+
+// GetAllDevices retrieves all devices from storage as point-in-time snapshots. Storage hands
+// out live devices that signing mutates, so each one is copied under the signing lock; the
+// copies never show a counter and last_signature from different links of the chain.
 func (s *SignatureDeviceService) GetAllDevices() ([]*model.SignatureDevice, error) {
 	devices, err := s.storage.GetAllDevices()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all devices: %w", err)
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := make([]*model.SignatureDevice, len(devices))
+	for i, device := range devices {
+		snapshots[i] = snapshotDevice(device)
+	}
+	return snapshots, nil
+}
+
+// snapshotDevice returns a shallow copy of device whose history is capped at its current
+// length, so records appended later never show through. Callers must hold s.mu.
+func snapshotDevice(device *model.SignatureDevice) *model.SignatureDevice {
+	snapshot := *device
+	snapshot.History = device.History[:len(device.History):len(device.History)]
+	return &snapshot
+}
+
+// SignatureStats reports the number of signatures produced since startup and the topN
+// devices ranked by signature counter. The total is read from an atomic counter; the
+// ranking is computed on demand under the signing lock so counters are not read mid-update.
+// Failures are broken down by algorithm and category (keygen, sign, storage).
+func (s *SignatureDeviceService) SignatureStats(topN int) (*model.SignatureStatsResponse, error) {
+	devices, err := s.storage.GetAllDevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all devices: %w", err)
+	}
+
+	s.mu.Lock()
+	counts := make([]model.DeviceSignatureCount, len(devices))
+	for i, device := range devices {
+		counts[i] = model.DeviceSignatureCount{
+			ID:               device.ID,
+			SignatureCounter: device.SignatureCounter,
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].SignatureCounter != counts[j].SignatureCounter {
+			return counts[i].SignatureCounter > counts[j].SignatureCounter
+		}
+		return counts[i].ID < counts[j].ID
+	})
+	if topN >= 0 && topN < len(counts) {
+		counts = counts[:topN]
+	}
+
+	return &model.SignatureStatsResponse{
+		TotalSignatures: s.totalSignatures.Load(),
+		TopDevices:      counts,
+		Errors:          s.errorCounts.snapshot(),
+	}, nil
+}
+
+// GetDevices fetches several devices concurrently. The result maps every requested ID to its
+// device, or to nil when no such device exists. Any other storage error fails the whole call.
+func (s *SignatureDeviceService) GetDevices(ids []string) (map[string]*model.SignatureDevice, error) {
+	devices := make(map[string]*model.SignatureDevice, len(ids))
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	seen := make(map[string]bool, len(ids))
+	slots := make(chan struct{}, runtime.NumCPU())
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			device, err := s.storage.GetDevice(id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				devices[id] = device
+			case !errors.Is(err, ErrDeviceNotFound) && firstErr == nil:
+				firstErr = fmt.Errorf("failed to get device %s: %w", id, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	for id := range seen {
+		if _, found := devices[id]; !found {
+			devices[id] = nil
+		}
+	}
 	return devices, nil
 }
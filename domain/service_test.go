@@ -1,9 +1,19 @@
 package domain
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	model "github.com/bayuhutajulu/signing-service/model"
 )
@@ -40,7 +50,7 @@ func (m *mockStorage) Update(device *model.SignatureDevice) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if _, exists := m.devices[device.ID]; !exists {
-		return fmt.Errorf("device not found")
+		return ErrDeviceNotFound
 	}
 	m.devices[device.ID] = device
 	return nil
@@ -54,11 +64,21 @@ func (m *mockStorage) GetDevice(id string) (*model.SignatureDevice, error) {
 	defer m.mu.RUnlock()
 	device, exists := m.devices[id]
 	if !exists {
-		return nil, fmt.Errorf("device not found")
+		return nil, ErrDeviceNotFound
 	}
 	return device, nil
 }
 
+func (m *mockStorage) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.devices[id]; !exists {
+		return ErrDeviceNotFound
+	}
+	delete(m.devices, id)
+	return nil
+}
+
 func (m *mockStorage) GetAllDevices() ([]*model.SignatureDevice, error) {
 	if m.getAllErr != nil {
 		return nil, m.getAllErr
@@ -143,6 +163,84 @@ func TestCreateDevice(t *testing.T) {
 		}
 	})
 
+	t.Run("deterministic ECC device creation", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:            "device-ecc-det-001",
+			Label:         "Deterministic ECC Device",
+			Algorithm:     "ECC",
+			Deterministic: true,
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !device.Deterministic {
+			t.Error("expected device to be marked deterministic")
+		}
+
+		first, _ := device.Signer.Sign([]byte("payload"))
+		second, _ := device.Signer.Sign([]byte("payload"))
+		if string(first) != string(second) {
+			t.Error("expected deterministic signer to produce identical signatures")
+		}
+	})
+
+	t.Run("custom separator", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-sep-001",
+			Label:     "Separator Device",
+			Algorithm: "ECC",
+			Separator: "|",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.Separator != "|" {
+			t.Errorf("expected separator '|', got '%s'", device.Separator)
+		}
+	})
+
+	t.Run("default separator", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-sep-002",
+			Label:     "Separator Device",
+			Algorithm: "ECC",
+		})
+
+		if device.Separator != DefaultSeparator {
+			t.Errorf("expected default separator, got '%s'", device.Separator)
+		}
+	})
+
+	t.Run("invalid separator", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-sep-003",
+			Label:     "Separator Device",
+			Algorithm: "ECC",
+			Separator: "ab",
+		})
+
+		if err == nil {
+			t.Fatal("expected error for invalid separator, got nil")
+		}
+		if device != nil {
+			t.Errorf("expected nil device, got %v", device)
+		}
+	})
+
 	t.Run("invalid algorithm", func(t *testing.T) {
 		storage := newMockStorage()
 		service := NewSignatureDeviceService(storage)
@@ -309,7 +407,7 @@ func TestSignData(t *testing.T) {
 			}
 
 			updatedDevice, _ := storage.GetDevice(device.ID)
-			if updatedDevice.SignatureCounter != i {
+			if updatedDevice.SignatureCounter != int64(i) {
 				t.Errorf("iteration %d: expected counter %d, got %d", i, i, updatedDevice.SignatureCounter)
 			}
 			if updatedDevice.LastSignature != resp.Signature {
@@ -408,6 +506,95 @@ func TestSignData(t *testing.T) {
 	})
 }
 
+func TestSignDataCustomSeparator(t *testing.T) {
+	t.Run("signed data uses the device separator", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-pipe-001",
+			Label:     "Pipe Device",
+			Algorithm: "ECC",
+			Separator: "|",
+		})
+
+		first, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "order_1"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		second, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "order_2"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expectedFirst := "0|order_1|" + "ZGV2aWNlLXBpcGUtMDAx"
+		if first.SignedData != expectedFirst {
+			t.Errorf("expected signed data %s, got %s", expectedFirst, first.SignedData)
+		}
+
+		parts, err := ParseSignedData(second.SignedData, device.Separator, CounterEncodingDecimal)
+		if err != nil {
+			t.Fatalf("expected no error parsing signed data, got %v", err)
+		}
+		if parts.Counter != 1 || parts.Data != "order_2" || parts.LastSignature != first.Signature {
+			t.Errorf("unexpected parsed parts %+v", parts)
+		}
+	})
+}
+
+func TestSignDataCounterEncoding(t *testing.T) {
+	t.Run("signed data uses the device counter encoding", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:              "device-hex-001",
+			Algorithm:       "ECC",
+			CounterEncoding: CounterEncodingHex,
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		}
+
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !strings.HasPrefix(resp.SignedData, "a_payload_") {
+			t.Errorf("expected hex counter prefix, got %s", resp.SignedData)
+		}
+
+		parts, err := ParseSignedData(resp.SignedData, device.Separator, device.CounterEncoding)
+		if err != nil {
+			t.Fatalf("expected no error parsing signed data, got %v", err)
+		}
+		if parts.Counter != 10 {
+			t.Errorf("expected counter 10, got %d", parts.Counter)
+		}
+
+		results, _ := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: device.ID,
+			Entries: []model.VerifySignatureOptions{{
+				Data: "payload", Signature: resp.Signature, Counter: 10, LastSignature: parts.LastSignature,
+			}},
+		})
+		if !results[0].Valid {
+			t.Errorf("expected signature to verify, got %+v", results[0])
+		}
+	})
+
+	t.Run("rejects unknown encodings", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-bad", Algorithm: "ECC", CounterEncoding: "octal"})
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
 func TestGetDevice(t *testing.T) {
 	t.Run("successful device retrieval", func(t *testing.T) {
 		storage := newMockStorage()
@@ -555,8 +742,819 @@ func TestConcurrentSignData(t *testing.T) {
 		}
 
 		finalDevice, _ := storage.GetDevice(device.ID)
-		if finalDevice.SignatureCounter != concurrency {
+		if finalDevice.SignatureCounter != int64(concurrency) {
 			t.Errorf("expected final counter %d, got %d", concurrency, finalDevice.SignatureCounter)
 		}
 	})
 }
+
+func TestSignatureStats(t *testing.T) {
+	t.Run("aggregates signatures and ranks devices", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		signs := map[string]int{
+			"device-stats-001": 3,
+			"device-stats-002": 1,
+			"device-stats-003": 5,
+		}
+		for id, count := range signs {
+			service.CreateDevice(model.CreateDeviceOptions{
+				ID:        id,
+				Label:     "Stats Test",
+				Algorithm: "ECC",
+			})
+			for i := 0; i < count; i++ {
+				if _, err := service.SignData(model.SignDataOptions{DeviceID: id, Data: "data"}); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+		}
+
+		stats, err := service.SignatureStats(2)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if stats.TotalSignatures != 9 {
+			t.Errorf("expected total 9, got %d", stats.TotalSignatures)
+		}
+		if len(stats.TopDevices) != 2 {
+			t.Fatalf("expected 2 top devices, got %d", len(stats.TopDevices))
+		}
+		if stats.TopDevices[0].ID != "device-stats-003" || stats.TopDevices[0].SignatureCounter != 5 {
+			t.Errorf("expected device-stats-003 with 5 first, got %+v", stats.TopDevices[0])
+		}
+		if stats.TopDevices[1].ID != "device-stats-001" || stats.TopDevices[1].SignatureCounter != 3 {
+			t.Errorf("expected device-stats-001 with 3 second, got %+v", stats.TopDevices[1])
+		}
+	})
+
+	t.Run("failed signatures are not counted", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		service.SignData(model.SignDataOptions{DeviceID: "non-existent-device", Data: "data"})
+
+		stats, err := service.SignatureStats(5)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if stats.TotalSignatures != 0 {
+			t.Errorf("expected total 0, got %d", stats.TotalSignatures)
+		}
+		if len(stats.TopDevices) != 0 {
+			t.Errorf("expected no top devices, got %d", len(stats.TopDevices))
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		storage := newMockStorage()
+		storage.getAllErr = fmt.Errorf("storage error")
+		service := NewSignatureDeviceService(storage)
+
+		stats, err := service.SignatureStats(5)
+
+		if err == nil {
+			t.Fatal("expected error from storage, got nil")
+		}
+		if stats != nil {
+			t.Errorf("expected nil stats, got %v", stats)
+		}
+	})
+}
+
+func TestVerifySignatures(t *testing.T) {
+	t.Run("verifies entries in order", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-verify-001",
+			Label:     "Verify Test",
+			Algorithm: "RSA",
+		})
+		initial := device.LastSignature
+		resp, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: device.ID,
+			Entries: []model.VerifySignatureOptions{
+				{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: initial},
+				{Data: "payload", Signature: resp.Signature, Counter: 1, LastSignature: initial},
+			},
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !results[0].Valid {
+			t.Errorf("expected first entry to be valid, got error %s", results[0].Error)
+		}
+		if results[1].Valid {
+			t.Error("expected entry with wrong counter to be invalid")
+		}
+	})
+
+	t.Run("device without stored verifier", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-verify-002",
+			Label:     "Verify Test",
+			Algorithm: "ECC",
+		})
+		initial := device.LastSignature
+		resp, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		device.Verifier = nil
+
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: device.ID,
+			Entries: []model.VerifySignatureOptions{
+				{Data: "payload", Signature: resp.Signature, Counter: 0, LastSignature: initial},
+			},
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !results[0].Valid {
+			t.Errorf("expected entry to be valid, got error %s", results[0].Error)
+		}
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: "non-existent-device"})
+
+		if err == nil {
+			t.Fatal("expected error for non-existent device, got nil")
+		}
+		if results != nil {
+			t.Errorf("expected nil results, got %v", results)
+		}
+	})
+}
+
+func TestCreateDeviceKeyGenerationDefaults(t *testing.T) {
+	t.Run("empty algorithm uses default", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage, WithKeyGenerationDefaults(KeyGenerationDefaults{
+			Algorithm: "ECC",
+		}))
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:    "device-default-001",
+			Label: "Default Algorithm",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.Algorithm != "ECC" {
+			t.Errorf("expected algorithm ECC, got %s", device.Algorithm)
+		}
+	})
+
+	t.Run("empty key size uses default", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage, WithKeyGenerationDefaults(KeyGenerationDefaults{
+			RSAKeySize: 1024,
+		}))
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-default-002",
+			Algorithm: "RSA",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if bits := device.PublicKey.(*rsa.PublicKey).N.BitLen(); bits != 1024 {
+			t.Errorf("expected 1024-bit key, got %d", bits)
+		}
+	})
+
+	t.Run("empty curve uses default", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage, WithKeyGenerationDefaults(KeyGenerationDefaults{
+			ECCCurve: "P-256",
+		}))
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-default-003",
+			Algorithm: "ECC",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if name := device.PublicKey.(*ecdsa.PublicKey).Curve.Params().Name; name != "P-256" {
+			t.Errorf("expected curve P-256, got %s", name)
+		}
+	})
+
+	t.Run("request values override defaults", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage, WithKeyGenerationDefaults(KeyGenerationDefaults{
+			Algorithm: "RSA",
+			ECCCurve:  "P-256",
+		}))
+
+		device, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-default-004",
+			Algorithm: "ECC",
+			Curve:     "P-521",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if name := device.PublicKey.(*ecdsa.PublicKey).Curve.Params().Name; name != "P-521" {
+			t.Errorf("expected curve P-521, got %s", name)
+		}
+	})
+
+	t.Run("invalid request parameters", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "a", Algorithm: "RSA", KeySize: 1000}); err == nil {
+			t.Error("expected error for unsupported key size")
+		}
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "b", Algorithm: "ECC", Curve: "P-999"}); err == nil {
+			t.Error("expected error for unsupported curve")
+		}
+	})
+}
+
+func TestKeyGenerationDefaultsValidate(t *testing.T) {
+	valid := []KeyGenerationDefaults{
+		{},
+		{Algorithm: "RSA", RSAKeySize: 2048, ECCCurve: "P-256"},
+	}
+	for _, defaults := range valid {
+		if err := defaults.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", defaults, err)
+		}
+	}
+
+	invalid := []KeyGenerationDefaults{
+		{Algorithm: "DSA"},
+		{RSAKeySize: 100},
+		{ECCCurve: "secp256k1"},
+	}
+	for _, defaults := range invalid {
+		if err := defaults.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", defaults)
+		}
+	}
+}
+
+func TestSignDataTimestamps(t *testing.T) {
+	t.Run("first_signed_at stays fixed while last_signed_at advances", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device-times-001",
+			Label:     "Timestamp Test",
+			Algorithm: "ECC",
+		})
+
+		if response := device.ToResponse(); response.FirstSignedAt != nil || response.LastSignedAt != nil {
+			t.Error("expected no signing timestamps before the first signature")
+		}
+
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "first"})
+		first := device.ToResponse()
+		time.Sleep(2 * time.Millisecond)
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "second"})
+		second := device.ToResponse()
+
+		if first.FirstSignedAt == nil || second.FirstSignedAt == nil {
+			t.Fatal("expected first_signed_at to be set after signing")
+		}
+		if !second.FirstSignedAt.Equal(*first.FirstSignedAt) {
+			t.Errorf("expected first_signed_at to stay %v, got %v", *first.FirstSignedAt, *second.FirstSignedAt)
+		}
+		if !second.LastSignedAt.After(*first.LastSignedAt) {
+			t.Errorf("expected last_signed_at to advance past %v, got %v", *first.LastSignedAt, *second.LastSignedAt)
+		}
+		if second.SignaturesPerMinute != 2 {
+			t.Errorf("expected 2 signatures per minute within the first minute, got %v", second.SignaturesPerMinute)
+		}
+	})
+}
+
+func TestCreateDeviceIDPolicy(t *testing.T) {
+	newService := func() *SignatureDeviceService {
+		return NewSignatureDeviceService(newMockStorage(), WithDeviceIDPolicy(regexp.MustCompile(DefaultDeviceIDPattern)))
+	}
+
+	t.Run("conforming ID", func(t *testing.T) {
+		service := newService()
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device_policy-001",
+			Algorithm: "ECC",
+		})
+
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("empty ID", func(t *testing.T) {
+		service := newService()
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "",
+			Algorithm: "ECC",
+		})
+
+		if !errors.Is(err, ErrInvalidDeviceID) {
+			t.Errorf("expected ErrInvalidDeviceID, got %v", err)
+		}
+	})
+
+	t.Run("illegal characters", func(t *testing.T) {
+		service := newService()
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device/../001",
+			Algorithm: "ECC",
+		})
+
+		if !errors.Is(err, ErrInvalidDeviceID) {
+			t.Errorf("expected ErrInvalidDeviceID, got %v", err)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+
+		_, err := service.CreateDevice(model.CreateDeviceOptions{
+			ID:        "device/../001",
+			Algorithm: "ECC",
+		})
+
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestReadOnlyService(t *testing.T) {
+	t.Run("write operations are refused", func(t *testing.T) {
+		storage := newMockStorage()
+		device, _ := NewSignatureDeviceService(storage).CreateDevice(model.CreateDeviceOptions{ID: "device-ro-001", Algorithm: "ECC"})
+		service := NewSignatureDeviceService(storage, WithReadOnly(true))
+
+		if _, err := service.CreateDevice(model.CreateDeviceOptions{ID: "device-ro-002", Algorithm: "ECC"}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("expected ErrReadOnly from CreateDevice, got %v", err)
+		}
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("expected ErrReadOnly from SignData, got %v", err)
+		}
+		if _, err := service.AttestSignature(model.AttestOptions{DeviceID: device.ID, Signature: "sig"}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("expected ErrReadOnly from AttestSignature, got %v", err)
+		}
+		if _, err := service.GetDevice(device.ID); err != nil {
+			t.Errorf("expected GetDevice to succeed, got %v", err)
+		}
+	})
+}
+
+func TestGetDevices(t *testing.T) {
+	t.Run("missing devices map to nil", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		service.CreateDevice(model.CreateDeviceOptions{ID: "device-multi-001", Algorithm: "ECC"})
+
+		devices, err := service.GetDevices([]string{"device-multi-001", "missing", "device-multi-001"})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(devices) != 2 {
+			t.Errorf("expected 2 entries, got %d", len(devices))
+		}
+		if devices["device-multi-001"] == nil {
+			t.Error("expected existing device to be returned")
+		}
+		if device, ok := devices["missing"]; !ok || device != nil {
+			t.Error("expected nil entry for missing device")
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		storage := newMockStorage()
+		storage.getErr = fmt.Errorf("storage error")
+		service := NewSignatureDeviceService(storage)
+
+		if _, err := service.GetDevices([]string{"device-multi-001"}); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestSignDataWithAAD(t *testing.T) {
+	storage := newMockStorage()
+	service := NewSignatureDeviceService(storage)
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-aad-001", Algorithm: "ECC"})
+	initialSignature := device.LastSignature
+
+	resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", AAD: "audience-a"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("aad is folded into signed data and echoed", func(t *testing.T) {
+		expected := "0_payload_" + initialSignature + "_audience-a"
+		if resp.SignedData != expected {
+			t.Errorf("expected signed data %s, got %s", expected, resp.SignedData)
+		}
+		if resp.AAD != "audience-a" {
+			t.Errorf("expected aad audience-a, got %s", resp.AAD)
+		}
+	})
+
+	t.Run("verification requires the same aad", func(t *testing.T) {
+		entry := model.VerifySignatureOptions{
+			Data:          "payload",
+			Signature:     resp.Signature,
+			Counter:       0,
+			LastSignature: initialSignature,
+		}
+		withoutAAD := entry
+		withAAD := entry
+		withAAD.AAD = "audience-a"
+		wrongAAD := entry
+		wrongAAD.AAD = "audience-b"
+
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{
+			DeviceID: device.ID,
+			Entries:  []model.VerifySignatureOptions{withoutAAD, withAAD, wrongAAD},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if results[0].Valid {
+			t.Error("expected verification without aad to fail")
+		}
+		if !results[1].Valid {
+			t.Error("expected verification with aad to pass")
+		}
+		if results[2].Valid {
+			t.Error("expected verification with a different aad to fail")
+		}
+	})
+
+	t.Run("empty aad keeps the three-segment format", func(t *testing.T) {
+		plain, _ := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		expected := FormatSignedData(1, "payload", resp.Signature, DefaultSeparator)
+		if plain.SignedData != expected {
+			t.Errorf("expected signed data %s, got %s", expected, plain.SignedData)
+		}
+	})
+}
+
+func TestSignDataMaxLength(t *testing.T) {
+	service := NewSignatureDeviceService(newMockStorage(), WithMaxSignDataLength(8))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-maxlen-001", Algorithm: "ECC"})
+
+	t.Run("within limit", func(t *testing.T) {
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "12345678"}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "123456789"})
+
+		if !errors.Is(err, ErrDataTooLarge) {
+			t.Errorf("expected ErrDataTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("multi-byte characters count as bytes", func(t *testing.T) {
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "äöüäöü"})
+
+		if !errors.Is(err, ErrDataTooLarge) {
+			t.Errorf("expected ErrDataTooLarge, got %v", err)
+		}
+	})
+	t.Run("aad, nonce and purpose are capped too", func(t *testing.T) {
+		for _, opts := range []model.SignDataOptions{
+			{DeviceID: device.ID, Data: "payload", AAD: "123456789"},
+			{DeviceID: device.ID, Data: "payload", Nonce: "123456789"},
+			{DeviceID: device.ID, Data: "payload", Purpose: "123456789"},
+		} {
+			if _, err := service.SignData(opts); !errors.Is(err, ErrDataTooLarge) {
+				t.Errorf("expected ErrDataTooLarge for %+v, got %v", opts, err)
+			}
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+	})
+}
+
+func TestSignDataEmptyData(t *testing.T) {
+	t.Run("allowed by default", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-empty-001", Algorithm: "ECC"})
+
+		resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: ""})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expected := "0__" + base64.StdEncoding.EncodeToString([]byte(device.ID))
+		if resp.SignedData != expected {
+			t.Errorf("expected signed data %q, got %q", expected, resp.SignedData)
+		}
+	})
+
+	t.Run("rejected when configured", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage(), WithRejectEmptyData(true))
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-empty-002", Algorithm: "ECC"})
+
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: ""})
+		if !errors.Is(err, ErrEmptyData) {
+			t.Fatalf("expected ErrEmptyData, got %v", err)
+		}
+		if device.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", device.SignatureCounter)
+		}
+
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err != nil {
+			t.Errorf("expected non-empty data to be signed, got %v", err)
+		}
+	})
+}
+
+func TestSignDataRejectDuplicates(t *testing.T) {
+	t.Run("flag on rejects the second signature", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-dup-001", Algorithm: "ECC", RejectDuplicates: true})
+
+		if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice-1"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice-2"})
+
+		_, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice-1"})
+		if !errors.Is(err, ErrDuplicateData) {
+			t.Errorf("expected ErrDuplicateData, got %v", err)
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected counter 2, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("flag off signs both", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-dup-002", Algorithm: "ECC"})
+
+		for i := 0; i < 2; i++ {
+			if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "invoice-1"}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+		if device.SignatureCounter != 2 {
+			t.Errorf("expected counter 2, got %d", device.SignatureCounter)
+		}
+	})
+}
+
+func TestSignDataPastInt32Counter(t *testing.T) {
+	t.Run("counter keeps chaining past the int32 range", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-busy-001", Algorithm: "ECC"})
+		device.SignatureCounter = math.MaxInt32
+
+		first, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		second, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !strings.HasPrefix(first.SignedData, "2147483647_") {
+			t.Errorf("expected counter 2147483647, got %s", first.SignedData)
+		}
+		parts, err := ParseSignedData(second.SignedData, DefaultSeparator, CounterEncodingDecimal)
+		if err != nil {
+			t.Fatalf("expected no error parsing signed data, got %v", err)
+		}
+		if parts.Counter != math.MaxInt32+1 || parts.LastSignature != first.Signature {
+			t.Errorf("unexpected parsed parts %+v", parts)
+		}
+		if device.SignatureCounter != math.MaxInt32+2 {
+			t.Errorf("expected counter %d, got %d", int64(math.MaxInt32+2), device.SignatureCounter)
+		}
+	})
+
+	t.Run("JSON keeps large counters exact", func(t *testing.T) {
+		device := &model.SignatureDevice{ID: "device-busy-002", SignatureCounter: 1<<53 + 1}
+
+		encoded, err := json.Marshal(device.ToResponse())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !strings.Contains(string(encoded), `"signature_counter":9007199254740993`) {
+			t.Errorf("expected exact numeric counter, got %s", encoded)
+		}
+
+		var decoded model.DeviceResponse
+		json.Unmarshal(encoded, &decoded)
+		if decoded.SignatureCounter != 1<<53+1 {
+			t.Errorf("expected counter %d, got %d", int64(1<<53+1), decoded.SignatureCounter)
+		}
+	})
+}
+
+func TestGetAllDevicesSnapshot(t *testing.T) {
+	t.Run("listing during concurrent signing never returns a torn device", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-snapshot-001", Algorithm: "ECC"})
+		initialSignature := device.LastSignature
+
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 25; j++ {
+					service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		for listing := true; listing; {
+			select {
+			case <-done:
+				listing = false
+			default:
+			}
+
+			devices, err := service.GetAllDevices()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			snapshot := devices[0]
+			if int64(len(snapshot.History)) != snapshot.SignatureCounter {
+				t.Fatalf("expected %d history records, got %d", snapshot.SignatureCounter, len(snapshot.History))
+			}
+			expected := initialSignature
+			if snapshot.SignatureCounter > 0 {
+				expected = snapshot.History[snapshot.SignatureCounter-1].Signature
+			}
+			if snapshot.LastSignature != expected {
+				t.Fatalf("expected last_signature of counter %d, got a different link", snapshot.SignatureCounter)
+			}
+		}
+	})
+
+	t.Run("snapshots are not updated by later signing", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-snapshot-002", Algorithm: "ECC"})
+
+		devices, _ := service.GetAllDevices()
+		service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+
+		if devices[0].SignatureCounter != 0 {
+			t.Errorf("expected snapshot counter 0, got %d", devices[0].SignatureCounter)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected device counter 1, got %d", device.SignatureCounter)
+		}
+	})
+}
+
+func TestSignDataHash(t *testing.T) {
+	service := NewSignatureDeviceService(newMockStorage())
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-hash-001", Algorithm: "ECC"})
+
+	resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "hello"})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if resp.DataHash != expected {
+		t.Errorf("expected data_hash %s, got %s", expected, resp.DataHash)
+	}
+}
+
+// gatedSigner blocks in Sign until released, reporting each call on entered.
+type gatedSigner struct {
+	signer  interface{ Sign([]byte) ([]byte, error) }
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedSigner) Sign(data []byte) ([]byte, error) {
+	g.entered <- struct{}{}
+	<-g.release
+	return g.signer.Sign(data)
+}
+
+func TestSignDataContext(t *testing.T) {
+	t.Run("cancellation mid-sign leaves the device unchanged", func(t *testing.T) {
+		storage := newMockStorage()
+		service := NewSignatureDeviceService(storage)
+		device, err := service.CreateDevice(model.CreateDeviceOptions{ID: "ctx-001", Algorithm: "ECC"})
+		if err != nil {
+			t.Fatalf("failed to create device: %v", err)
+		}
+		lastSignature := device.LastSignature
+		gate := &gatedSigner{signer: device.Signer, entered: make(chan struct{}), release: make(chan struct{})}
+		device.Signer = gate
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err := service.SignDataContext(ctx, model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+			done <- err
+		}()
+
+		<-gate.entered
+		cancel()
+		close(gate.release)
+
+		err = <-done
+		if !errors.Is(err, ErrSignAborted) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected ErrSignAborted wrapping context.Canceled, got %v", err)
+		}
+		stored, _ := storage.GetDevice(device.ID)
+		if stored.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", stored.SignatureCounter)
+		}
+		if stored.LastSignature != lastSignature || len(stored.History) != 0 {
+			t.Error("expected chain and history to be unchanged")
+		}
+		if total := service.totalSignatures.Load(); total != 0 {
+			t.Errorf("expected no signatures counted, got %d", total)
+		}
+	})
+
+	t.Run("expired deadline aborts before signing", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "ctx-002", Algorithm: "ECC"})
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		_, err := service.SignDataContext(ctx, model.SignDataOptions{DeviceID: device.ID, Data: "payload"})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if device.SignatureCounter != 0 {
+			t.Errorf("expected counter 0, got %d", device.SignatureCounter)
+		}
+	})
+
+	t.Run("live context signs normally", func(t *testing.T) {
+		service := NewSignatureDeviceService(newMockStorage())
+		device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "ctx-003", Algorithm: "ECC"})
+
+		if _, err := service.SignDataContext(context.Background(), model.SignDataOptions{DeviceID: device.ID, Data: "payload"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.SignatureCounter != 1 {
+			t.Errorf("expected counter 1, got %d", device.SignatureCounter)
+		}
+	})
+}
+
+func TestSignDataFormatFailure(t *testing.T) {
+	for _, format := range []string{model.SignatureFormatCMS, model.SignatureFormatTagged} {
+		t.Run(format, func(t *testing.T) {
+			service := NewSignatureDeviceService(newMockStorage())
+			device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-format-" + format, Algorithm: "ECC"})
+			device.Hash = "SHA3-256" // A hash neither format can describe
+
+			if _, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "payload", Format: format}); err == nil {
+				t.Fatal("expected the format to fail")
+			}
+			if device.SignatureCounter != 0 || len(device.History) != 0 {
+				t.Errorf("expected nothing signed, got counter %d and %d records", device.SignatureCounter, len(device.History))
+			}
+		})
+	}
+}
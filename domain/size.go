@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// historyRecordOverhead approximates the fixed-size fields of a history record: the counter
+// and the timestamp.
+const historyRecordOverhead = 8 + 24
+
+// DeviceSize estimates the storage footprint of a device for capacity planning. Keys are
+// measured by their DER encoding (PKIX public key, PKCS#8 private key) and history records by
+// their string payloads plus a fixed overhead. DataBytes is the client data retained in the
+// history, which is also counted in HistoryBytes.
+func (s *SignatureDeviceService) DeviceSize(deviceID string) (*model.DeviceSizeResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	size := &model.DeviceSizeResponse{
+		CertificateBytes: len(device.Certificate),
+		HistoryEntries:   len(device.History),
+	}
+	if device.PublicKey != nil {
+		der, err := x509.MarshalPKIXPublicKey(device.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode public key: %w", err)
+		}
+		size.PublicKeyBytes = len(der)
+	}
+	if device.PrivateKey != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(device.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
+		}
+		size.PrivateKeyBytes = len(der)
+	}
+	for _, record := range device.History {
+		size.DataBytes += int64(len(record.Data))
+		size.HistoryBytes += int64(historyRecordOverhead + len(record.Type) + len(record.Data) +
+			len(record.LastSignature) + len(record.AAD) + len(record.Purpose) +
+			len(record.SignedData) + len(record.Signature))
+	}
+	size.TotalBytes = int64(size.PublicKeyBytes+size.PrivateKeyBytes+size.CertificateBytes) + size.HistoryBytes
+	return size, nil
+}
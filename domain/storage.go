@@ -4,7 +4,10 @@ import model "github.com/bayuhutajulu/signing-service/model"
 
 type DeviceStorage interface {
 	Save(device *model.SignatureDevice) error
+	// Update replaces an existing device, returning ErrDeviceNotFound if it does not exist.
 	Update(device *model.SignatureDevice) error
 	GetDevice(id string) (*model.SignatureDevice, error)
 	GetAllDevices() ([]*model.SignatureDevice, error)
+	// Delete removes a device, returning ErrDeviceNotFound if it does not exist.
+	Delete(id string) error
 }
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// DeviceKeyStrength assesses the device's stored key: its size or curve, the estimated
+// security strength of the key and of the hash it signs with, and a rating that is weak when
+// the lower of the two falls below signingcrypto.MinimumSecurityBits, e.g. for RSA keys
+// under 2048 bits.
+func (s *SignatureDeviceService) DeviceKeyStrength(deviceID string) (*model.KeyStrength, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, err := s.storage.GetDevice(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	keyBits, err := signingcrypto.KeySecurityBits(device.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	hash := deviceHash(device)
+	hashBits, err := signingcrypto.HashSecurityBits(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	strength := &model.KeyStrength{
+		Algorithm:    device.Algorithm,
+		Curve:        signingcrypto.KeyCurve(device.PublicKey),
+		Hash:         hash,
+		KeyBits:      keyBits,
+		SecurityBits: keyBits,
+		Rating:       model.KeyRatingOK,
+	}
+	if hashBits < keyBits {
+		strength.SecurityBits = hashBits
+	}
+	if key, ok := device.PublicKey.(*rsa.PublicKey); ok {
+		strength.KeySize = key.N.BitLen()
+	}
+	if strength.SecurityBits < signingcrypto.MinimumSecurityBits {
+		strength.Rating = model.KeyRatingWeak
+	}
+	return strength, nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"fmt"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// TenantStorage is implemented by storage backends that look devices up per tenant natively.
+// For other backends the service filters GetDevice and GetAllDevices by tenant instead.
+type TenantStorage interface {
+	// GetTenantDevice returns the device if it belongs to tenantID, and ErrDeviceNotFound otherwise.
+	GetTenantDevice(tenantID, id string) (*model.SignatureDevice, error)
+	// GetTenantDevices returns the devices belonging to tenantID.
+	GetTenantDevices(tenantID string) ([]*model.SignatureDevice, error)
+}
+
+// GetTenantDevice retrieves a device on behalf of tenantID. Devices of other tenants fail
+// with ErrDeviceNotFound, exactly like missing ones, so their IDs cannot be enumerated. An
+// empty tenantID is unscoped and behaves like GetDevice.
+func (s *SignatureDeviceService) GetTenantDevice(tenantID, id string) (*model.SignatureDevice, error) {
+	if tenantID == "" {
+		return s.GetDevice(id)
+	}
+
+	var device *model.SignatureDevice
+	var err error
+	if storage, ok := s.storage.(TenantStorage); ok {
+		device, err = storage.GetTenantDevice(tenantID, id)
+	} else {
+		device, err = s.storage.GetDevice(id)
+		if err == nil && device.TenantID != tenantID {
+			err = ErrDeviceNotFound
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	return device, nil
+}
+
+// GetTenantDevices lists the devices of tenantID as point-in-time snapshots, like
+// GetAllDevices. An empty tenantID is unscoped and lists every device.
+func (s *SignatureDeviceService) GetTenantDevices(tenantID string) ([]*model.SignatureDevice, error) {
+	if tenantID == "" {
+		return s.GetAllDevices()
+	}
+
+	var devices []*model.SignatureDevice
+	if storage, ok := s.storage.(TenantStorage); ok {
+		tenantDevices, err := storage.GetTenantDevices(tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tenant devices: %w", err)
+		}
+		devices = tenantDevices
+	} else {
+		all, err := s.storage.GetAllDevices()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tenant devices: %w", err)
+		}
+		for _, device := range all {
+			if device.TenantID == tenantID {
+				devices = append(devices, device)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := make([]*model.SignatureDevice, len(devices))
+	for i, device := range devices {
+		snapshots[i] = snapshotDevice(device)
+	}
+	return snapshots, nil
+}
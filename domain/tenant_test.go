@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestTenantLookups(t *testing.T) {
+	// mockStorage has no native tenant lookups, so the service filters by itself.
+	service := NewSignatureDeviceService(newMockStorage())
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-tenant-001", Algorithm: "ECC", TenantID: "tenant-a"})
+	service.CreateDevice(model.CreateDeviceOptions{ID: "device-tenant-002", Algorithm: "ECC", TenantID: "tenant-b"})
+
+	t.Run("foreign devices are not found", func(t *testing.T) {
+		if _, err := service.GetTenantDevice("tenant-a", "device-tenant-002"); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+		if _, err := service.GetTenantDevice("tenant-a", "device-tenant-001"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("listing is scoped", func(t *testing.T) {
+		devices, err := service.GetTenantDevices("tenant-b")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(devices) != 1 || devices[0].ID != "device-tenant-002" {
+			t.Errorf("expected only device-tenant-002, got %d devices", len(devices))
+		}
+	})
+
+	t.Run("empty tenant is unscoped", func(t *testing.T) {
+		devices, _ := service.GetTenantDevices("")
+		if len(devices) != 2 {
+			t.Errorf("expected 2 devices, got %d", len(devices))
+		}
+		if _, err := service.GetTenantDevice("", "device-tenant-002"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("migrated devices stay with the tenant", func(t *testing.T) {
+		device, err := service.MigrateDeviceKeys(model.MigrateDeviceOptions{SourceID: "device-tenant-001", ID: "device-tenant-003", Algorithm: "RSA"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if device.TenantID != "tenant-a" {
+			t.Errorf("expected tenant-a, got %q", device.TenantID)
+		}
+	})
+}
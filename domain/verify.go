@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// VerifySignatures checks each entry against the device's public key, reconstructing the
+// signed payload from the entry's counter, data, and last_signature with the device's separator
+// and counter encoding. Signatures may carry an algorithm tag, which must match the device.
+// Entries are verified concurrently; results are returned in the same order as the entries.
+// An entry carrying expires_at is bound to that expiry, and a genuine signature past it is
+// reported invalid with the reason "expired". An entry whose last_signature is not strict
+// standard base64, e.g. one carrying a trailing expiry segment, is reported invalid.
+// Individual failures are reported per entry; only an unknown device fails the whole call.
+func (s *SignatureDeviceService) VerifySignatures(opts model.BatchVerifyOptions) ([]model.VerifyResult, error) {
+	device, err := s.storage.GetDevice(opts.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	verifier, err := deviceVerifier(device)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]model.VerifyResult, len(opts.Entries))
+	slots := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, entry := range opts.Entries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, entry model.VerifySignatureOptions) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = s.verifyEntry(device, verifier, entry)
+		}(i, entry)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// VerifyAt verifies a signature given only the counter it was made at: the last_signature in
+// effect at that counter, and any nonce, AAD and expiry bound into the signature, are taken
+// from the device's stored history. Returns ErrCounterNotInHistory if the history has no record
+// for the counter, e.g. because it was pruned.
+func (s *SignatureDeviceService) VerifyAt(opts model.VerifyAtOptions) (*model.VerifyResult, error) {
+	s.mu.Lock()
+	device, err := s.storage.GetDevice(opts.DeviceID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+	snapshot := snapshotDevice(device)
+	s.mu.Unlock()
+
+	record, ok := historyRecord(snapshot, opts.Counter)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrCounterNotInHistory, opts.Counter)
+	}
+	verifier, err := deviceVerifier(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	result := s.verifyEntry(snapshot, verifier, model.VerifySignatureOptions{
+		Data:          opts.Data,
+		Signature:     opts.Signature,
+		Counter:       record.Counter,
+		LastSignature: record.LastSignature,
+		AAD:           record.AAD,
+		Nonce:         record.Nonce,
+		ExpiresAt:     record.ExpiresAt,
+	})
+	return &result, nil
+}
+
+// historyRecord returns the device's history record for counter. Records are normally stored
+// in counter order starting at PrunedHistory, so the record is looked up by position first.
+func historyRecord(device *model.SignatureDevice, counter int64) (*model.SignatureRecord, bool) {
+	if i := counter - device.PrunedHistory; i >= 0 && i < int64(len(device.History)) && device.History[i].Counter == counter {
+		return &device.History[i], true
+	}
+	for i := range device.History {
+		if device.History[i].Counter == counter {
+			return &device.History[i], true
+		}
+	}
+	return nil, false
+}
+
+// verifyEntry verifies a single entry and converts any failure into a result.
+// When a verify cache is configured, the cryptographic outcome is served from it if present.
+func (s *SignatureDeviceService) verifyEntry(device *model.SignatureDevice, verifier signingcrypto.Verifier, entry model.VerifySignatureOptions) model.VerifyResult {
+	algorithm, signature, err := signingcrypto.DecodeTaggedSignature(entry.Signature)
+	if err != nil {
+		return model.VerifyResult{Valid: false, Error: err.Error()}
+	}
+	if algorithm != "" && algorithm != device.Algorithm {
+		return model.VerifyResult{Valid: false, Error: fmt.Sprintf("signature is tagged %s, device uses %s", algorithm, device.Algorithm)}
+	}
+
+	if err := checkBoundSegments(entry.Nonce, entry.AAD); err != nil {
+		return model.VerifyResult{Valid: false, Error: err.Error()}
+	}
+	if err := checkLastSignature(entry.LastSignature); err != nil {
+		return model.VerifyResult{Valid: false, Error: err.Error()}
+	}
+
+	sep := deviceSeparator(device)
+	signedData := FormatSignedDataWithAAD(entry.Counter, entry.Data, entry.LastSignature,
+		boundSegments(entry.ExpiresAt, entry.Nonce, entry.AAD, sep), sep, deviceCounterEncoding(device))
+
+	var cacheKey verifyCacheKey
+	if s.verifyCache != nil {
+		cacheKey = newVerifyCacheKey(device.ID, signedData, entry.Signature)
+		if valid, ok := s.verifyCache.get(cacheKey); ok {
+			return s.expiringVerifyResult(valid, entry.ExpiresAt)
+		}
+	}
+
+	err = verifier.Verify([]byte(signedData), signature)
+	if s.verifyCache != nil {
+		s.verifyCache.put(device.ID, cacheKey, err == nil)
+	}
+	return s.expiringVerifyResult(err == nil, entry.ExpiresAt)
+}
+
+// expiringVerifyResult is verifyResult for a signature bound to expiresAt, if set: a genuine
+// signature no longer counts as valid once the service clock reaches its expiry.
+func (s *SignatureDeviceService) expiringVerifyResult(valid bool, expiresAt *time.Time) model.VerifyResult {
+	if valid && expiresAt != nil && !s.clock.Now().Before(*expiresAt) {
+		return model.VerifyResult{Valid: false, Error: ErrSignatureExpired.Error(), Reason: model.VerifyReasonExpired}
+	}
+	return verifyResult(valid)
+}
+
+// verifyResult converts a verification outcome into its API result.
+func verifyResult(valid bool) model.VerifyResult {
+	if !valid {
+		return model.VerifyResult{Valid: false, Error: signingcrypto.ErrInvalidSignature.Error()}
+	}
+	return model.VerifyResult{Valid: true}
+}
+
+// deviceVerifier returns the device's verifier, deriving one from the public key for
+// devices persisted without it.
+func deviceVerifier(device *model.SignatureDevice) (signingcrypto.Verifier, error) {
+	if device.Verifier != nil {
+		return device.Verifier, nil
+	}
+	return publicKeyVerifier(device)
+}
+
+// deviceHash names the hash the device signs with, which is SHA-256 for devices created before
+// the hash became configurable.
+func deviceHash(device *model.SignatureDevice) string {
+	if device.Hash == "" {
+		return signingcrypto.DefaultHash
+	}
+	return device.Hash
+}
+
+// publicKeyVerifier builds a verifier from the device's public key and hash.
+func publicKeyVerifier(device *model.SignatureDevice) (signingcrypto.Verifier, error) {
+	hash, err := signingcrypto.ParseHash(device.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	verifier, err := signingcrypto.NewHashVerifier(device.PublicKey, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build verifier: %w", err)
+	}
+	return verifier, nil
+}
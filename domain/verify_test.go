@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+func TestVerifySignaturesLastSignature(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := newFakeClock(start)
+	service := NewSignatureDeviceService(newMockStorage(), WithClock(clock))
+	device, _ := service.CreateDevice(model.CreateDeviceOptions{ID: "device-verify-001", Algorithm: "ECC"})
+	resp, err := service.SignData(model.SignDataOptions{DeviceID: device.ID, Data: "ticket", ExpiresIn: 60})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	lastSignature := device.History[0].LastSignature
+	clock.Advance(time.Hour)
+
+	verify := func(entry model.VerifySignatureOptions) model.VerifyResult {
+		entry.Data, entry.Signature = "ticket", resp.Signature
+		results, err := service.VerifySignatures(model.BatchVerifyOptions{DeviceID: device.ID, Entries: []model.VerifySignatureOptions{entry}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return results[0]
+	}
+
+	t.Run("expired signature is expired", func(t *testing.T) {
+		result := verify(model.VerifySignatureOptions{LastSignature: lastSignature, ExpiresAt: resp.ExpiresAt})
+		if result.Valid || result.Reason != model.VerifyReasonExpired {
+			t.Errorf("expected an expired signature, got %+v", result)
+		}
+	})
+
+	t.Run("expiry moved into last_signature is rejected", func(t *testing.T) {
+		moved := lastSignature + DefaultSeparator + ExpirySegmentPrefix + strconv.FormatInt(resp.ExpiresAt.Unix(), 10)
+		if result := verify(model.VerifySignatureOptions{LastSignature: moved}); result.Valid {
+			t.Errorf("expected the expired signature to stay invalid, got %+v", result)
+		}
+	})
+
+	t.Run("non-base64 last_signature is rejected", func(t *testing.T) {
+		for _, lastSignature := range []string{"", "not base64!", lastSignature + "_"} {
+			if result := verify(model.VerifySignatureOptions{LastSignature: lastSignature}); result.Valid || result.Error == "" {
+				t.Errorf("expected %q to be rejected, got %+v", lastSignature, result)
+			}
+		}
+	})
+}
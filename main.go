@@ -2,9 +2,11 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/bayuhutajulu/signing-service/api"
 	"github.com/bayuhutajulu/signing-service/domain"
+	"github.com/bayuhutajulu/signing-service/model"
 	"github.com/bayuhutajulu/signing-service/persistence"
 )
 
@@ -13,11 +15,143 @@ const (
 )
 
 func main() {
-	storage := persistence.NewInMemoryStorage()
-	service := domain.NewSignatureDeviceService(storage)
-	server := api.NewServer(ListenAddress, service)
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
 
-	if err := server.Run(); err != nil {
+	keyDefaults, err := loadKeyGenerationDefaults()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	verifyCache, err := loadVerifyCacheOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	signLimit, err := loadSignLimitOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	rateLimit, err := loadRateLimitOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	responseHeaders, err := loadResponseHeadersOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	unwrapResponses, err := loadUnwrapResponsesOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	maxConnections, err := loadMaxConnectionsOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	metricsLimit, err := loadMetricsDeviceLimitOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	maxListDevices, err := loadMaxListDevicesOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	timeoutOpts, err := loadTimeoutOptions()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	tlsOpts, err := loadTLSOptions()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	responseSigner, err := loadResponseSignerOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	idPolicy, err := loadDeviceIDPolicyOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	printableOnly, err := loadPrintableOnlyOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	idStrategy, err := loadDeviceIDStrategyOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	labelTemplate, err := loadLabelTemplateOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	readOnly, err := loadReadOnlyOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	maxDataLength, err := loadMaxSignDataLengthOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	rejectEmptyData, err := loadRejectEmptyDataOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	keygenPool, err := loadKeyGenPoolOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	maxHistory, err := loadMaxHistoryEntriesOption()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	reconcileInterval, err := loadReconcileInterval()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	storageCapacity, err := loadStorageCapacity()
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	storage := persistence.NewInMemoryStorageWithCapacity(storageCapacity)
+	service := domain.NewSignatureDeviceService(storage,
+		domain.WithKeyGenerationDefaults(keyDefaults),
+		verifyCache,
+		signLimit,
+		idStrategy,
+		idPolicy,
+		printableOnly,
+		labelTemplate,
+		readOnly,
+		maxDataLength,
+		rejectEmptyData,
+		keygenPool,
+		maxHistory,
+	)
+	serverOpts := append([]api.ServerOption{rateLimit, responseHeaders, unwrapResponses, maxConnections, metricsLimit, maxListDevices, responseSigner}, loadAuthOptions()...)
+	serverOpts = append(serverOpts, tlsOpts...)
+	serverOpts = append(serverOpts, timeoutOpts...)
+	serverOpts = append(serverOpts, loadJWKSHostsOption())
+	server := api.NewServer(ListenAddress, service, serverOpts...)
+
+	var reconciler *domain.Reconciler
+	if reconcileInterval > 0 {
+		reconciler = service.StartReconciler(reconcileInterval, logReconcileReport)
+	}
+
+	err = server.Run()
+	// log.Fatal skips deferred calls, so the job is stopped explicitly.
+	if reconciler != nil {
+		reconciler.Stop()
+	}
+	if err != nil {
 		log.Fatal("Could not start server on ", ListenAddress)
 	}
 }
+
+// logReconcileReport logs every device a reconciliation pass found inconsistent.
+func logReconcileReport(report *model.ReconcileReport) {
+	for _, discrepancy := range report.Discrepancies {
+		log.Printf("Reconcile: device %s is inconsistent: %s", discrepancy.DeviceID, discrepancy.Error)
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type AttestOptions struct {
+	DeviceID  string
+	Signature string
+	// Nonce is required by devices created with RequireNonce and bound into the signed data.
+	Nonce string
+}
+
+type AttestRequest struct {
+	Signature string `json:"signature"`
+	Nonce     string `json:"nonce"`
+}
+
+func (r *AttestRequest) ToOptions() AttestOptions {
+	return AttestOptions{
+		Signature: r.Signature,
+		Nonce:     r.Nonce,
+	}
+}
+
+type AttestResponse struct {
+	Signature  string    `json:"signature"`
+	SignedData string    `json:"signed_data"`
+	Digest     string    `json:"digest"`
+	Nonce      string    `json:"nonce,omitempty"`
+	Counter    int64     `json:"counter"`
+	Timestamp  time.Time `json:"timestamp"`
+}
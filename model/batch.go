@@ -0,0 +1,23 @@
+package model
+
+// BatchGetDevicesRequest lists the device IDs to fetch in one call.
+type BatchGetDevicesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// Statuses reported per ID by a bulk delete.
+const (
+	DeleteStatusDeleted  = "deleted"
+	DeleteStatusNotFound = "not_found"
+)
+
+// BulkDeleteDevicesRequest lists the device IDs to delete in one call.
+type BulkDeleteDevicesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// DeleteResult reports the outcome of deleting one device of a bulk delete.
+type DeleteResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
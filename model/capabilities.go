@@ -0,0 +1,12 @@
+package model
+
+// DeviceCapabilities describes the operations a device currently supports.
+type DeviceCapabilities struct {
+	Sign               bool     `json:"sign"`
+	Verify             bool     `json:"verify"`
+	VerifyOnly         bool     `json:"verify_only"`
+	Locked             bool     `json:"locked"`
+	Hashes             []string `json:"hashes"` // The hash the device signs with
+	SignatureEncodings []string `json:"signature_encodings"`
+	Formats            []string `json:"formats"` // Optional sign output formats besides the default
+}
@@ -1,41 +1,303 @@
 package model
 
-import signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+import (
+	"time"
 
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+// SignatureDevice is the domain representation of a signing device. Key material and the
+// signer/verifier are tagged json:"-" so marshaling a device can never leak them.
 type SignatureDevice struct {
 	ID               string
 	Label            string
 	Algorithm        string
-	SignatureCounter int
+	SignatureCounter int64
 	LastSignature    string
-	PublicKey        interface{}
-	PrivateKey       interface{}
-	Signer           signingcrypto.Signer
+	PublicKey        interface{}            `json:"-"`
+	PrivateKey       interface{}            `json:"-"`
+	Signer           signingcrypto.Signer   `json:"-"`
+	Verifier         signingcrypto.Verifier `json:"-"`
+	Deterministic    bool
+	// Hash names the digest signed by the device, e.g. "SHA-384"; empty means SHA-256.
+	Hash             string
+	Separator        string
+	CounterEncoding  string
+	CreatedAt        time.Time
+	RejectDuplicates bool
+	// MaxHistoryEntries caps the retained history; zero defers to the service-wide limit.
+	MaxHistoryEntries int
+	// RequireNonce makes SignData and AttestSignature demand a fresh client nonce per signature.
+	RequireNonce bool
+	// PrunedHistory counts the oldest history records dropped by retention.
+	PrunedHistory int64
+	FirstSignedAt time.Time
+	LastSignedAt  time.Time
+	History       []SignatureRecord
+	Certificate   []byte `json:"-"`
+	Locked        bool
+	// MigratedFrom names the device whose chain this device continues after a key migration.
+	MigratedFrom string
+	// MigratedTo names the device that took over this device's chain after a key migration.
+	MigratedTo string
+	// TenantID scopes the device to one tenant; empty devices belong to no tenant.
+	TenantID string
+	// SignQuota caps the signatures, attestations included, made in any sliding QuotaWindow;
+	// further requests are refused until the oldest leaves the window. Zero leaves signing
+	// unlimited.
+	SignQuota   int
+	QuotaWindow time.Duration
 }
 
 type CreateDeviceOptions struct {
-	ID        string
-	Label     string
-	Algorithm string
+	ID            string
+	Label         string
+	Algorithm     string
+	Deterministic bool
+	Separator     string
+	KeySize       int
+	Curve         string
+	// Hash selects the digest to sign; empty applies the algorithm's hash policy.
+	Hash string
+	// CounterEncoding selects how the counter appears in signed_data: decimal, padded or hex.
+	CounterEncoding string
+	// RejectDuplicates makes SignData refuse data the device has signed before.
+	RejectDuplicates bool
+	// MaxHistoryEntries caps the device's retained history, pruning the oldest records.
+	MaxHistoryEntries int
+	// RequireNonce makes SignData demand a fresh client nonce per signature.
+	RequireNonce bool
+	// ImportPublicKeyPEM registers a verify-only device for an externally held key pair.
+	ImportPublicKeyPEM string
+	// ImportJWK creates the device from an RSA or EC JSON Web Key instead of generating one.
+	ImportJWK []byte
+	// TenantID scopes the device to one tenant.
+	TenantID string
+	// SignQuota caps the signatures made per QuotaWindow, which must be set along with it.
+	SignQuota   int
+	QuotaWindow time.Duration
+}
+
+// DeviceFilter narrows a device listing. Unset criteria match every device; counter bounds
+// are inclusive.
+type DeviceFilter struct {
+	Algorithm  string
+	MinCounter *int64
+	MaxCounter *int64
+}
+
+// Matches reports whether device satisfies every criterion of the filter.
+func (f DeviceFilter) Matches(device *SignatureDevice) bool {
+	if f.Algorithm != "" && device.Algorithm != f.Algorithm {
+		return false
+	}
+	if f.MinCounter != nil && device.SignatureCounter < *f.MinCounter {
+		return false
+	}
+	if f.MaxCounter != nil && device.SignatureCounter > *f.MaxCounter {
+		return false
+	}
+	return true
+}
+
+// MigrateDeviceOptions describes the device taking over SourceID's chain with new keys.
+// Empty fields fall back as in CreateDeviceOptions; an empty Label keeps the source's label.
+type MigrateDeviceOptions struct {
+	SourceID      string
+	ID            string
+	Label         string
+	Algorithm     string
+	KeySize       int
+	Curve         string
+	Deterministic bool
+}
+
+type MigrateDeviceRequest struct {
+	ID            string
+	Label         string
+	Algorithm     string
+	KeySize       int `json:"key_size"`
+	Curve         string
+	Deterministic bool
+}
+
+func (r *MigrateDeviceRequest) ToOptions() MigrateDeviceOptions {
+	return MigrateDeviceOptions{
+		ID:            r.ID,
+		Label:         r.Label,
+		Algorithm:     r.Algorithm,
+		KeySize:       r.KeySize,
+		Curve:         r.Curve,
+		Deterministic: r.Deterministic,
+	}
 }
 
 type CreateDeviceRequest struct {
-	ID        string
-	Label     string
-	Algorithm string
+	ID                 string
+	Label              string
+	Algorithm          string
+	Deterministic      bool
+	Separator          string
+	KeySize            int `json:"key_size"`
+	Curve              string
+	Hash               string
+	ImportPublicKeyPEM string `json:"import_public_key_pem"`
+	CounterEncoding    string `json:"counter_encoding"`
+	RejectDuplicates   bool   `json:"reject_duplicates"`
+	MaxHistoryEntries  int    `json:"max_history_entries"`
+	RequireNonce       bool   `json:"require_nonce"`
+	TenantID           string `json:"tenant_id"`
+	SignQuota          int    `json:"sign_quota"`
+	QuotaWindowSeconds int64  `json:"quota_window_seconds"`
 }
 
 func (r *CreateDeviceRequest) ToOptions() CreateDeviceOptions {
 	return CreateDeviceOptions{
-		ID:        r.ID,
-		Label:     r.Label,
-		Algorithm: r.Algorithm,
+		ID:                 r.ID,
+		Label:              r.Label,
+		Algorithm:          r.Algorithm,
+		Deterministic:      r.Deterministic,
+		Separator:          r.Separator,
+		KeySize:            r.KeySize,
+		Curve:              r.Curve,
+		Hash:               r.Hash,
+		ImportPublicKeyPEM: r.ImportPublicKeyPEM,
+		CounterEncoding:    r.CounterEncoding,
+		RejectDuplicates:   r.RejectDuplicates,
+		MaxHistoryEntries:  r.MaxHistoryEntries,
+		RequireNonce:       r.RequireNonce,
+		TenantID:           r.TenantID,
+		SignQuota:          r.SignQuota,
+		QuotaWindow:        time.Duration(r.QuotaWindowSeconds) * time.Second,
 	}
 }
 
 type DeviceResponse struct {
-	ID               string `json:"id"`
-	Label            string `json:"label"`
-	Algorithm        string `json:"algorithm"`
-	SignatureCounter int    `json:"signature_counter"`
+	ID                  string     `json:"id"`
+	Label               string     `json:"label"`
+	Algorithm           string     `json:"algorithm"`
+	Hash                string     `json:"hash"`
+	SignatureCounter    int64      `json:"signature_counter"`
+	Separator           string     `json:"separator"`
+	CounterEncoding     string     `json:"counter_encoding"`
+	CreatedAt           *time.Time `json:"created_at,omitempty"`
+	RejectDuplicates    bool       `json:"reject_duplicates"`
+	MaxHistoryEntries   int        `json:"max_history_entries,omitempty"`
+	PrunedHistory       int64      `json:"pruned_history_entries,omitempty"`
+	RequireNonce        bool       `json:"require_nonce"`
+	FirstSignedAt       *time.Time `json:"first_signed_at,omitempty"`
+	LastSignedAt        *time.Time `json:"last_signed_at,omitempty"`
+	SignaturesPerMinute float64    `json:"signatures_per_minute"`
+	VerifyOnly          bool       `json:"verify_only,omitempty"`
+	Locked              bool       `json:"locked"`
+	MigratedFrom        string     `json:"migrated_from,omitempty"`
+	MigratedTo          string     `json:"migrated_to,omitempty"`
+	TenantID            string     `json:"tenant_id,omitempty"`
+	SignQuota           int        `json:"sign_quota,omitempty"`
+	QuotaWindowSeconds  int64      `json:"quota_window_seconds,omitempty"`
+}
+
+// CreateDeviceResponse is returned when a device is created. It adds the device's base-case
+// last_signature, base64(id), so clients continuing the chain themselves can build the first
+// signed payload without fetching the device again.
+type CreateDeviceResponse struct {
+	DeviceResponse
+	LastSignature string `json:"last_signature"`
+}
+
+// ChainHeadResponse is the minimal view of a device for clients polling only the chain head.
+type ChainHeadResponse struct {
+	Counter       int64  `json:"counter"`
+	LastSignature string `json:"last_signature"`
+}
+
+// ChainTip is the chain head of one device in a fleet-wide listing.
+type ChainTip struct {
+	ID string `json:"id"`
+	ChainHeadResponse
+}
+
+// ChainTipsPage is one page of chain tips ordered by device ID. Next is the cursor for the
+// following page and is empty on the last one.
+type ChainTipsPage struct {
+	Chains []ChainTip `json:"chains"`
+	Next   string     `json:"next,omitempty"`
+}
+
+type CompareDevicesResponse struct {
+	SamePublicKey bool `json:"same_public_key"`
+}
+
+func (d *SignatureDevice) ToResponse() DeviceResponse {
+	response := DeviceResponse{
+		ID:                d.ID,
+		Label:             d.Label,
+		Algorithm:         d.Algorithm,
+		Hash:              d.Hash,
+		SignatureCounter:  d.SignatureCounter,
+		Separator:         d.Separator,
+		CounterEncoding:   d.CounterEncoding,
+		RejectDuplicates:  d.RejectDuplicates,
+		MaxHistoryEntries: d.MaxHistoryEntries,
+		PrunedHistory:     d.PrunedHistory,
+		RequireNonce:      d.RequireNonce,
+		VerifyOnly:        d.IsVerifyOnly(),
+		Locked:            d.Locked,
+		MigratedFrom:      d.MigratedFrom,
+		MigratedTo:        d.MigratedTo,
+		TenantID:          d.TenantID,
+	}
+	if response.Hash == "" {
+		response.Hash = signingcrypto.DefaultHash
+	}
+	if d.SignQuota > 0 {
+		response.SignQuota = d.SignQuota
+		response.QuotaWindowSeconds = int64(d.QuotaWindow / time.Second)
+	}
+	if !d.CreatedAt.IsZero() {
+		created := d.CreatedAt
+		response.CreatedAt = &created
+	}
+	if !d.FirstSignedAt.IsZero() {
+		first, last := d.FirstSignedAt, d.LastSignedAt
+		response.FirstSignedAt = &first
+		response.LastSignedAt = &last
+		response.SignaturesPerMinute = d.SignaturesPerMinute()
+	}
+	return response
+}
+
+// ToCreateResponse returns the device info sent back when the device is created.
+func (d *SignatureDevice) ToCreateResponse() CreateDeviceResponse {
+	return CreateDeviceResponse{
+		DeviceResponse: d.ToResponse(),
+		LastSignature:  d.LastSignature,
+	}
+}
+
+// SignaturesPerMinute averages the signature count over the period between the first and
+// last signature. Periods shorter than a minute count as one minute to avoid inflated rates.
+func (d *SignatureDevice) SignaturesPerMinute() float64 {
+	if d.SignatureCounter == 0 {
+		return 0
+	}
+	minutes := d.LastSignedAt.Sub(d.FirstSignedAt).Minutes()
+	if minutes < 1 {
+		minutes = 1
+	}
+	return float64(d.SignatureCounter) / minutes
+}
+
+// IsVerifyOnly reports whether the device lacks a private key and can therefore only verify.
+func (d *SignatureDevice) IsVerifyOnly() bool {
+	return d.Signer == nil && d.PrivateKey == nil
+}
+
+// AtRiskDevice is a device close to exhausting its signing quota for the current window.
+type AtRiskDevice struct {
+	ID             string    `json:"id"`
+	SignQuota      int       `json:"sign_quota"`
+	QuotaUsed      int       `json:"quota_used"`
+	QuotaRemaining int       `json:"quota_remaining"`
+	WindowResetsAt time.Time `json:"window_resets_at"`
 }
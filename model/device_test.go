@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	signingcrypto "github.com/bayuhutajulu/signing-service/crypto"
+)
+
+func TestSignatureDeviceJSON(t *testing.T) {
+	t.Run("RSA device marshals without key material", func(t *testing.T) {
+		keyPair, err := (&signingcrypto.RSAGenerator{}).Generate()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		device := &SignatureDevice{
+			ID:         "device-json-001",
+			Algorithm:  "RSA",
+			PublicKey:  keyPair.Public,
+			PrivateKey: keyPair.Private,
+			Signer:     signingcrypto.NewRSASigner(keyPair.Private),
+			Verifier:   signingcrypto.NewRSAVerifier(keyPair.Public),
+		}
+
+		body, err := json.Marshal(device)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		assertNoKeyFields(t, body)
+		if strings.Contains(string(body), keyPair.Private.D.String()) {
+			t.Error("expected private exponent to be absent from JSON")
+		}
+		if strings.Contains(string(body), keyPair.Public.N.String()) {
+			t.Error("expected modulus to be absent from JSON")
+		}
+	})
+
+	t.Run("ECC device marshals without key material", func(t *testing.T) {
+		keyPair, err := (&signingcrypto.ECCGenerator{}).Generate()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		device := &SignatureDevice{
+			ID:         "device-json-002",
+			Algorithm:  "ECC",
+			PublicKey:  keyPair.Public,
+			PrivateKey: keyPair.Private,
+			Signer:     signingcrypto.NewECDSASigner(keyPair.Private),
+			Verifier:   signingcrypto.NewECDSAVerifier(keyPair.Public),
+		}
+
+		body, err := json.Marshal(device)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		assertNoKeyFields(t, body)
+		if strings.Contains(string(body), keyPair.Private.D.String()) {
+			t.Error("expected private scalar to be absent from JSON")
+		}
+	})
+}
+
+func assertNoKeyFields(t *testing.T, body []byte) {
+	t.Helper()
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	for _, key := range []string{"PublicKey", "PrivateKey", "Signer", "Verifier"} {
+		if _, exists := fields[key]; exists {
+			t.Errorf("expected %s to be omitted from JSON", key)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+// FeatureFlags reports the runtime feature flags of the service.
+type FeatureFlags struct {
+	Signing        bool `json:"signing"`
+	DeviceCreation bool `json:"device_creation"`
+}
+
+// FeatureFlagsUpdate changes the flags that are set and leaves the others untouched.
+type FeatureFlagsUpdate struct {
+	Signing        *bool `json:"signing"`
+	DeviceCreation *bool `json:"device_creation"`
+}
+
+// IsEmpty reports whether the update changes no flag.
+func (u FeatureFlagsUpdate) IsEmpty() bool {
+	return u.Signing == nil && u.DeviceCreation == nil
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Record types distinguish regular signatures from attestations in a device's history.
+const (
+	RecordTypeSignature   = "signature"
+	RecordTypeAttestation = "attestation"
+)
+
+// SignatureRecord is one entry in a device's signature history. Data holds the signed data
+// segment: the client payload for signatures, the attestation digest for attestations.
+type SignatureRecord struct {
+	Type          string     `json:"type"`
+	Counter       int64      `json:"counter"`
+	Data          string     `json:"data"`
+	LastSignature string     `json:"last_signature"`
+	AAD           string     `json:"aad,omitempty"`
+	Nonce         string     `json:"nonce,omitempty"`
+	Purpose       string     `json:"purpose,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	SignedData    string     `json:"signed_data"`
+	Signature     string     `json:"signature"`
+	SignedAt      time.Time  `json:"signed_at"`
+}
@@ -0,0 +1,46 @@
+package model
+
+// IntegrityReport lists the counters missing from a device's signature history.
+type IntegrityReport struct {
+	OK      bool    `json:"ok"`
+	Missing []int64 `json:"missing"`
+}
+
+// SelfVerifyFailure describes a history record that failed re-verification.
+type SelfVerifyFailure struct {
+	Counter int64  `json:"counter"`
+	Error   string `json:"error"`
+}
+
+// SelfVerifyReport is the outcome of re-verifying a device's stored history.
+type SelfVerifyReport struct {
+	OK       bool                `json:"ok"`
+	Verified int                 `json:"verified"`
+	Failures []SelfVerifyFailure `json:"failures"`
+}
+
+// ReconcileDiscrepancy describes a device whose stored chain head disagrees with its history.
+type ReconcileDiscrepancy struct {
+	DeviceID string `json:"device_id"`
+	Error    string `json:"error"`
+}
+
+// ReconcileReport is the outcome of one reconciliation pass over all stored devices.
+type ReconcileReport struct {
+	Checked       int                    `json:"checked"`
+	Discrepancies []ReconcileDiscrepancy `json:"discrepancies"`
+}
+
+// SelfTestResult is the outcome of the sign-and-verify self-test for one algorithm.
+type SelfTestResult struct {
+	Algorithm  string  `json:"algorithm"`
+	OK         bool    `json:"ok"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SelfTestReport is the outcome of the self-test across all algorithms; OK only if all passed.
+type SelfTestReport struct {
+	OK      bool             `json:"ok"`
+	Results []SelfTestResult `json:"results"`
+}
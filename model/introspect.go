@@ -0,0 +1,43 @@
+package model
+
+// IntrospectOptions describes a signature of unknown provenance to examine.
+type IntrospectOptions struct {
+	TenantID   string
+	SignedData string
+	Signature  string
+	// KID names the device expected to have produced the signature; empty scans every device.
+	KID string
+}
+
+type IntrospectRequest struct {
+	SignedData string `json:"signed_data"`
+	Signature  string `json:"signature"`
+	KID        string `json:"kid"`
+}
+
+func (r *IntrospectRequest) ToOptions() IntrospectOptions {
+	return IntrospectOptions{
+		SignedData: r.SignedData,
+		Signature:  r.Signature,
+		KID:        r.KID,
+	}
+}
+
+// ChainSegments are the segments extracted from a signed_data string.
+type ChainSegments struct {
+	Counter       int64  `json:"counter"`
+	Data          string `json:"data"`
+	LastSignature string `json:"last_signature"`
+}
+
+// IntrospectReport describes what a signed_data string contains and which device, if any,
+// produced the signature over it.
+type IntrospectReport struct {
+	// Chain is nil when signed_data cannot be parsed in the chain format.
+	Chain          *ChainSegments `json:"chain,omitempty"`
+	ParseError     string         `json:"parse_error,omitempty"`
+	Identified     bool           `json:"identified"`
+	DeviceID       string         `json:"device_id,omitempty"`
+	Algorithm      string         `json:"algorithm,omitempty"`
+	DevicesScanned int            `json:"devices_scanned"`
+}
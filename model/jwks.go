@@ -0,0 +1,36 @@
+package model
+
+import "encoding/json"
+
+// VerifyJWKSRequest verifies a signature against a key from a JSON Web Key Set, given either
+// inline or by URL.
+type VerifyJWKSRequest struct {
+	JWKSURL   string          `json:"jwks_url"`
+	JWKS      json.RawMessage `json:"jwks,omitempty"`
+	KID       string          `json:"kid"`
+	Data      string          `json:"data"`
+	Signature string          `json:"signature"`
+}
+
+// ImportJWKRequest creates a device from a JSON Web Key instead of generating a key pair.
+type ImportJWKRequest struct {
+	ID              string
+	Label           string
+	JWK             json.RawMessage `json:"jwk"`
+	Deterministic   bool
+	Separator       string
+	CounterEncoding string `json:"counter_encoding"`
+	TenantID        string `json:"tenant_id"`
+}
+
+func (r *ImportJWKRequest) ToOptions() CreateDeviceOptions {
+	return CreateDeviceOptions{
+		ID:              r.ID,
+		Label:           r.Label,
+		ImportJWK:       r.JWK,
+		Deterministic:   r.Deterministic,
+		Separator:       r.Separator,
+		CounterEncoding: r.CounterEncoding,
+		TenantID:        r.TenantID,
+	}
+}
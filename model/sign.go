@@ -1,21 +1,80 @@
 package model
 
+import "time"
+
+// SignatureFormatCMS requests the signature additionally wrapped in a detached CMS/PKCS#7 structure.
+const SignatureFormatCMS = "cms"
+
+// SignatureFormatTagged requests the signature additionally prefixed with its algorithm tag.
+const SignatureFormatTagged = "tagged"
+
+// SignatureFormatMultibase requests the signature additionally encoded as multibase base58btc.
+const SignatureFormatMultibase = "multibase"
+
 type SignDataOptions struct {
 	DeviceID string
 	Data     string
+	// Format additionally returns the signature as a base64 detached CMS structure ("cms"),
+	// prefixed with the algorithm's signature tag ("tagged") or in multibase base58btc
+	// ("multibase"). Empty returns the plain base64 signature only.
+	Format string
+	// AAD is bound into the signed data as its last segment; it must not start with "exp=".
+	AAD string
+	// Nonce is a client-supplied value bound into the signed data. Devices created with
+	// RequireNonce need a well-formed nonce they have not seen; other devices accept none.
+	Nonce string
+	// Purpose labels the signature in the history (e.g. "invoice"); it is not signed.
+	Purpose string
+	// ExpectedCounter, when set, makes signing a compare-and-sign: it fails, without advancing
+	// the counter, unless the device's counter equals it.
+	ExpectedCounter *int64
+	// OperationID makes the request idempotent: a retry with the same ID returns the original
+	// response without signing again, and a retry differing in any other option that shapes
+	// the response is refused as a conflict.
+	OperationID string
+	// ExpiresIn, when positive, binds an expiry this many seconds after signing into the
+	// signed data, at most ten years ahead.
+	ExpiresIn int64
 }
 
 type SignDataRequest struct {
-	Data string
+	Data            string
+	Format          string
+	AAD             string
+	Nonce           string
+	Purpose         string
+	ExpectedCounter *int64 `json:"expected_counter"`
+	OperationID     string `json:"operation_id"`
+	ExpiresIn       int64  `json:"expires_in"` // Seconds the signature stays valid; 0 never expires
 }
 
 func (r *SignDataRequest) ToOptions() SignDataOptions {
 	return SignDataOptions{
-		Data: r.Data,
+		Data:            r.Data,
+		Format:          r.Format,
+		AAD:             r.AAD,
+		Nonce:           r.Nonce,
+		Purpose:         r.Purpose,
+		ExpectedCounter: r.ExpectedCounter,
+		OperationID:     r.OperationID,
+		ExpiresIn:       r.ExpiresIn,
 	}
 }
 
 type SignDataResponse struct {
-	Signature  string `json:"signature"`
-	SignedData string `json:"signed_data"`
+	Signature       string `json:"signature"`
+	TaggedSignature string `json:"tagged_signature,omitempty"`
+	// MultibaseSignature is the raw signature in multibase base58btc, e.g. "z3sP2...".
+	MultibaseSignature string `json:"multibase_signature,omitempty"`
+	SignedData         string `json:"signed_data"`
+	DataHash           string `json:"data_hash"`
+	CMS                string `json:"cms,omitempty"`
+	AAD                string `json:"aad,omitempty"`
+	Nonce              string `json:"nonce,omitempty"`
+	Purpose            string `json:"purpose,omitempty"`
+	// ExpiresAt is the expiry bound into the signed data, to be passed back when verifying.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// QuotaRemaining is the number of signatures left in the device's sliding quota window,
+	// or nil for devices without a quota. It is sent as a header rather than in the body.
+	QuotaRemaining *int `json:"-"`
 }
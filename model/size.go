@@ -0,0 +1,12 @@
+package model
+
+// DeviceSizeResponse estimates the storage footprint of a device in bytes.
+type DeviceSizeResponse struct {
+	PublicKeyBytes   int   `json:"public_key_bytes"`
+	PrivateKeyBytes  int   `json:"private_key_bytes"`
+	CertificateBytes int   `json:"certificate_bytes"`
+	HistoryEntries   int   `json:"history_entries"`
+	HistoryBytes     int64 `json:"history_bytes"`
+	DataBytes        int64 `json:"data_bytes"`
+	TotalBytes       int64 `json:"total_bytes"`
+}
@@ -0,0 +1,18 @@
+package model
+
+type DeviceSignatureCount struct {
+	ID               string `json:"id"`
+	SignatureCounter int64  `json:"signature_counter"`
+}
+
+type ThroughputResponse struct {
+	Window     string `json:"window"`
+	Signatures int    `json:"signatures"`
+}
+
+type SignatureStatsResponse struct {
+	TotalSignatures int64                  `json:"total_signatures"`
+	TopDevices      []DeviceSignatureCount `json:"top_devices"`
+	// Errors counts failures by algorithm, then by category (keygen, sign, storage).
+	Errors map[string]map[string]int64 `json:"errors"`
+}
@@ -0,0 +1,19 @@
+package model
+
+// Ratings of a key strength assessment.
+const (
+	KeyRatingOK   = "ok"
+	KeyRatingWeak = "weak"
+)
+
+// KeyStrength assesses a device key for audits. SecurityBits is the lower of the key's and
+// the signature hash's estimated strength.
+type KeyStrength struct {
+	Algorithm    string `json:"algorithm"`
+	KeySize      int    `json:"key_size,omitempty"` // RSA modulus size in bits
+	Curve        string `json:"curve,omitempty"`
+	Hash         string `json:"hash"`
+	KeyBits      int    `json:"key_security_bits"`
+	SecurityBits int    `json:"security_bits"`
+	Rating       string `json:"rating"`
+}
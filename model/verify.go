@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// VerifyReasonExpired is the reason given for a valid signature whose bound expiry has passed.
+const VerifyReasonExpired = "expired"
+
+type VerifySignatureOptions struct {
+	Data          string
+	Signature     string
+	Counter       int64
+	LastSignature string
+	AAD           string
+	Nonce         string
+	ExpiresAt     *time.Time
+}
+
+type BatchVerifyOptions struct {
+	DeviceID string
+	Entries  []VerifySignatureOptions
+}
+
+type VerifySignatureRequest struct {
+	Data          string     `json:"data"`
+	Signature     string     `json:"signature"`
+	Counter       int64      `json:"counter"`
+	LastSignature string     `json:"last_signature"`
+	AAD           string     `json:"aad"`
+	Nonce         string     `json:"nonce"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+func (r *VerifySignatureRequest) ToOptions() VerifySignatureOptions {
+	return VerifySignatureOptions{
+		Data:          r.Data,
+		Signature:     r.Signature,
+		Counter:       r.Counter,
+		LastSignature: r.LastSignature,
+		AAD:           r.AAD,
+		Nonce:         r.Nonce,
+		ExpiresAt:     r.ExpiresAt,
+	}
+}
+
+type VerifyAtOptions struct {
+	DeviceID  string
+	Counter   int64
+	Data      string
+	Signature string
+}
+
+type VerifyAtRequest struct {
+	Counter   int64  `json:"counter"`
+	Data      string `json:"data"`
+	Signature string `json:"signature"`
+}
+
+func (r *VerifyAtRequest) ToOptions() VerifyAtOptions {
+	return VerifyAtOptions{
+		Counter:   r.Counter,
+		Data:      r.Data,
+		Signature: r.Signature,
+	}
+}
+
+type VerifyResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// Reason names why an otherwise genuine signature is not valid, e.g. VerifyReasonExpired.
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,147 @@
+package persistence
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// ErrCircuitOpen is returned without calling the backend while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("storage circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreakerStorage decorates a DeviceStorage so a failing backend is not hammered.
+// After threshold consecutive failures the breaker opens and calls fail fast with
+// ErrCircuitOpen for the cooldown. It then half-opens: a single probe call goes through,
+// closing the breaker on success and reopening it on failure. Not-found and already-exists
+// results are answers from a healthy backend, so they do not count as failures.
+type CircuitBreakerStorage struct {
+	backend   domain.DeviceStorage
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Compile-time check that CircuitBreakerStorage implements DeviceStorage interface.
+var _ domain.DeviceStorage = (*CircuitBreakerStorage)(nil)
+
+// NewCircuitBreakerStorage wraps backend with a breaker that opens after threshold
+// consecutive failures and stays open for cooldown.
+func NewCircuitBreakerStorage(backend domain.DeviceStorage, threshold int, cooldown time.Duration) *CircuitBreakerStorage {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreakerStorage{
+		backend:   backend,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Save saves the device through the breaker.
+func (s *CircuitBreakerStorage) Save(device *model.SignatureDevice) error {
+	return s.call(func() error { return s.backend.Save(device) })
+}
+
+// Update updates the device through the breaker.
+func (s *CircuitBreakerStorage) Update(device *model.SignatureDevice) error {
+	return s.call(func() error { return s.backend.Update(device) })
+}
+
+// GetDevice looks up the device through the breaker.
+func (s *CircuitBreakerStorage) GetDevice(id string) (*model.SignatureDevice, error) {
+	var device *model.SignatureDevice
+	err := s.call(func() error {
+		var err error
+		device, err = s.backend.GetDevice(id)
+		return err
+	})
+	return device, err
+}
+
+// GetAllDevices lists the devices through the breaker.
+func (s *CircuitBreakerStorage) GetAllDevices() ([]*model.SignatureDevice, error) {
+	var devices []*model.SignatureDevice
+	err := s.call(func() error {
+		var err error
+		devices, err = s.backend.GetAllDevices()
+		return err
+	})
+	return devices, err
+}
+
+// Delete removes the device through the breaker.
+func (s *CircuitBreakerStorage) Delete(id string) error {
+	return s.call(func() error { return s.backend.Delete(id) })
+}
+
+// call runs fn unless the breaker is open, and records its outcome.
+func (s *CircuitBreakerStorage) call(fn func() error) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+	err := fn()
+	s.record(err == nil || errors.Is(err, domain.ErrDeviceNotFound) || errors.Is(err, domain.ErrDeviceExists))
+	return err
+}
+
+// allow reports whether a call may reach the backend, half-opening the breaker once the
+// cooldown has passed and admitting one probe at a time.
+func (s *CircuitBreakerStorage) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.state {
+	case breakerOpen:
+		if s.now().Sub(s.openedAt) < s.cooldown {
+			return false
+		}
+		s.state = breakerHalfOpen
+		s.probing = true
+		return true
+	case breakerHalfOpen:
+		if s.probing {
+			return false
+		}
+		s.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (s *CircuitBreakerStorage) record(success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if success {
+		s.state = breakerClosed
+		s.failures = 0
+		s.probing = false
+		return
+	}
+
+	s.failures++
+	if s.state == breakerHalfOpen || s.failures >= s.threshold {
+		s.state = breakerOpen
+		s.openedAt = s.now()
+		s.probing = false
+	}
+}
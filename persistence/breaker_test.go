@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	model "github.com/bayuhutajulu/signing-service/model"
+	"github.com/bayuhutajulu/signing-service/persistence/storagetest"
+)
+
+// flakyStorage fails every call while err is set and counts the calls that reach it.
+type flakyStorage struct {
+	*InMemoryStorage
+	err   error
+	calls int
+}
+
+func (s *flakyStorage) GetDevice(id string) (*model.SignatureDevice, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.InMemoryStorage.GetDevice(id)
+}
+
+func (s *flakyStorage) Update(device *model.SignatureDevice) error {
+	s.calls++
+	if s.err != nil {
+		return s.err
+	}
+	return s.InMemoryStorage.Update(device)
+}
+
+func TestCircuitBreakerStorage(t *testing.T) {
+	newBreaker := func() (*CircuitBreakerStorage, *flakyStorage, *time.Time) {
+		backend := &flakyStorage{InMemoryStorage: NewInMemoryStorage()}
+		backend.InMemoryStorage.Save(createTestDevice("device-001", "Breaker", "ECC"))
+		breaker := NewCircuitBreakerStorage(backend, 3, time.Minute)
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		breaker.now = func() time.Time { return now }
+		return breaker, backend, &now
+	}
+
+	t.Run("trips after consecutive failures and fails fast", func(t *testing.T) {
+		breaker, backend, _ := newBreaker()
+		backend.err = errors.New("connection refused")
+
+		for i := 0; i < 3; i++ {
+			if _, err := breaker.GetDevice("device-001"); !errors.Is(err, backend.err) {
+				t.Fatalf("call %d: expected backend error, got %v", i, err)
+			}
+		}
+		for i := 0; i < 5; i++ {
+			if _, err := breaker.GetDevice("device-001"); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("expected ErrCircuitOpen, got %v", err)
+			}
+		}
+		if backend.calls != 3 {
+			t.Errorf("expected 3 backend calls, got %d", backend.calls)
+		}
+	})
+
+	t.Run("recovers after cooldown", func(t *testing.T) {
+		breaker, backend, now := newBreaker()
+		backend.err = errors.New("connection refused")
+		for i := 0; i < 3; i++ {
+			breaker.GetDevice("device-001")
+		}
+
+		backend.err = nil
+		*now = now.Add(time.Minute)
+
+		if _, err := breaker.GetDevice("device-001"); err != nil {
+			t.Fatalf("expected probe to succeed, got %v", err)
+		}
+		if err := breaker.Update(createTestDevice("device-001", "Recovered", "ECC")); err != nil {
+			t.Errorf("expected closed breaker to pass calls, got %v", err)
+		}
+	})
+
+	t.Run("failed probe reopens the breaker", func(t *testing.T) {
+		breaker, backend, now := newBreaker()
+		backend.err = errors.New("connection refused")
+		for i := 0; i < 3; i++ {
+			breaker.GetDevice("device-001")
+		}
+
+		*now = now.Add(time.Minute)
+		if _, err := breaker.GetDevice("device-001"); !errors.Is(err, backend.err) {
+			t.Fatalf("expected probe to reach the backend, got %v", err)
+		}
+		if _, err := breaker.GetDevice("device-001"); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected ErrCircuitOpen after failed probe, got %v", err)
+		}
+	})
+
+	t.Run("not found does not count as a failure", func(t *testing.T) {
+		breaker, backend, _ := newBreaker()
+
+		for i := 0; i < 5; i++ {
+			breaker.GetDevice("missing")
+		}
+		if _, err := breaker.GetDevice("device-001"); err != nil {
+			t.Errorf("expected breaker to stay closed, got %v", err)
+		}
+		if backend.calls != 6 {
+			t.Errorf("expected 6 backend calls, got %d", backend.calls)
+		}
+	})
+}
+
+func TestCircuitBreakerStorageConformance(t *testing.T) {
+	storagetest.StorageConformanceTest(t, func() domain.DeviceStorage {
+		return NewCircuitBreakerStorage(NewInMemoryStorage(), 3, time.Second)
+	})
+}
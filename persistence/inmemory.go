@@ -17,8 +17,18 @@ type InMemoryStorage struct {
 
 // NewInMemoryStorage creates an empty in-memory storage instance.
 func NewInMemoryStorage() *InMemoryStorage {
+	return NewInMemoryStorageWithCapacity(0)
+}
+
+// NewInMemoryStorageWithCapacity creates an empty in-memory storage instance with room for n
+// devices, sparing the map from growing while a known number of devices is created. The storage
+// still grows past n; a non-positive n is the same as NewInMemoryStorage.
+func NewInMemoryStorageWithCapacity(n int) *InMemoryStorage {
+	if n < 0 {
+		n = 0
+	}
 	return &InMemoryStorage{
-		devices: make(map[string]*model.SignatureDevice),
+		devices: make(map[string]*model.SignatureDevice, n),
 	}
 }
 
@@ -31,17 +41,21 @@ func (s *InMemoryStorage) Save(device *model.SignatureDevice) error {
 	defer s.mu.Unlock()
 
 	if _, exists := s.devices[device.ID]; exists {
-		return fmt.Errorf("device %s already exists", device.ID)
+		return fmt.Errorf("%w: %s", domain.ErrDeviceExists, device.ID)
 	}
 
 	s.devices[device.ID] = device
 	return nil
 }
 
-// Update overwrites an existing device in storage. Creates device if it doesn't exist.
+// Update overwrites an existing device in storage. Returns ErrDeviceNotFound if device does
+// not exist, so a mistyped ID can never create a device.
 func (s *InMemoryStorage) Update(device *model.SignatureDevice) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if _, exists := s.devices[device.ID]; !exists {
+		return domain.ErrDeviceNotFound
+	}
 	s.devices[device.ID] = device
 	return nil
 }
@@ -52,11 +66,47 @@ func (s *InMemoryStorage) GetDevice(id string) (*model.SignatureDevice, error) {
 	defer s.mu.RUnlock()
 	device, exists := s.devices[id]
 	if !exists {
-		return nil, fmt.Errorf("device not found")
+		return nil, domain.ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+// Delete removes a device from storage. Returns ErrDeviceNotFound if device does not exist.
+func (s *InMemoryStorage) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.devices[id]; !exists {
+		return domain.ErrDeviceNotFound
+	}
+	delete(s.devices, id)
+	return nil
+}
+
+// GetTenantDevice retrieves a device by ID if it belongs to tenantID. Returns ErrDeviceNotFound
+// for devices of other tenants as well as missing ones.
+func (s *InMemoryStorage) GetTenantDevice(tenantID, id string) (*model.SignatureDevice, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	device, exists := s.devices[id]
+	if !exists || device.TenantID != tenantID {
+		return nil, domain.ErrDeviceNotFound
 	}
 	return device, nil
 }
 
+// GetTenantDevices returns the devices belonging to tenantID. Returns empty slice if it has none.
+func (s *InMemoryStorage) GetTenantDevices(tenantID string) ([]*model.SignatureDevice, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	devices := make([]*model.SignatureDevice, 0)
+	for _, device := range s.devices {
+		if device.TenantID == tenantID {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
 // GetAllDevices returns all devices in storage. Returns empty slice if no devices exist.
 func (s *InMemoryStorage) GetAllDevices() ([]*model.SignatureDevice, error) {
 	s.mu.RLock()
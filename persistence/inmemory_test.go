@@ -1,12 +1,15 @@
 package persistence
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
 
 	"github.com/bayuhutajulu/signing-service/crypto"
+	"github.com/bayuhutajulu/signing-service/domain"
 	model "github.com/bayuhutajulu/signing-service/model"
+	"github.com/bayuhutajulu/signing-service/persistence/storagetest"
 )
 
 func createTestDevice(id, label, algorithm string) *model.SignatureDevice {
@@ -55,6 +58,35 @@ func TestNewInMemoryStorage(t *testing.T) {
 	})
 }
 
+func TestNewInMemoryStorageWithCapacity(t *testing.T) {
+	t.Run("behaves like the unsized storage", func(t *testing.T) {
+		for _, storage := range []*InMemoryStorage{NewInMemoryStorage(), NewInMemoryStorageWithCapacity(2)} {
+			for i := 0; i < 5; i++ {
+				if err := storage.Save(createTestDevice(fmt.Sprintf("device-%d", i), "Device", "ECC")); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+			if err := storage.Save(createTestDevice("device-0", "Duplicate", "ECC")); !errors.Is(err, domain.ErrDeviceExists) {
+				t.Errorf("expected ErrDeviceExists, got %v", err)
+			}
+			devices, err := storage.GetAllDevices()
+			if err != nil || len(devices) != 5 {
+				t.Errorf("expected 5 devices past the capacity, got %d, %v", len(devices), err)
+			}
+			if _, err := storage.GetDevice("device-4"); err != nil {
+				t.Errorf("expected device-4 to be found, got %v", err)
+			}
+		}
+	})
+
+	t.Run("negative capacity creates an empty storage", func(t *testing.T) {
+		storage := NewInMemoryStorageWithCapacity(-1)
+		if storage.devices == nil || len(storage.devices) != 0 {
+			t.Errorf("expected an empty map, got %v", storage.devices)
+		}
+	})
+}
+
 func TestSave(t *testing.T) {
 	t.Run("successfully saves device", func(t *testing.T) {
 		storage := NewInMemoryStorage()
@@ -141,18 +173,18 @@ func TestUpdate(t *testing.T) {
 		}
 	})
 
-	t.Run("creates device if not exists", func(t *testing.T) {
+	t.Run("returns error if device not exists", func(t *testing.T) {
 		storage := NewInMemoryStorage()
 		device := createTestDevice("device-007", "New Device", "ECC")
 
 		err := storage.Update(device)
 
-		if err != nil {
-			t.Fatalf("expected no error, got %v", err)
+		if !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Fatalf("expected ErrDeviceNotFound, got %v", err)
 		}
 
-		if len(storage.devices) != 1 {
-			t.Errorf("expected 1 device in storage, got %d", len(storage.devices))
+		if len(storage.devices) != 0 {
+			t.Errorf("expected no device in storage, got %d", len(storage.devices))
 		}
 	})
 
@@ -163,7 +195,7 @@ func TestUpdate(t *testing.T) {
 
 		storage.Save(device)
 
-		for i := 1; i <= 10; i++ {
+		for i := int64(1); i <= 10; i++ {
 			device.SignatureCounter = i
 			err := storage.Update(device)
 			if err != nil {
@@ -334,7 +366,7 @@ func TestConcurrentOperations(t *testing.T) {
 			go func(index int) {
 				defer wg.Done()
 				device := createTestDevice("device-concurrent-update", fmt.Sprintf("Label %d", index), "RSA")
-				device.SignatureCounter = index
+				device.SignatureCounter = int64(index)
 				storage.Update(device)
 			}(i)
 		}
@@ -433,3 +465,44 @@ func TestConcurrentOperations(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestInMemoryStorageConformance(t *testing.T) {
+	storagetest.StorageConformanceTest(t, func() domain.DeviceStorage {
+		return NewInMemoryStorage()
+	})
+}
+
+func TestInMemoryStorageWithCapacityConformance(t *testing.T) {
+	storagetest.StorageConformanceTest(t, func() domain.DeviceStorage {
+		return NewInMemoryStorageWithCapacity(16)
+	})
+}
+
+// BenchmarkBulkSave compares creating many devices in an unsized and a preallocated storage.
+func BenchmarkBulkSave(b *testing.B) {
+	const count = 10000
+	devices := make([]*model.SignatureDevice, count)
+	for i := range devices {
+		devices[i] = &model.SignatureDevice{ID: fmt.Sprintf("device-%d", i), Algorithm: "ECC"}
+	}
+
+	for _, bench := range []struct {
+		name    string
+		storage func() *InMemoryStorage
+	}{
+		{"unsized", NewInMemoryStorage},
+		{"preallocated", func() *InMemoryStorage { return NewInMemoryStorageWithCapacity(count) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				storage := bench.storage()
+				for _, device := range devices {
+					if err := storage.Save(device); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Package storagetest provides a conformance suite for domain.DeviceStorage implementations,
+// so every backend behaves like the in-memory reference.
+package storagetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// StorageConformanceTest runs the shared battery of storage assertions against the storage
+// returned by factory, which must return a new, empty storage on every call. Backends that
+// buffer writes must make them visible to reads immediately.
+func StorageConformanceTest(t *testing.T, factory func() domain.DeviceStorage) {
+	t.Helper()
+
+	t.Run("save and get", func(t *testing.T) {
+		storage := factory()
+		device := newDevice("device-001")
+
+		if err := storage.Save(device); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got, err := storage.GetDevice(device.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.ID != device.ID || got.Label != device.Label || got.Algorithm != device.Algorithm {
+			t.Errorf("expected %s/%s/%s, got %s/%s/%s", device.ID, device.Label, device.Algorithm,
+				got.ID, got.Label, got.Algorithm)
+		}
+		if got.LastSignature != device.LastSignature {
+			t.Errorf("expected last signature %q, got %q", device.LastSignature, got.LastSignature)
+		}
+	})
+
+	t.Run("duplicate save", func(t *testing.T) {
+		storage := factory()
+		storage.Save(newDevice("device-001"))
+
+		duplicate := newDevice("device-001")
+		duplicate.Label = "Duplicate"
+		if err := storage.Save(duplicate); !errors.Is(err, domain.ErrDeviceExists) {
+			t.Errorf("expected ErrDeviceExists, got %v", err)
+		}
+		if got, _ := storage.GetDevice("device-001"); got.Label == "Duplicate" {
+			t.Error("expected duplicate save to leave the original device in place")
+		}
+	})
+
+	t.Run("get missing device", func(t *testing.T) {
+		storage := factory()
+
+		if _, err := storage.GetDevice("missing"); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		storage := factory()
+		device := newDevice("device-001")
+		storage.Save(device)
+
+		updated := *device
+		updated.SignatureCounter = 7
+		updated.LastSignature = "c2lnbmF0dXJlLTY="
+		if err := storage.Update(&updated); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		got, err := storage.GetDevice(device.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.SignatureCounter != 7 || got.LastSignature != updated.LastSignature {
+			t.Errorf("expected counter 7 and the new last signature, got %d and %q", got.SignatureCounter, got.LastSignature)
+		}
+	})
+
+	t.Run("update missing device", func(t *testing.T) {
+		storage := factory()
+
+		if err := storage.Update(newDevice("missing")); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+		if _, err := storage.GetDevice("missing"); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected update not to create the device, got %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		storage := factory()
+		storage.Save(newDevice("device-001"))
+		storage.Save(newDevice("device-002"))
+
+		if err := storage.Delete("device-001"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := storage.GetDevice("device-001"); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound after delete, got %v", err)
+		}
+		if _, err := storage.GetDevice("device-002"); err != nil {
+			t.Errorf("expected other device to remain, got %v", err)
+		}
+		if err := storage.Save(newDevice("device-001")); err != nil {
+			t.Errorf("expected deleted ID to be reusable, got %v", err)
+		}
+	})
+
+	t.Run("delete missing device", func(t *testing.T) {
+		storage := factory()
+
+		if err := storage.Delete("missing"); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected ErrDeviceNotFound, got %v", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		storage := factory()
+
+		devices, err := storage.GetAllDevices()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if devices == nil || len(devices) != 0 {
+			t.Errorf("expected empty non-nil list, got %v", devices)
+		}
+
+		storage.Save(newDevice("device-001"))
+		storage.Save(newDevice("device-002"))
+		storage.Save(newDevice("device-003"))
+		storage.Delete("device-002")
+
+		devices, _ = storage.GetAllDevices()
+		ids := make(map[string]bool, len(devices))
+		for _, device := range devices {
+			ids[device.ID] = true
+		}
+		if len(devices) != 2 || !ids["device-001"] || !ids["device-003"] {
+			t.Errorf("expected device-001 and device-003, got %v", ids)
+		}
+	})
+
+	t.Run("concurrent saves", func(t *testing.T) {
+		storage := factory()
+		const count = 50
+
+		var wg sync.WaitGroup
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := storage.Save(newDevice(fmt.Sprintf("device-%03d", i))); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if devices, _ := storage.GetAllDevices(); len(devices) != count {
+			t.Errorf("expected %d devices, got %d", count, len(devices))
+		}
+	})
+
+	t.Run("concurrent duplicate saves", func(t *testing.T) {
+		storage := factory()
+		const attempts = 20
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := storage.Save(newDevice("device-001")); err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if succeeded != 1 {
+			t.Errorf("expected exactly 1 successful save, got %d", succeeded)
+		}
+	})
+
+	t.Run("concurrent updates and reads", func(t *testing.T) {
+		storage := factory()
+		device := newDevice("device-001")
+		storage.Save(device)
+
+		var wg sync.WaitGroup
+		for i := 1; i <= 50; i++ {
+			wg.Add(2)
+			go func(counter int64) {
+				defer wg.Done()
+				updated := *device
+				updated.SignatureCounter = counter
+				if err := storage.Update(&updated); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			}(int64(i))
+			go func() {
+				defer wg.Done()
+				if _, err := storage.GetDevice(device.ID); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, _ := storage.GetDevice(device.ID); got.SignatureCounter < 1 || got.SignatureCounter > 50 {
+			t.Errorf("expected a counter written by one of the updates, got %d", got.SignatureCounter)
+		}
+	})
+}
+
+func newDevice(id string) *model.SignatureDevice {
+	return &model.SignatureDevice{
+		ID:            id,
+		Label:         "Conformance " + id,
+		Algorithm:     "ECC",
+		LastSignature: "aW5pdGlhbA==",
+	}
+}
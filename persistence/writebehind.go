@@ -0,0 +1,265 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	model "github.com/bayuhutajulu/signing-service/model"
+)
+
+// WriteBehindStorage decorates a DeviceStorage so Update returns as soon as the write is
+// queued. Queued writes are flushed to the backend in batches, either when batchSize devices
+// are pending or every interval, and on Close. Writes to the same device are coalesced, so
+// the backend only ever sees a device's states in the order they were written. Reads see
+// queued and in-flight writes until the backend has committed them. Queued states are
+// snapshots, so later changes to a device only reach the backend through another Update.
+type WriteBehindStorage struct {
+	backend   domain.DeviceStorage
+	batchSize int
+
+	mu       sync.Mutex
+	pending  map[string]*model.SignatureDevice
+	order    []string                          // Pending device IDs in the order they were first queued
+	inflight map[string]*model.SignatureDevice // States being written by the running flush
+
+	flushMu sync.Mutex // Serializes flushes so batches reach the backend in order
+	kick    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	closed  bool
+}
+
+// Compile-time check that WriteBehindStorage implements DeviceStorage interface.
+var _ domain.DeviceStorage = (*WriteBehindStorage)(nil)
+
+// NewWriteBehindStorage wraps backend with a write-behind buffer flushing batches of up to
+// batchSize devices, and at least every interval. Call Close to flush pending writes on shutdown.
+func NewWriteBehindStorage(backend domain.DeviceStorage, batchSize int, interval time.Duration) *WriteBehindStorage {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	s := &WriteBehindStorage{
+		backend:   backend,
+		batchSize: batchSize,
+		pending:   make(map[string]*model.SignatureDevice),
+		inflight:  make(map[string]*model.SignatureDevice),
+		kick:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go s.run(interval)
+	return s
+}
+
+// snapshotDevice copies device so it can be queued or handed out without sharing mutable
+// state. History is append-only, so capping the slice at its length is enough to freeze it.
+func snapshotDevice(device *model.SignatureDevice) *model.SignatureDevice {
+	snapshot := *device
+	snapshot.History = device.History[:len(device.History):len(device.History)]
+	return &snapshot
+}
+
+// buffered returns a snapshot of the queued or in-flight state of the device, if any.
+// Callers must hold s.mu.
+func (s *WriteBehindStorage) buffered(id string) (*model.SignatureDevice, bool) {
+	device, ok := s.pending[id]
+	if !ok {
+		device, ok = s.inflight[id]
+	}
+	if !ok {
+		return nil, false
+	}
+	return snapshotDevice(device), true
+}
+
+// run flushes pending writes whenever a batch fills up or the interval elapses.
+func (s *WriteBehindStorage) run(interval time.Duration) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.kick:
+		case <-ticker.C:
+		case <-s.done:
+			return
+		}
+		s.Flush()
+	}
+}
+
+// Save writes a new device through to the backend, so duplicate IDs are still rejected
+// synchronously. A device that only exists in the buffer counts as existing.
+func (s *WriteBehindStorage) Save(device *model.SignatureDevice) error {
+	s.mu.Lock()
+	_, queued := s.buffered(device.ID)
+	s.mu.Unlock()
+	if queued {
+		return fmt.Errorf("%w: %s", domain.ErrDeviceExists, device.ID)
+	}
+	return s.backend.Save(device)
+}
+
+// Update queues a snapshot of the device for the next flush, replacing any queued state of the
+// same device. Devices neither buffered nor present in the backend fail with ErrDeviceNotFound.
+func (s *WriteBehindStorage) Update(device *model.SignatureDevice) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("write-behind storage is closed")
+	}
+	if _, queued := s.pending[device.ID]; !queued {
+		if _, inflight := s.inflight[device.ID]; !inflight {
+			if _, err := s.backend.GetDevice(device.ID); err != nil {
+				s.mu.Unlock()
+				return err
+			}
+		}
+		s.order = append(s.order, device.ID)
+	}
+	s.pending[device.ID] = snapshotDevice(device)
+	full := len(s.order) >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// GetDevice returns a snapshot of the queued or in-flight state of the device if any, falling
+// back to the backend.
+func (s *WriteBehindStorage) GetDevice(id string) (*model.SignatureDevice, error) {
+	s.mu.Lock()
+	device, buffered := s.buffered(id)
+	s.mu.Unlock()
+	if buffered {
+		return device, nil
+	}
+	return s.backend.GetDevice(id)
+}
+
+// GetAllDevices returns the backend's devices with buffered states taking precedence.
+func (s *WriteBehindStorage) GetAllDevices() ([]*model.SignatureDevice, error) {
+	devices, err := s.backend.GetAllDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool, len(devices))
+	for i, device := range devices {
+		seen[device.ID] = true
+		if buffered, ok := s.buffered(device.ID); ok {
+			devices[i] = buffered
+		}
+	}
+	for id := range s.inflight {
+		if _, queued := s.pending[id]; !queued && !seen[id] {
+			seen[id] = true
+			devices = append(devices, snapshotDevice(s.inflight[id]))
+		}
+	}
+	for _, id := range s.order {
+		if !seen[id] {
+			devices = append(devices, snapshotDevice(s.pending[id]))
+		}
+	}
+	return devices, nil
+}
+
+// Delete drops any queued write of the device and deletes it from the backend. It waits for
+// a running flush, so a queued write can never resurrect the device afterwards. A device that
+// only exists in the buffer is deleted as well.
+func (s *WriteBehindStorage) Delete(id string) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	_, queued := s.pending[id]
+	if queued {
+		delete(s.pending, id)
+		for i, pendingID := range s.order {
+			if pendingID == id {
+				s.order = append(s.order[:i], s.order[i+1:]...)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	err := s.backend.Delete(id)
+	if queued && errors.Is(err, domain.ErrDeviceNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Flush writes all queued devices to the backend. Until a device's write commits, reads keep
+// seeing its in-flight state rather than the older one in the backend. Devices whose write
+// fails are queued again, unless a newer state was queued meanwhile or the device was deleted
+// from the backend, and the first error is returned.
+func (s *WriteBehindStorage) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	batch, order := s.pending, s.order
+	s.pending = make(map[string]*model.SignatureDevice)
+	s.order = nil
+	for id, device := range batch {
+		s.inflight[id] = device
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, id := range order {
+		device := batch[id]
+		err := s.backend.Update(device)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to flush device %s: %w", id, err)
+		}
+		s.settle(device, err)
+	}
+	return firstErr
+}
+
+// settle ends the in-flight write of a device, queuing it again if the write failed for any
+// reason but the device's deletion, unless it was updated again in the meantime.
+func (s *WriteBehindStorage) settle(device *model.SignatureDevice, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, device.ID)
+	if err == nil || errors.Is(err, domain.ErrDeviceNotFound) {
+		return
+	}
+	if _, queued := s.pending[device.ID]; queued {
+		return
+	}
+	s.pending[device.ID] = device
+	s.order = append(s.order, device.ID)
+}
+
+// Close stops the background flusher and flushes the remaining writes. Updates after Close fail.
+func (s *WriteBehindStorage) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	<-s.stopped
+	return s.Flush()
+}
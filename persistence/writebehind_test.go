@@ -0,0 +1,247 @@
+package persistence
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bayuhutajulu/signing-service/domain"
+	model "github.com/bayuhutajulu/signing-service/model"
+	"github.com/bayuhutajulu/signing-service/persistence/storagetest"
+)
+
+// recordingStorage records the counters written per device, in backend order.
+type recordingStorage struct {
+	*InMemoryStorage
+	mu      sync.Mutex
+	written map[string][]int64
+}
+
+func newRecordingStorage() *recordingStorage {
+	return &recordingStorage{InMemoryStorage: NewInMemoryStorage(), written: make(map[string][]int64)}
+}
+
+func (s *recordingStorage) Update(device *model.SignatureDevice) error {
+	s.mu.Lock()
+	s.written[device.ID] = append(s.written[device.ID], device.SignatureCounter)
+	s.mu.Unlock()
+	return s.InMemoryStorage.Update(device)
+}
+
+// withCounter returns a copy of device at the given counter, as a durable backend would store it.
+func withCounter(device *model.SignatureDevice, counter int64) *model.SignatureDevice {
+	copied := *device
+	copied.SignatureCounter = counter
+	return &copied
+}
+
+// blockingStorage holds every Update until release is closed, signalling each one on started.
+type blockingStorage struct {
+	*InMemoryStorage
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStorage) Update(device *model.SignatureDevice) error {
+	s.started <- struct{}{}
+	<-s.release
+	return s.InMemoryStorage.Update(device)
+}
+
+func TestWriteBehindStorage(t *testing.T) {
+	t.Run("writes eventually persist", func(t *testing.T) {
+		backend := NewInMemoryStorage()
+		storage := NewWriteBehindStorage(backend, 100, 10*time.Millisecond)
+		defer storage.Close()
+		device := createTestDevice("device-001", "Write Behind", "ECC")
+		storage.Save(device)
+
+		storage.Update(withCounter(device, 5))
+
+		if queued, _ := storage.GetDevice(device.ID); queued.SignatureCounter != 5 {
+			t.Errorf("expected queued counter 5 to be readable, got %d", queued.SignatureCounter)
+		}
+		deadline := time.Now().Add(time.Second)
+		for {
+			stored, _ := backend.GetDevice(device.ID)
+			if stored.SignatureCounter == 5 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected counter 5 to reach the backend, got %d", stored.SignatureCounter)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("full batch flushes without waiting for the interval", func(t *testing.T) {
+		backend := newRecordingStorage()
+		storage := NewWriteBehindStorage(backend, 2, time.Hour)
+		defer storage.Close()
+		first := createTestDevice("device-001", "First", "ECC")
+		second := createTestDevice("device-002", "Second", "ECC")
+		storage.Save(first)
+		storage.Save(second)
+
+		storage.Update(withCounter(first, 1))
+		storage.Update(withCounter(second, 1))
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			backend.mu.Lock()
+			flushed := len(backend.written)
+			backend.mu.Unlock()
+			if flushed == 2 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected 2 devices flushed to the backend, got %d", flushed)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("close flushes pending writes", func(t *testing.T) {
+		backend := NewInMemoryStorage()
+		storage := NewWriteBehindStorage(backend, 100, time.Hour)
+		device := createTestDevice("device-001", "Shutdown", "ECC")
+		storage.Save(device)
+		storage.Update(withCounter(device, 3))
+
+		if stored, _ := backend.GetDevice(device.ID); stored.SignatureCounter != 0 {
+			t.Fatalf("expected write to be buffered, got counter %d", stored.SignatureCounter)
+		}
+		if err := storage.Close(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if stored, _ := backend.GetDevice(device.ID); stored.SignatureCounter != 3 {
+			t.Errorf("expected counter 3 after close, got %d", stored.SignatureCounter)
+		}
+		if err := storage.Update(withCounter(device, 4)); err == nil {
+			t.Error("expected update after close to fail")
+		}
+	})
+
+	t.Run("per-device ordering is preserved", func(t *testing.T) {
+		backend := newRecordingStorage()
+		storage := NewWriteBehindStorage(backend, 3, time.Millisecond)
+		device := createTestDevice("device-001", "Ordering", "ECC")
+		storage.Save(device)
+
+		for counter := int64(1); counter <= 200; counter++ {
+			storage.Update(withCounter(device, counter))
+		}
+		storage.Close()
+
+		written := backend.written[device.ID]
+		for i := 1; i < len(written); i++ {
+			if written[i] <= written[i-1] {
+				t.Fatalf("expected increasing counters, got %d after %d", written[i], written[i-1])
+			}
+		}
+		if last := written[len(written)-1]; last != 200 {
+			t.Errorf("expected final counter 200, got %d", last)
+		}
+	})
+
+	t.Run("save rejects devices with a queued write", func(t *testing.T) {
+		storage := NewWriteBehindStorage(NewInMemoryStorage(), 100, time.Hour)
+		defer storage.Close()
+		device := createTestDevice("device-001", "Queued", "ECC")
+		storage.Save(device)
+		storage.Update(withCounter(device, 1))
+
+		if err := storage.Save(createTestDevice("device-001", "Duplicate", "ECC")); err == nil {
+			t.Error("expected error for existing device, got nil")
+		}
+	})
+
+	t.Run("reads see in-flight writes until they commit", func(t *testing.T) {
+		backend := &blockingStorage{InMemoryStorage: NewInMemoryStorage(), started: make(chan struct{}), release: make(chan struct{})}
+		storage := NewWriteBehindStorage(backend, 100, time.Hour)
+		device := createTestDevice("device-001", "In Flight", "ECC")
+		storage.Save(device)
+		storage.Update(withCounter(device, 7))
+
+		flushed := make(chan error)
+		go func() { flushed <- storage.Flush() }()
+		<-backend.started
+		if stored, _ := storage.GetDevice(device.ID); stored.SignatureCounter != 7 {
+			t.Errorf("expected the in-flight counter 7, got %d", stored.SignatureCounter)
+		}
+		close(backend.release)
+		if err := <-flushed; err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if stored, _ := storage.GetDevice(device.ID); stored.SignatureCounter != 7 {
+			t.Errorf("expected the committed counter 7, got %d", stored.SignatureCounter)
+		}
+		storage.Close()
+	})
+
+	t.Run("failed writes are queued again", func(t *testing.T) {
+		backend := &flakyStorage{InMemoryStorage: NewInMemoryStorage()}
+		storage := NewWriteBehindStorage(backend, 100, time.Hour)
+		device := createTestDevice("device-001", "Flaky", "ECC")
+		storage.Save(device)
+		storage.Update(withCounter(device, 2))
+
+		backend.err = errors.New("backend unavailable")
+		if err := storage.Flush(); err == nil {
+			t.Fatal("expected the flush to fail")
+		}
+		if stored, _ := storage.GetDevice(device.ID); stored.SignatureCounter != 2 {
+			t.Errorf("expected the queued counter 2 to survive the failure, got %d", stored.SignatureCounter)
+		}
+		backend.err = nil
+		if err := storage.Close(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if stored, _ := backend.GetDevice(device.ID); stored.SignatureCounter != 2 {
+			t.Errorf("expected counter 2 in the backend, got %d", stored.SignatureCounter)
+		}
+	})
+
+	t.Run("update queues a snapshot", func(t *testing.T) {
+		backend := NewInMemoryStorage()
+		storage := NewWriteBehindStorage(backend, 100, time.Hour)
+		device := createTestDevice("device-001", "Snapshot", "ECC")
+		storage.Save(device)
+		live := withCounter(device, 1)
+		storage.Update(live)
+		live.SignatureCounter = 99
+
+		if queued, _ := storage.GetDevice(device.ID); queued.SignatureCounter != 1 {
+			t.Errorf("expected the queued counter 1, got %d", queued.SignatureCounter)
+		}
+		storage.Close()
+		if stored, _ := backend.GetDevice(device.ID); stored.SignatureCounter != 1 {
+			t.Errorf("expected counter 1 in the backend, got %d", stored.SignatureCounter)
+		}
+	})
+
+	t.Run("update rejects unknown devices", func(t *testing.T) {
+		backend := NewInMemoryStorage()
+		storage := NewWriteBehindStorage(backend, 100, time.Hour)
+
+		err := storage.Update(createTestDevice("device-001", "Ghost", "ECC"))
+		if !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Fatalf("expected ErrDeviceNotFound, got %v", err)
+		}
+		storage.Close()
+
+		if _, err := backend.GetDevice("device-001"); !errors.Is(err, domain.ErrDeviceNotFound) {
+			t.Errorf("expected no device in the backend, got %v", err)
+		}
+	})
+}
+
+func TestWriteBehindStorageConformance(t *testing.T) {
+	storagetest.StorageConformanceTest(t, func() domain.DeviceStorage {
+		storage := NewWriteBehindStorage(NewInMemoryStorage(), 4, time.Millisecond)
+		t.Cleanup(func() { storage.Close() })
+		return storage
+	})
+}